@@ -0,0 +1,147 @@
+// Command go-keywordsai inspects SDK configuration and calls the KeywordsAI
+// API with it.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rizome-dev/go-keywordsai/internal/version"
+	"github.com/rizome-dev/go-keywordsai/pkg/config"
+	"github.com/rizome-dev/go-keywordsai/pkg/models"
+)
+
+// Set by the Makefile with -ldflags.
+var (
+	Version   string
+	GitCommit string
+	BuildDate string
+)
+
+const usage = `Usage: go-keywordsai [flags] <command>
+
+Commands:
+  config     show the resolved settings and where each value came from
+  profiles   list the profiles in the config file
+  models     list available models
+  version    show build information
+
+Flags:
+`
+
+// setFlags collects repeated -set key=value flags.
+type setFlags []string
+
+func (s *setFlags) String() string { return strings.Join(*s, ",") }
+
+func (s *setFlags) Set(v string) error {
+	if !strings.Contains(v, "=") {
+		return errors.New("expected key=value")
+	}
+	*s = append(*s, v)
+	return nil
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("go-keywordsai", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	file := fs.String("config", "", "config file (default $KEYWORDSAI_CONFIG_FILE or "+config.DefaultPath()+")")
+	profile := fs.String("profile", "", "profile to use (default $KEYWORDSAI_PROFILE or the file's default_profile)")
+	apiKey := fs.String("api-key", "", "API key")
+	baseURL := fs.String("base-url", "", "API base URL")
+	timeout := fs.String("timeout", "", "request timeout, e.g. 30s")
+	var sets setFlags
+	fs.Var(&sets, "set", "set any setting as key=value, e.g. retry.max_retries=3 (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	if fs.Arg(0) == "version" {
+		if Version != "" {
+			version.Version, version.GitCommit, version.BuildDate = Version, GitCommit, BuildDate
+		}
+		fmt.Fprintln(stdout, version.String())
+		return 0
+	}
+
+	opts := []config.Option{
+		config.WithProfile(*profile),
+		config.WithAPIKey(*apiKey),
+		config.WithBaseURL(*baseURL),
+		config.WithValue("timeout", *timeout),
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			opts = append(opts, config.WithFile(*file))
+		}
+	})
+	for _, s := range sets {
+		key, value, _ := strings.Cut(s, "=")
+		opts = append(opts, config.WithValue(key, value))
+	}
+	cfg, err := config.Load(opts...)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	switch fs.Arg(0) {
+	case "config":
+		file := cfg.File
+		if file == "" {
+			file = "(none)"
+		}
+		fmt.Fprintf(stdout, "file:    %s\nprofile: %s\n\n", file, cfg.Profile)
+		w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+		for _, s := range cfg.Settings() {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, s.Value, s.Source)
+		}
+		w.Flush()
+	case "profiles":
+		for _, name := range cfg.Profiles {
+			marker := " "
+			if name == cfg.Profile {
+				marker = "*"
+			}
+			fmt.Fprintf(stdout, "%s %s\n", marker, name)
+		}
+	case "models":
+		c, err := cfg.NewClient()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		list, err := models.NewService(c).List(ctx)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		for _, m := range list {
+			fmt.Fprintln(stdout, m.ID)
+		}
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n", fs.Arg(0))
+		fs.Usage()
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigCommand(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("KEYWORDSAI_API_KEY", "")
+	t.Setenv("KEYWORDSAI_PROFILE", "")
+	t.Setenv("KEYWORDSAI_CONFIG_FILE", "")
+	t.Setenv("KEYWORDSAI_TIMEOUT", "15s")
+	path := filepath.Join(dir, "config.yaml")
+	os.WriteFile(path, []byte("profiles:\n  dev:\n    api_key: dev-key-1234567890\n  prod:\n"), 0o600)
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-config", path, "-profile", "dev", "-set", "retry.max_retries=2", "config"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	rows := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) > 1 {
+			rows[fields[0]] = strings.Join(fields[1:], " ")
+		}
+	}
+	want := map[string]string{
+		"profile:":          "dev",
+		"api_key":           "********7890 file " + path + " (profile dev)",
+		"timeout":           "15s env KEYWORDSAI_TIMEOUT",
+		"retry.max_retries": "2 option retry.max_retries",
+		"retry.min_backoff": "500ms default",
+	}
+	for key, row := range want {
+		if rows[key] != row {
+			t.Errorf("%s: expected %q, got %q:\n%s", key, row, rows[key], out)
+		}
+	}
+}
+
+func TestModelsCommand(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("KEYWORDSAI_PROFILE", "")
+	t.Setenv("KEYWORDSAI_CONFIG_FILE", "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer flag-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[{"id":"gpt-4o"},{"id":"claude-3-5-sonnet"}]`))
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"-api-key", "flag-key", "-base-url", server.URL, "models"}, &stdout, &stderr)
+	if code != 0 || stdout.String() != "gpt-4o\nclaude-3-5-sonnet\n" {
+		t.Errorf("exit %d, stdout %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+
+	if code := run(context.Background(), []string{"-profile", "missing", "config"}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected an unknown profile to fail, got exit %d", code)
+	}
+}
@@ -0,0 +1,22 @@
+// Package paging holds the offset pagination helpers shared by the services
+// that iterate over list endpoints.
+package paging
+
+// DefaultPageSize is the page size used when the caller sets no limit.
+const DefaultPageSize = 100
+
+// Next works out where the page after the one at offset starts, preferring
+// the server's next_offset and otherwise advancing by the page length until
+// total is reached. It returns false when there are no more pages.
+func Next(offset, pageLen int, serverNext *int, total int) (int, bool) {
+	if pageLen == 0 {
+		return 0, false
+	}
+	if serverNext != nil {
+		return *serverNext, *serverNext > offset
+	}
+	if offset+pageLen >= total {
+		return 0, false
+	}
+	return offset + pageLen, true
+}
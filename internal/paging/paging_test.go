@@ -0,0 +1,26 @@
+package paging
+
+import "testing"
+
+func TestNext(t *testing.T) {
+	ten, five := 10, 5
+	tests := []struct {
+		name                   string
+		offset, pageLen, total int
+		serverNext             *int
+		want                   int
+		ok                     bool
+	}{
+		{name: "empty page", offset: 0, pageLen: 0, total: 50},
+		{name: "server offset", offset: 0, pageLen: 10, total: 0, serverNext: &ten, want: 10, ok: true},
+		{name: "server offset not advancing", offset: 10, pageLen: 10, total: 50, serverNext: &five},
+		{name: "advance by page", offset: 10, pageLen: 10, total: 50, want: 20, ok: true},
+		{name: "last page", offset: 40, pageLen: 10, total: 50},
+	}
+	for _, tt := range tests {
+		got, ok := Next(tt.offset, tt.pageLen, tt.serverNext, tt.total)
+		if ok != tt.ok || ok && got != tt.want {
+			t.Errorf("%s: got (%d, %v), want (%d, %v)", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
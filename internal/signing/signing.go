@@ -0,0 +1,190 @@
+// Package signing implements the HMAC-SHA256 and Ed25519 signatures exposed
+// by the shared library. Signatures are hex encoded.
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MinHMACKeyLen is the shortest HMAC key accepted, in bytes.
+const MinHMACKeyLen = 16
+
+var (
+	ErrInvalidKey         = errors.New("invalid key")
+	ErrInvalidSignature   = errors.New("malformed signature")
+	ErrVerificationFailed = errors.New("signature verification failed")
+)
+
+// ParseHMACKey loads an HMAC key given as "hex:<hex>", "base64:<base64>" or
+// raw text.
+func ParseHMACKey(s string) ([]byte, error) {
+	key, err := decodePrefixed(s)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		key = []byte(s)
+	}
+	if len(key) < MinHMACKeyLen {
+		return nil, fmt.Errorf("%w: HMAC key must be at least %d bytes", ErrInvalidKey, MinHMACKeyLen)
+	}
+	return key, nil
+}
+
+// SignHMAC returns the HMAC-SHA256 of data under key.
+func SignHMAC(key, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyHMAC checks signature against data in constant time.
+func VerifyHMAC(key, data []byte, signature string) error {
+	sig, err := hex.DecodeString(strings.TrimSpace(signature))
+	if err != nil || len(sig) != sha256.Size {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return ErrVerificationFailed
+	}
+	return nil
+}
+
+// ParseEd25519PrivateKey loads a private key from a PKCS#8 PEM block, or from
+// a 32-byte seed or 64-byte key encoded as hex or base64 (optionally with a
+// "hex:" or "base64:" prefix).
+func ParseEd25519PrivateKey(s string) (ed25519.PrivateKey, error) {
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		key, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: PEM block is not an Ed25519 private key", ErrInvalidKey)
+		}
+		return key, nil
+	}
+
+	raw, err := decodeKeyBytes(s)
+	if err != nil {
+		return nil, err
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	}
+	return nil, fmt.Errorf("%w: Ed25519 private key must be %d or %d bytes, got %d", ErrInvalidKey, ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+}
+
+// ParseEd25519PublicKey loads a public key from a PKIX PEM block or from 32
+// bytes encoded as hex or base64 (optionally with a "hex:" or "base64:"
+// prefix).
+func ParseEd25519PublicKey(s string) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode([]byte(s)); block != nil {
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		key, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: PEM block is not an Ed25519 public key", ErrInvalidKey)
+		}
+		return key, nil
+	}
+
+	raw, err := decodeKeyBytes(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: Ed25519 public key must be %d bytes, got %d", ErrInvalidKey, ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// SignEd25519 signs data with key.
+func SignEd25519(key ed25519.PrivateKey, data []byte) string {
+	return hex.EncodeToString(ed25519.Sign(key, data))
+}
+
+// VerifyEd25519 checks an Ed25519 signature over data.
+func VerifyEd25519(key ed25519.PublicKey, data []byte, signature string) error {
+	sig, err := hex.DecodeString(strings.TrimSpace(signature))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return ErrInvalidSignature
+	}
+	if !ed25519.Verify(key, data, sig) {
+		return ErrVerificationFailed
+	}
+	return nil
+}
+
+// GenerateEd25519 creates a key pair encoded as PKCS#8 and PKIX PEM blocks.
+func GenerateEd25519() (privatePEM, publicPEM string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return "", "", err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", "", err
+	}
+	privatePEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+	publicPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	return privatePEM, publicPEM, nil
+}
+
+// decodePrefixed decodes "hex:" and "base64:" values. It returns nil, nil
+// when s has neither prefix.
+func decodePrefixed(s string) ([]byte, error) {
+	var (
+		raw []byte
+		err error
+	)
+	switch {
+	case strings.HasPrefix(s, "hex:"):
+		raw, err = hex.DecodeString(strings.TrimPrefix(s, "hex:"))
+	case strings.HasPrefix(s, "base64:"):
+		raw, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(s, "base64:"))
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return raw, nil
+}
+
+// decodeKeyBytes decodes a prefixed value, or tries hex and then base64.
+func decodeKeyBytes(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if raw, err := decodePrefixed(s); raw != nil || err != nil {
+		return raw, err
+	}
+	if raw, err := hex.DecodeString(s); err == nil {
+		return raw, nil
+	}
+	if raw, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return raw, nil
+	}
+	return nil, fmt.Errorf("%w: key is not PEM, hex or base64", ErrInvalidKey)
+}
@@ -0,0 +1,117 @@
+package signing
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHMAC(t *testing.T) {
+	// RFC 4231 test case 2 uses a 4-byte key, below our minimum; test case 1
+	// uses 20 bytes of 0x0b.
+	key, err := ParseHMACKey("hex:" + strings.Repeat("0b", 20))
+	if err != nil {
+		t.Fatalf("ParseHMACKey() error = %v", err)
+	}
+	const want = "b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7"
+	sig := SignHMAC(key, []byte("Hi There"))
+	if sig != want {
+		t.Errorf("SignHMAC() = %s, want %s", sig, want)
+	}
+
+	if err := VerifyHMAC(key, []byte("Hi There"), strings.ToUpper(sig)); err != nil {
+		t.Errorf("VerifyHMAC() error = %v", err)
+	}
+	if err := VerifyHMAC(key, []byte("Hi there"), sig); !errors.Is(err, ErrVerificationFailed) {
+		t.Errorf("expected ErrVerificationFailed for altered data, got %v", err)
+	}
+	if err := VerifyHMAC(key, []byte("Hi There"), "not-hex"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+	if err := VerifyHMAC(key, []byte("Hi There"), sig[:32]); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for truncated signature, got %v", err)
+	}
+}
+
+func TestParseHMACKey(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantLen int
+		wantErr bool
+	}{
+		{in: "a plain text secret", wantLen: 19},
+		{in: "base64:" + "MDEyMzQ1Njc4OWFiY2RlZg==", wantLen: 16},
+		{in: "short", wantErr: true},
+		{in: "hex:zz", wantErr: true},
+	}
+	for _, tt := range tests {
+		key, err := ParseHMACKey(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("ParseHMACKey(%q) expected ErrInvalidKey, got %v", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || len(key) != tt.wantLen {
+			t.Errorf("ParseHMACKey(%q) = %d bytes, %v; want %d bytes", tt.in, len(key), err, tt.wantLen)
+		}
+	}
+}
+
+func TestEd25519(t *testing.T) {
+	// RFC 8032 test 1.
+	const (
+		seed = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+		pub  = "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"
+		want = "e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b"
+	)
+	priv, err := ParseEd25519PrivateKey(seed)
+	if err != nil {
+		t.Fatalf("ParseEd25519PrivateKey() error = %v", err)
+	}
+	sig := SignEd25519(priv, nil)
+	if sig != want {
+		t.Errorf("SignEd25519() = %s, want %s", sig, want)
+	}
+
+	pubKey, err := ParseEd25519PublicKey("hex:" + pub)
+	if err != nil {
+		t.Fatalf("ParseEd25519PublicKey() error = %v", err)
+	}
+	if err := VerifyEd25519(pubKey, nil, sig); err != nil {
+		t.Errorf("VerifyEd25519() error = %v", err)
+	}
+	if err := VerifyEd25519(pubKey, []byte("x"), sig); !errors.Is(err, ErrVerificationFailed) {
+		t.Errorf("expected ErrVerificationFailed, got %v", err)
+	}
+	if err := VerifyEd25519(pubKey, nil, "abcd"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestGenerateEd25519PEM(t *testing.T) {
+	privPEM, pubPEM, err := GenerateEd25519()
+	if err != nil {
+		t.Fatalf("GenerateEd25519() error = %v", err)
+	}
+	priv, err := ParseEd25519PrivateKey(privPEM)
+	if err != nil {
+		t.Fatalf("ParseEd25519PrivateKey(PEM) error = %v", err)
+	}
+	pub, err := ParseEd25519PublicKey(pubPEM)
+	if err != nil {
+		t.Fatalf("ParseEd25519PublicKey(PEM) error = %v", err)
+	}
+
+	data := []byte("payload\x00with a NUL byte")
+	if err := VerifyEd25519(pub, data, SignEd25519(priv, data)); err != nil {
+		t.Errorf("round trip failed: %v", err)
+	}
+
+	if _, err := ParseEd25519PublicKey(privPEM); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey for private PEM as public key, got %v", err)
+	}
+	if _, err := ParseEd25519PrivateKey("hex:abcd"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey for short key, got %v", err)
+	}
+}
@@ -2,12 +2,15 @@
 package keywordsai
 
 import (
+	"github.com/rizome-dev/go-keywordsai/pkg/analytics"
 	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/customers"
 	"github.com/rizome-dev/go-keywordsai/pkg/integrations"
 	"github.com/rizome-dev/go-keywordsai/pkg/keys"
 	"github.com/rizome-dev/go-keywordsai/pkg/logs"
 	"github.com/rizome-dev/go-keywordsai/pkg/models"
 	"github.com/rizome-dev/go-keywordsai/pkg/prompts"
+	"github.com/rizome-dev/go-keywordsai/pkg/threads"
 	"github.com/rizome-dev/go-keywordsai/pkg/types"
 	"github.com/rizome-dev/go-keywordsai/pkg/utils"
 )
@@ -17,6 +20,10 @@ type (
 	RequestLog        = types.RequestLog
 	Message           = types.Message
 	LogFilter         = types.LogFilter
+	Customer          = types.Customer
+	CustomerFilter    = types.CustomerFilter
+	Thread            = types.Thread
+	ThreadFilter      = types.ThreadFilter
 	Prompt            = types.Prompt
 	PromptVersion     = types.PromptVersion
 	Model             = types.Model
@@ -45,6 +52,9 @@ type SDK struct {
 	Models       *models.Service
 	Keys         *keys.Service
 	Integrations *integrations.Service
+	Threads      *threads.Service
+	Customers    *customers.Service
+	Analytics    *analytics.Service
 }
 
 // New creates a new KeywordsAI SDK instance with all services initialized.
@@ -54,8 +64,12 @@ type SDK struct {
 //   sdk := keywordsai.New("api-key")           // Uses provided API key
 //   sdk := keywordsai.New(client.WithTimeout(30*time.Second))  // With options
 func New(params ...interface{}) *SDK {
-	c := client.New(params...)
-	
+	return NewWithClient(client.New(params...))
+}
+
+// NewWithClient creates an SDK instance around an existing client, such as
+// one created by config.Config.NewClient.
+func NewWithClient(c *client.Client) *SDK {
 	return &SDK{
 		Client:       c,
 		Logs:         logs.NewService(c),
@@ -63,5 +77,8 @@ func New(params ...interface{}) *SDK {
 		Models:       models.NewService(c),
 		Keys:         keys.NewService(c),
 		Integrations: integrations.NewService(c),
+		Threads:      threads.NewService(c),
+		Customers:    customers.NewService(c),
+		Analytics:    analytics.NewService(c),
 	}
 }
\ No newline at end of file
@@ -40,6 +40,15 @@ func TestNew(t *testing.T) {
 				if sdk.Integrations == nil {
 					t.Fatal("expected non-nil Integrations service")
 				}
+				if sdk.Threads == nil {
+					t.Fatal("expected non-nil Threads service")
+				}
+				if sdk.Customers == nil {
+					t.Fatal("expected non-nil Customers service")
+				}
+				if sdk.Analytics == nil {
+					t.Fatal("expected non-nil Analytics service")
+				}
 			},
 		},
 		{
@@ -92,6 +101,8 @@ func TestTypeAliases(t *testing.T) {
 	var _ RequestLog = RequestLog{}
 	var _ Message = Message{}
 	var _ LogFilter = LogFilter{}
+	var _ Thread = Thread{}
+	var _ ThreadFilter = ThreadFilter{}
 	var _ Prompt = Prompt{}
 	var _ PromptVersion = PromptVersion{}
 	var _ Model = Model{}
@@ -0,0 +1,78 @@
+package analytics
+
+import (
+	"context"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// Metrics accepted by AnalyticsQuery.Metrics. Tokens fills the prompt,
+// completion and total token fields, Latency the average and percentiles,
+// and ErrorRate both the error count and rate.
+const (
+	MetricRequests  = "requests"
+	MetricTokens    = "tokens"
+	MetricCost      = "cost"
+	MetricLatency   = "latency"
+	MetricErrorRate = "error_rate"
+)
+
+// Dimensions accepted by AnalyticsQuery.GroupBy. Each series' Group is keyed
+// by these names.
+const (
+	GroupByModel    = "model"
+	GroupByCustomer = "customer_identifier"
+	GroupByPrompt   = "prompt_id"
+	GroupByTag      = "tag"
+)
+
+// Time buckets accepted by AnalyticsQuery.Interval. Without an interval each
+// series has a single point covering the whole filter range.
+const (
+	IntervalHour  = "hour"
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// AllMetrics lists every metric, in CSV column order.
+var AllMetrics = []string{MetricRequests, MetricTokens, MetricCost, MetricLatency, MetricErrorRate}
+
+type Service struct {
+	client *client.Client
+}
+
+func NewService(client *client.Client) *Service {
+	return &Service{client: client}
+}
+
+// Query returns aggregated metrics for the logs matching q.Filter, one series
+// per combination of q.GroupBy values. All metrics are returned when
+// q.Metrics is empty.
+func (s *Service) Query(ctx context.Context, q *types.AnalyticsQuery) (*types.AnalyticsResponse, error) {
+	req := types.AnalyticsQuery{}
+	if q != nil {
+		req = *q
+	}
+	if len(req.Metrics) == 0 {
+		req.Metrics = AllMetrics
+	}
+	var result types.AnalyticsResponse
+	return &result, s.client.Post(ctx, "/api/analytics/query", &req, &result)
+}
+
+// TimeSeries returns the given metrics for logs matching filter, bucketed by
+// interval and split by groupBy. An empty interval returns a single point per
+// series.
+func (s *Service) TimeSeries(ctx context.Context, filter *types.LogFilter, interval string, groupBy []string, metrics ...string) (*types.AnalyticsResponse, error) {
+	q := &types.AnalyticsQuery{
+		Filter:  filter,
+		Metrics: metrics,
+		GroupBy: groupBy,
+	}
+	if interval != "" {
+		q.Interval = &interval
+	}
+	return s.Query(ctx, q)
+}
@@ -0,0 +1,90 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+func TestTimeSeries(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/analytics/query" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		filters, _ := body["filters"].(map[string]interface{})
+		if filters["model"] != "gpt-4o" || filters["start_time"] != "2024-03-01T00:00:00Z" {
+			t.Errorf("Expected LogFilter fields in filters, got %v", filters)
+		}
+		if body["interval"] != IntervalDay {
+			t.Errorf("Expected interval=day, got %v", body["interval"])
+		}
+		if groups, _ := body["group_by"].([]interface{}); len(groups) != 1 || groups[0] != GroupByCustomer {
+			t.Errorf("Unexpected group_by: %v", body["group_by"])
+		}
+		if metrics, _ := body["metrics"].([]interface{}); len(metrics) != 2 {
+			t.Errorf("Unexpected metrics: %v", body["metrics"])
+		}
+
+		w.Write([]byte(`{
+			"interval": "day",
+			"series": [{
+				"group": {"customer_identifier": "acme"},
+				"points": [
+					{"timestamp": "2024-03-01T00:00:00Z", "requests": 10, "cost": 0.5, "latency_p95": 1.2},
+					{"timestamp": "2024-03-02T00:00:00Z", "requests": 4, "cost": 0.25, "latency_p95": 0.9}
+				]
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	s := NewService(client.New("test-key", client.WithBaseURL(server.URL)))
+	resp, err := s.TimeSeries(context.Background(),
+		&types.LogFilter{Model: utils.String("gpt-4o"), StartTime: &start},
+		IntervalDay, []string{GroupByCustomer}, MetricCost, MetricLatency)
+	if err != nil {
+		t.Fatalf("TimeSeries() error = %v", err)
+	}
+	if len(resp.Series) != 1 || resp.Series[0].Group[GroupByCustomer] != "acme" {
+		t.Fatalf("Unexpected series: %+v", resp.Series)
+	}
+	points := resp.Series[0].Points
+	if len(points) != 2 || points[1].Cost != 0.25 || points[0].LatencyP95 != 1.2 || !points[0].Timestamp.Equal(start) {
+		t.Errorf("Unexpected points: %+v", points)
+	}
+}
+
+func TestQueryDefaultsToAllMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q types.AnalyticsQuery
+		json.NewDecoder(r.Body).Decode(&q)
+		if len(q.Metrics) != len(AllMetrics) {
+			t.Errorf("Expected all metrics, got %v", q.Metrics)
+		}
+		if q.Filter != nil || q.Interval != nil {
+			t.Errorf("Expected no filter or interval, got %+v", q)
+		}
+		w.Write([]byte(`{"series": []}`))
+	}))
+	defer server.Close()
+
+	s := NewService(client.New("test-key", client.WithBaseURL(server.URL)))
+	if _, err := s.Query(context.Background(), nil); err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if _, err := s.TimeSeries(context.Background(), nil, "", nil); err != nil {
+		t.Fatalf("TimeSeries() error = %v", err)
+	}
+}
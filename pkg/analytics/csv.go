@@ -0,0 +1,117 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// metricColumns maps each metric to its CSV columns and values.
+var metricColumns = map[string][]struct {
+	name  string
+	value func(p *types.AnalyticsPoint) string
+}{
+	MetricRequests: {
+		{"requests", func(p *types.AnalyticsPoint) string { return strconv.FormatInt(p.Requests, 10) }},
+	},
+	MetricTokens: {
+		{"prompt_tokens", func(p *types.AnalyticsPoint) string { return strconv.FormatInt(p.PromptTokens, 10) }},
+		{"completion_tokens", func(p *types.AnalyticsPoint) string { return strconv.FormatInt(p.CompletionTokens, 10) }},
+		{"total_tokens", func(p *types.AnalyticsPoint) string { return strconv.FormatInt(p.TotalTokens, 10) }},
+	},
+	MetricCost: {
+		{"cost", func(p *types.AnalyticsPoint) string { return formatFloat(p.Cost) }},
+	},
+	MetricLatency: {
+		{"latency_avg", func(p *types.AnalyticsPoint) string { return formatFloat(p.LatencyAvg) }},
+		{"latency_p50", func(p *types.AnalyticsPoint) string { return formatFloat(p.LatencyP50) }},
+		{"latency_p90", func(p *types.AnalyticsPoint) string { return formatFloat(p.LatencyP90) }},
+		{"latency_p95", func(p *types.AnalyticsPoint) string { return formatFloat(p.LatencyP95) }},
+		{"latency_p99", func(p *types.AnalyticsPoint) string { return formatFloat(p.LatencyP99) }},
+	},
+	MetricErrorRate: {
+		{"errors", func(p *types.AnalyticsPoint) string { return strconv.FormatInt(p.Errors, 10) }},
+		{"error_rate", func(p *types.AnalyticsPoint) string { return formatFloat(p.ErrorRate) }},
+	},
+}
+
+// Records flattens resp into CSV rows: a header, then one row per point with
+// the timestamp, each group dimension and the columns of the given metrics
+// (all metrics when none are given). Group columns are sorted by name; series
+// missing a dimension leave it empty. A nil resp yields only the header.
+func Records(resp *types.AnalyticsResponse, metrics ...string) ([][]string, error) {
+	if resp == nil {
+		resp = &types.AnalyticsResponse{}
+	}
+	if len(metrics) == 0 {
+		metrics = AllMetrics
+	}
+	for _, m := range metrics {
+		if _, ok := metricColumns[m]; !ok {
+			return nil, fmt.Errorf("analytics: unknown metric %q", m)
+		}
+	}
+
+	var dims []string
+	for _, series := range resp.Series {
+		for dim := range series.Group {
+			if !slices.Contains(dims, dim) {
+				dims = append(dims, dim)
+			}
+		}
+	}
+	slices.Sort(dims)
+
+	header := append([]string{"timestamp"}, dims...)
+	for _, m := range metrics {
+		for _, col := range metricColumns[m] {
+			header = append(header, col.name)
+		}
+	}
+
+	records := [][]string{header}
+	for _, series := range resp.Series {
+		for i := range series.Points {
+			p := &series.Points[i]
+			row := make([]string, 0, len(header))
+			if p.Timestamp.IsZero() {
+				row = append(row, "")
+			} else {
+				row = append(row, p.Timestamp.UTC().Format(time.RFC3339))
+			}
+			for _, dim := range dims {
+				row = append(row, series.Group[dim])
+			}
+			for _, m := range metrics {
+				for _, col := range metricColumns[m] {
+					row = append(row, col.value(p))
+				}
+			}
+			records = append(records, row)
+		}
+	}
+	return records, nil
+}
+
+// WriteCSV writes resp to w as CSV; see Records for the layout.
+func WriteCSV(w io.Writer, resp *types.AnalyticsResponse, metrics ...string) error {
+	records, err := Records(resp, metrics...)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(records); err != nil {
+		return fmt.Errorf("analytics: failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// formatFloat avoids exponent notation so spreadsheets read values exactly.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,56 @@
+package analytics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+func TestWriteCSV(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	resp := &types.AnalyticsResponse{
+		Series: []types.AnalyticsSeries{
+			{
+				Group:  map[string]string{"model": "gpt-4o", "customer_identifier": "acme, inc"},
+				Points: []types.AnalyticsPoint{{Timestamp: day, Requests: 3, Cost: 0.0000125}},
+			},
+			{
+				Group:  map[string]string{"model": "gpt-4o-mini"},
+				Points: []types.AnalyticsPoint{{Requests: 7, Cost: 12}},
+			},
+		},
+	}
+
+	var b strings.Builder
+	if err := WriteCSV(&b, resp, MetricRequests, MetricCost); err != nil {
+		t.Fatal(err)
+	}
+	want := "timestamp,customer_identifier,model,requests,cost\n" +
+		"2024-03-01T00:00:00Z,\"acme, inc\",gpt-4o,3,0.0000125\n" +
+		",,gpt-4o-mini,7,12\n"
+	if b.String() != want {
+		t.Errorf("Unexpected CSV:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestRecordsColumns(t *testing.T) {
+	records, err := Records(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected only a header, got %d records", len(records))
+	}
+	header := strings.Join(records[0], ",")
+	want := "timestamp,requests,prompt_tokens,completion_tokens,total_tokens,cost," +
+		"latency_avg,latency_p50,latency_p90,latency_p95,latency_p99,errors,error_rate"
+	if header != want {
+		t.Errorf("Unexpected header %s", header)
+	}
+
+	if _, err := Records(&types.AnalyticsResponse{}, "throughput"); err == nil {
+		t.Error("Expected error for unknown metric")
+	}
+}
@@ -0,0 +1,299 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets requests through and counts failures.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails requests immediately with a *CircuitOpenError.
+	BreakerOpen
+	// BreakerHalfOpen lets a few trial requests through to decide whether
+	// to close again.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// ErrCircuitOpen matches every *CircuitOpenError with errors.Is.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned without sending a request while the breaker
+// of its endpoint family is open.
+type CircuitOpenError struct {
+	Family Family
+	// Until is when the breaker lets a trial request through.
+	Until time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("KeywordsAI %s endpoints unavailable: circuit breaker is open until %s", e.Family, e.Until.Format(time.RFC3339))
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitBreaker configures WithCircuitBreaker. Zero values use the
+// defaults noted on each field.
+type CircuitBreaker struct {
+	// FailureRate opens the breaker once this share of requests in the
+	// current window failed. Default 0.5.
+	FailureRate float64
+	// MinRequests is how many requests a window needs before FailureRate is
+	// applied. Default 10.
+	MinRequests int
+	// Window is how long failures are counted before the counts reset.
+	// Default 30s.
+	Window time.Duration
+	// SlowCall counts requests slower than this as failures. Zero disables
+	// the latency threshold.
+	SlowCall time.Duration
+	// OpenTimeout is how long the breaker stays open before trial requests.
+	// Default 30s.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is how many trial requests must succeed to close the
+	// breaker. Default 1.
+	HalfOpenRequests int
+	// OnStateChange is called on every transition, outside the breaker's
+	// lock.
+	OnStateChange func(family Family, from, to BreakerState)
+}
+
+// WithCircuitBreaker adds a circuit breaker per endpoint family. Transport
+// errors, 5xx and 429 responses and slow calls count as failures; other
+// 4xx responses and requests canceled by the caller do not.
+func WithCircuitBreaker(config CircuitBreaker) Option {
+	return func(c *Client) {
+		c.breaker = newBreaker(config)
+	}
+}
+
+type breaker struct {
+	config CircuitBreaker
+	now    func() time.Time
+
+	mu       sync.Mutex
+	circuits map[Family]*circuit
+}
+
+type circuit struct {
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	trials      int // half-open requests in flight
+	successes   int // half-open requests that succeeded
+}
+
+func newBreaker(config CircuitBreaker) *breaker {
+	if config.FailureRate <= 0 {
+		config.FailureRate = 0.5
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.Window <= 0 {
+		config.Window = 30 * time.Second
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+	return &breaker{config: config, now: time.Now, circuits: make(map[Family]*circuit)}
+}
+
+type transition struct {
+	family   Family
+	from, to BreakerState
+}
+
+// allow reports whether req may be sent. The returned function records the
+// outcome and must be called exactly once. A nil breaker allows everything.
+func (b *breaker) allow(req *http.Request) (func(resp *http.Response, err error, latency time.Duration), error) {
+	if b == nil {
+		return func(*http.Response, error, time.Duration) {}, nil
+	}
+	f := EndpointFamily(req.URL.Path)
+	now := b.now()
+
+	b.mu.Lock()
+	c, ok := b.circuits[f]
+	if !ok {
+		c = &circuit{windowStart: now}
+		b.circuits[f] = c
+	}
+	changed := b.expire(f, c, now)
+	var err error
+	switch c.state {
+	case BreakerOpen:
+		err = &CircuitOpenError{Family: f, Until: c.openedAt.Add(b.config.OpenTimeout)}
+	case BreakerHalfOpen:
+		if c.trials+c.successes >= b.config.HalfOpenRequests {
+			err = &CircuitOpenError{Family: f, Until: now}
+		} else {
+			c.trials++
+		}
+	}
+	trial := c.state == BreakerHalfOpen && err == nil
+	b.mu.Unlock()
+	b.notify(changed)
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func(resp *http.Response, err error, latency time.Duration) {
+		once.Do(func() {
+			b.record(f, trial, outcome(req.Context(), resp, err, latency, b.config.SlowCall))
+		})
+	}, nil
+}
+
+// check returns a *CircuitOpenError if req's circuit opened after allow let
+// it through. A nil breaker allows everything.
+func (b *breaker) check(req *http.Request) error {
+	if b == nil {
+		return nil
+	}
+	f := EndpointFamily(req.URL.Path)
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[f]; ok && c.state == BreakerOpen && now.Sub(c.openedAt) < b.config.OpenTimeout {
+		return &CircuitOpenError{Family: f, Until: c.openedAt.Add(b.config.OpenTimeout)}
+	}
+	return nil
+}
+
+type callOutcome int
+
+const (
+	callIgnored callOutcome = iota
+	callSucceeded
+	callFailed
+)
+
+func outcome(ctx context.Context, resp *http.Response, err error, latency, slow time.Duration) callOutcome {
+	if ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return callIgnored
+	}
+	if err != nil {
+		return callFailed
+	}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return callFailed
+	}
+	if slow > 0 && latency > slow {
+		return callFailed
+	}
+	return callSucceeded
+}
+
+func (b *breaker) record(f Family, trial bool, result callOutcome) {
+	now := b.now()
+	b.mu.Lock()
+	c := b.circuits[f]
+	var changed []transition
+
+	if trial {
+		c.trials--
+		// A trial that was overtaken by another trial's failure only
+		// counts if the circuit is still half-open.
+		if c.state == BreakerHalfOpen {
+			switch result {
+			case callFailed:
+				changed = append(changed, b.set(f, c, BreakerOpen, now))
+			case callSucceeded:
+				c.successes++
+				if c.successes >= b.config.HalfOpenRequests {
+					changed = append(changed, b.set(f, c, BreakerClosed, now))
+				}
+			}
+		}
+	} else if c.state == BreakerClosed && result != callIgnored {
+		if now.Sub(c.windowStart) >= b.config.Window {
+			c.windowStart, c.requests, c.failures = now, 0, 0
+		}
+		c.requests++
+		if result == callFailed {
+			c.failures++
+		}
+		if c.requests >= b.config.MinRequests && float64(c.failures)/float64(c.requests) >= b.config.FailureRate {
+			changed = append(changed, b.set(f, c, BreakerOpen, now))
+		}
+	}
+	b.mu.Unlock()
+	b.notify(changed)
+}
+
+// expire moves c from open to half-open once OpenTimeout has passed. b.mu
+// must be held.
+func (b *breaker) expire(f Family, c *circuit, now time.Time) []transition {
+	if c.state == BreakerOpen && now.Sub(c.openedAt) >= b.config.OpenTimeout {
+		return []transition{b.set(f, c, BreakerHalfOpen, now)}
+	}
+	return nil
+}
+
+// set moves c to state and resets its counters. b.mu must be held.
+func (b *breaker) set(f Family, c *circuit, state BreakerState, now time.Time) transition {
+	t := transition{family: f, from: c.state, to: state}
+	c.state = state
+	c.windowStart, c.requests, c.failures = now, 0, 0
+	c.successes = 0
+	if state == BreakerOpen {
+		c.openedAt = now
+	}
+	return t
+}
+
+func (b *breaker) notify(changed []transition) {
+	if b.config.OnStateChange == nil {
+		return
+	}
+	for _, t := range changed {
+		b.config.OnStateChange(t.family, t.from, t.to)
+	}
+}
+
+// BreakerState returns the state of family's circuit breaker. An open
+// breaker whose OpenTimeout has passed is reported, and moved, as half-open.
+// Without WithCircuitBreaker it is always BreakerClosed.
+func (c *Client) BreakerState(family Family) BreakerState {
+	b := c.breaker
+	if b == nil {
+		return BreakerClosed
+	}
+	b.mu.Lock()
+	circuit, ok := b.circuits[family]
+	if !ok {
+		b.mu.Unlock()
+		return BreakerClosed
+	}
+	changed := b.expire(family, circuit, b.now())
+	state := circuit.state
+	b.mu.Unlock()
+	b.notify(changed)
+	return state
+}
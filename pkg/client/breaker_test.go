@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type transitions struct {
+	mu  sync.Mutex
+	got []string
+}
+
+func (r *transitions) record(f Family, from, to BreakerState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.got = append(r.got, string(f)+":"+from.String()+"->"+to.String())
+}
+
+func (r *transitions) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fmt.Sprint(r.got)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var status atomic.Int32
+	var calls atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(int(status.Load()))
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	rec := &transitions{}
+	c := New("test-key", WithBaseURL(server.URL), WithCircuitBreaker(CircuitBreaker{
+		MinRequests:   4,
+		OpenTimeout:   time.Minute,
+		OnStateChange: rec.record,
+	}))
+	now := time.Now()
+	c.breaker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// 4xx responses are the caller's fault and do not count.
+	status.Store(http.StatusNotFound)
+	for range 4 {
+		c.Get(ctx, "/api/models", nil)
+	}
+	if c.BreakerState(FamilyDefault) != BreakerClosed {
+		t.Fatal("expected 4xx responses not to open the breaker")
+	}
+
+	status.Store(http.StatusInternalServerError)
+	for range 4 {
+		c.Get(ctx, "/api/request-logs", nil)
+	}
+	if c.BreakerState(FamilyLogs) != BreakerOpen {
+		t.Fatalf("expected the logs breaker to open, got %s", c.BreakerState(FamilyLogs))
+	}
+
+	calls.Store(0)
+	err := c.Get(ctx, "/api/request-logs", nil)
+	var open *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &open) || open.Family != FamilyLogs || !open.Until.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected a CircuitOpenError, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Error("expected an open breaker not to send requests")
+	}
+	if c.Get(ctx, "/api/models", nil); calls.Load() != 1 {
+		t.Error("expected other families to be unaffected")
+	}
+
+	// A failed trial opens the breaker again; a successful one closes it.
+	now = now.Add(time.Minute)
+	c.Get(ctx, "/api/request-logs", nil)
+	if c.BreakerState(FamilyLogs) != BreakerOpen {
+		t.Fatal("expected a failed trial to reopen the breaker")
+	}
+	now = now.Add(time.Minute)
+	if c.BreakerState(FamilyLogs) != BreakerHalfOpen {
+		t.Fatal("expected the breaker to report half-open once the timeout passed")
+	}
+	status.Store(http.StatusOK)
+	if err := c.Get(ctx, "/api/request-logs", nil); err != nil {
+		t.Fatal(err)
+	}
+	if c.BreakerState(FamilyLogs) != BreakerClosed {
+		t.Fatal("expected a successful trial to close the breaker")
+	}
+
+	want := "[logs:closed->open logs:open->half-open logs:half-open->open logs:open->half-open logs:half-open->closed]"
+	if rec.String() != want {
+		t.Errorf("unexpected transitions:\n got %s\nwant %s", rec, want)
+	}
+}
+
+func TestCircuitBreakerSlowCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := New("test-key", WithBaseURL(server.URL), WithCircuitBreaker(CircuitBreaker{
+		MinRequests: 2,
+		SlowCall:    10 * time.Millisecond,
+	}))
+	for range 2 {
+		if err := c.Get(context.Background(), "/api/prompts/", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.BreakerState(FamilyPrompts) != BreakerOpen {
+		t.Error("expected slow calls to open the breaker")
+	}
+}
+
+func TestCircuitBreakerIgnoresCanceledRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	c := New("test-key", WithBaseURL(server.URL), WithCircuitBreaker(CircuitBreaker{MinRequests: 1}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := c.Get(ctx, "/api/models", nil); err == nil {
+		t.Fatal("expected the request to fail")
+	}
+	if c.BreakerState(FamilyDefault) != BreakerClosed {
+		t.Error("expected requests canceled by the caller not to count")
+	}
+}
+
+func TestCircuitBreakerFailsFastBeforeRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	c := New("test-key", WithBaseURL(server.URL),
+		WithCircuitBreaker(CircuitBreaker{MinRequests: 1}),
+		WithRateLimit(RateLimit{MaxInFlight: 1}))
+	c.Get(context.Background(), "/api/request-logs", nil)
+	if c.BreakerState(FamilyLogs) != BreakerOpen {
+		t.Fatal("expected the breaker to open")
+	}
+
+	// With every in-flight slot taken, an open breaker still fails at once.
+	c.limiter.inFlight <- struct{}{}
+	defer func() { <-c.limiter.inFlight }()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.Get(ctx, "/api/request-logs", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen without waiting, got %v", err)
+	}
+}
+
+func TestCircuitBreakerOpenedWhileWaiting(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	c := New("test-key", WithBaseURL(server.URL),
+		WithCircuitBreaker(CircuitBreaker{OpenTimeout: time.Minute}),
+		WithRateLimit(RateLimit{Default: Limit{RequestsPerSecond: 10, RequestBurst: 1}}))
+	c.breaker.now = func() time.Time { return now }
+	// Another request opens the breaker while this one waits for tokens.
+	c.limiter.now = func() time.Time {
+		c.breaker.mu.Lock()
+		if circuit := c.breaker.circuits[FamilyDefault]; circuit.state == BreakerClosed {
+			c.breaker.set(FamilyDefault, circuit, BreakerOpen, now)
+		}
+		c.breaker.mu.Unlock()
+		return now
+	}
+
+	if err := c.Get(context.Background(), "/api/models", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Error("expected the request not to be sent")
+	}
+	if d := c.limiter.family(FamilyDefault).requests.reserve(now, 1, 1); d != 0 {
+		t.Errorf("expected the request token to be refunded, got a %v wait", d)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,9 +18,13 @@ const (
 )
 
 type Client struct {
-	httpClient *http.Client
-	baseURL    string
-	apiKey     string
+	httpClient  *http.Client
+	baseURL     string
+	apiKey      string
+	limiter     *rateLimiter
+	breaker     *breaker
+	credentials *credentialsCache
+	retry       Retry
 }
 
 type Option func(*Client)
@@ -36,6 +41,13 @@ func WithBaseURL(baseURL string) Option {
 	}
 }
 
+// WithAPIKey sets the API key, taking precedence over a key passed to New.
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
 func WithTimeout(timeout time.Duration) Option {
 	return func(c *Client) {
 		c.httpClient.Timeout = timeout
@@ -48,6 +60,7 @@ func WithTimeout(timeout time.Duration) Option {
 //   client.New("api-key")           // Uses provided API key
 //   client.New(client.WithTimeout(30*time.Second))  // With options only
 //   client.New("api-key", client.WithTimeout(30*time.Second))  // With API key and options
+// To load settings from config file profiles, see package config.
 func New(params ...interface{}) *Client {
 	var apiKey string
 	var opts []Option
@@ -93,42 +106,169 @@ func New(params ...interface{}) *Client {
 }
 
 func (c *Client) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	resp, err := c.doJSON(ctx, method, path, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return decodeResponse(resp.Body, result)
+}
+
+// decodeResponse decodes a JSON body into result. Non-JSON bodies such as
+// plain text or subtitles are read raw when result is a *string, *[]byte or
+// io.Writer.
+func decodeResponse(body io.Reader, result interface{}) error {
+	var err error
+	switch r := result.(type) {
+	case nil:
+		return nil
+	case *string:
+		var b []byte
+		b, err = io.ReadAll(body)
+		*r = string(b)
+	case *[]byte:
+		*r, err = io.ReadAll(body)
+	case io.Writer:
+		_, err = io.Copy(r, body)
+	default:
+		if err := json.NewDecoder(body).Decode(result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	return nil
+}
+
+// doJSON sends body as JSON and returns the successful response unread.
+func (c *Client) doJSON(ctx context.Context, method, path string, body interface{}, accept string) (*http.Response, error) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
-			return fmt.Errorf("failed to marshal request body: %w", err)
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
 		reqBody = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	req, err := c.newRequest(ctx, method, path, reqBody)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+
+	return c.send(req)
+}
 
+// newRequest creates an authenticated request for path.
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	return req, nil
+}
 
-	resp, err := c.httpClient.Do(req)
+// send executes req. Error responses are consumed and returned as *APIError;
+// on success the caller must close the response body. With WithCredentials,
+// a 401 is retried once with refreshed credentials; with WithRetry, transient
+// failures are retried.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.credentials == nil {
+		return c.sendRetrying(req)
+	}
+	key, err := c.credentials.key(req.Context())
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("failed to get credentials: %w", err)
 	}
-	defer resp.Body.Close()
+	req.Header.Set("Authorization", "Bearer "+key)
+	resp, err := c.sendRetrying(req)
 
-	if resp.StatusCode >= 400 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return parseAPIError(resp.StatusCode, bodyBytes)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	// Streamed bodies cannot be sent again.
+	if req.Body != nil && req.GetBody == nil {
+		return nil, err
+	}
+	newKey, changed, refreshErr := c.credentials.refresh(req.Context(), key)
+	if refreshErr != nil || !changed {
+		return nil, err
 	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, refreshErr = req.GetBody(); refreshErr != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", "Bearer "+newKey)
+	return c.sendRetrying(retry)
+}
 
-	if result != nil {
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+func (c *Client) sendOnce(req *http.Request) (*http.Response, error) {
+	// Check the breaker before queueing on the limiter so an open breaker
+	// fails fast, and again afterwards in case it opened while waiting.
+	done, err := c.breaker.allow(req)
+	if err != nil {
+		return nil, err
+	}
+	release, err := c.limiter.wait(req)
+	if err != nil {
+		done(nil, err, 0)
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if err := c.breaker.check(req); err != nil {
+		c.limiter.refund(req)
+		release()
+		done(nil, err, 0)
+		return nil, err
+	}
+	httpClient := c.httpClient
+	if streaming, _ := req.Context().Value(streamKey{}).(bool); streaming && httpClient.Timeout > 0 {
+		// Timeout bounds the wait for the response headers only; the body
+		// may take as long as the caller's context allows.
+		unbounded := *httpClient
+		unbounded.Timeout = 0
+		httpClient = &unbounded
+		ctx, cancel := context.WithCancel(req.Context())
+		timer := time.AfterFunc(c.httpClient.Timeout, cancel)
+		req = req.WithContext(ctx)
+		releaseLimiter := release
+		release = func() {
+			cancel()
+			releaseLimiter()
 		}
+		defer timer.Stop()
 	}
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	done(resp, err, time.Since(start))
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	c.limiter.observe(req, resp)
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
 
-	return nil
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		err := parseAPIError(resp.StatusCode, bodyBytes)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if until, ok := resetTime(resp.Header, time.Now()); ok {
+				err.(*APIError).retryAt = until
+			}
+		}
+		return nil, err
+	}
+
+	return resp, nil
 }
 
 func (c *Client) Get(ctx context.Context, path string, result interface{}) error {
@@ -161,11 +301,29 @@ func (c *Client) Delete(ctx context.Context, path string, result interface{}) er
 	return c.do(ctx, http.MethodDelete, path, nil, result)
 }
 
+// streamKey marks requests made by Stream.
+type streamKey struct{}
+
+// Stream sends body as JSON and returns the response without reading it, for
+// endpoints that return large or non-JSON payloads such as audio. Error
+// responses are returned as *APIError. The caller must close the response body.
+// The client's timeout only bounds the wait for the response headers; reading
+// the body is bounded by ctx.
+func (c *Client) Stream(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	return c.doJSON(context.WithValue(ctx, streamKey{}, true), method, path, body, "*/*")
+}
+
 func (c *Client) BaseURL() string {
 	return c.baseURL
 }
 
+// APIKey returns the key requests are sent with. With WithCredentials it is
+// the provider's current key, or "" if it cannot be retrieved.
 func (c *Client) APIKey() string {
+	if c.credentials != nil {
+		key, _ := c.credentials.key(context.Background())
+		return key
+	}
 	return c.apiKey
 }
 
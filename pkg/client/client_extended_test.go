@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -234,4 +236,49 @@ func TestPostMultipartError(t *testing.T) {
 	if apiErr.StatusCode != 400 {
 		t.Errorf("expected status code 400, got %d", apiErr.StatusCode)
 	}
-}
\ No newline at end of file
+}
+
+func TestPostMultipartRawResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("failed to get file: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		if ct := header.Header.Get("Content-Type"); ct != "audio/mpeg" {
+			t.Errorf("expected content type audio/mpeg, got %s", ct)
+		}
+		data, _ := io.ReadAll(file)
+		if string(data) != "streamed audio" {
+			t.Errorf("unexpected file data %q", data)
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("1\n00:00:00,000 --> 00:00:01,000\nHello\n"))
+	}))
+	defer server.Close()
+
+	c := New("test-key", WithBaseURL(server.URL))
+
+	fields := []MultipartField{
+		{Name: "file", IsFile: true, FileName: "a.mp3", ContentType: "audio/mpeg", Reader: strings.NewReader("streamed audio")},
+	}
+
+	var text string
+	if err := c.PostMultipart(context.Background(), "/transcribe", fields, &text); err != nil {
+		t.Fatalf("PostMultipart() error = %v", err)
+	}
+	if !strings.HasPrefix(text, "1\n00:00:00,000") {
+		t.Errorf("expected raw body, got %q", text)
+	}
+
+	var buf bytes.Buffer
+	fields[0].Reader = strings.NewReader("streamed audio")
+	if err := c.PostMultipart(context.Background(), "/transcribe", fields, &buf); err != nil {
+		t.Fatalf("PostMultipart() error = %v", err)
+	}
+	if buf.String() != text {
+		t.Errorf("expected io.Writer result to receive the raw body, got %q", buf.String())
+	}
+}
@@ -0,0 +1,254 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials authenticate requests.
+type Credentials struct {
+	APIKey string
+	// Expires is when the client retrieves credentials again. Zero keeps
+	// them until a request is rejected with 401.
+	Expires time.Time
+}
+
+// CredentialsProvider supplies the API key. The client caches the result
+// until it expires, and retrieves it again when a request fails with 401.
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsProviderFunc adapts a function to CredentialsProvider.
+type CredentialsProviderFunc func(ctx context.Context) (Credentials, error)
+
+func (f CredentialsProviderFunc) Retrieve(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+var ErrNoCredentials = errors.New("no API key available")
+
+// ErrCredentialsCycle is returned when a CredentialsProvider sends a request
+// through the client it supplies credentials for while the client is waiting
+// for it. Such a provider needs a separate client.
+var ErrCredentialsCycle = errors.New("credentials provider uses the client it authenticates")
+
+// WithCredentials authenticates every request with the key from provider,
+// replacing the key passed to New. A request rejected with 401 is retried
+// once if retrieving the credentials again yields a different key, so
+// long-running processes pick up rotated keys without restarting.
+func WithCredentials(provider CredentialsProvider) Option {
+	return func(c *Client) {
+		c.credentials = &credentialsCache{provider: provider, now: time.Now}
+	}
+}
+
+// StaticCredentials always returns key.
+func StaticCredentials(key string) CredentialsProvider {
+	return CredentialsProviderFunc(func(context.Context) (Credentials, error) {
+		if key == "" {
+			return Credentials{}, ErrNoCredentials
+		}
+		return Credentials{APIKey: key}, nil
+	})
+}
+
+// EnvCredentials reads the key from the environment variable name, or
+// KEYWORDSAI_API_KEY if name is empty. The variable is read again after a
+// 401.
+func EnvCredentials(name string) CredentialsProvider {
+	if name == "" {
+		name = "KEYWORDSAI_API_KEY"
+	}
+	return CredentialsProviderFunc(func(context.Context) (Credentials, error) {
+		key := os.Getenv(name)
+		if key == "" {
+			return Credentials{}, fmt.Errorf("%w: %s is not set", ErrNoCredentials, name)
+		}
+		return Credentials{APIKey: key}, nil
+	})
+}
+
+// credentialsTimeout bounds a single provider call.
+const credentialsTimeout = 30 * time.Second
+
+// fileCheckInterval is how often FileCredentials checks the file for changes.
+const fileCheckInterval = 10 * time.Second
+
+type fileCredentials struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	key     string
+}
+
+// FileCredentials reads the key from the file at path, e.g. a mounted
+// Kubernetes secret. The file is checked for changes every 10 seconds and
+// after a 401, and read again when it has changed. Surrounding whitespace is
+// ignored.
+func FileCredentials(path string) CredentialsProvider {
+	return &fileCredentials{path: path}
+}
+
+func (f *fileCredentials) Retrieve(context.Context) (Credentials, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	if f.key == "" || !info.ModTime().Equal(f.modTime) || info.Size() != f.size {
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("failed to read credentials file: %w", err)
+		}
+		key := strings.TrimSpace(string(data))
+		if key == "" {
+			return Credentials{}, fmt.Errorf("%w: %s is empty", ErrNoCredentials, f.path)
+		}
+		f.key, f.modTime, f.size = key, info.ModTime(), info.Size()
+	}
+	return Credentials{APIKey: f.key, Expires: time.Now().Add(fileCheckInterval)}, nil
+}
+
+// ExecCredentials runs a command, such as a secrets manager CLI, and uses its
+// standard output as the key. Output that is a JSON object is read as
+// {"api_key": "...", "expires_at": "<RFC 3339>"} so the command can say when
+// to run it again.
+func ExecCredentials(name string, args ...string) CredentialsProvider {
+	return CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, name, args...)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			return Credentials{}, fmt.Errorf("credentials command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+
+		out := bytes.TrimSpace(stdout.Bytes())
+		if len(out) > 0 && out[0] == '{' {
+			var parsed struct {
+				APIKey    string    `json:"api_key"`
+				ExpiresAt time.Time `json:"expires_at"`
+			}
+			if err := json.Unmarshal(out, &parsed); err != nil {
+				return Credentials{}, fmt.Errorf("failed to parse credentials command output: %w", err)
+			}
+			if parsed.APIKey == "" {
+				return Credentials{}, fmt.Errorf("%w: credentials command returned no api_key", ErrNoCredentials)
+			}
+			return Credentials{APIKey: parsed.APIKey, Expires: parsed.ExpiresAt}, nil
+		}
+		if len(out) == 0 {
+			return Credentials{}, fmt.Errorf("%w: credentials command printed nothing", ErrNoCredentials)
+		}
+		return Credentials{APIKey: string(out)}, nil
+	})
+}
+
+// credentialsCache holds the current credentials of a client.
+type credentialsCache struct {
+	provider CredentialsProvider
+	now      func() time.Time
+
+	mu       sync.Mutex
+	current  Credentials
+	valid    bool
+	inFlight *credentialsCall
+}
+
+// credentialsCall is a provider call shared by the requests waiting on it.
+type credentialsCall struct {
+	done chan struct{}
+	key  string
+	err  error
+}
+
+// key returns the cached key, retrieving it if missing or expired.
+func (c *credentialsCache) key(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	if c.valid && (c.current.Expires.IsZero() || c.now().Before(c.current.Expires)) {
+		key := c.current.APIKey
+		c.mu.Unlock()
+		return key, nil
+	}
+	return c.retrieve(ctx)
+}
+
+// refresh retrieves credentials again after rejected was refused, unless
+// another request already replaced it. It reports whether the key changed.
+func (c *credentialsCache) refresh(ctx context.Context, rejected string) (string, bool, error) {
+	c.mu.Lock()
+	if c.valid && c.current.APIKey != rejected {
+		key := c.current.APIKey
+		c.mu.Unlock()
+		return key, true, nil
+	}
+	key, err := c.retrieve(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	return key, key != rejected, nil
+}
+
+// retrieve starts a provider call, or joins the one already in flight, so
+// concurrent requests share one retrieval. The call is not canceled with
+// ctx, since other requests may be waiting on it; it is bounded by
+// credentialsTimeout instead, and each caller stops waiting when its own ctx
+// ends. c.mu must be held; retrieve releases it.
+func (c *credentialsCache) retrieve(ctx context.Context) (string, error) {
+	call := c.inFlight
+	if call != nil && ctx.Value(retrievingKey{}) == c {
+		c.mu.Unlock()
+		return "", ErrCredentialsCycle
+	}
+	if call == nil {
+		call = &credentialsCall{done: make(chan struct{})}
+		c.inFlight = call
+		go c.call(context.WithoutCancel(ctx), call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.key, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// retrievingKey marks the context of a provider call with its cache.
+type retrievingKey struct{}
+
+// call runs the provider for call and stores the result.
+func (c *credentialsCache) call(ctx context.Context, call *credentialsCall) {
+	ctx = context.WithValue(ctx, retrievingKey{}, c)
+	ctx, cancel := context.WithTimeout(ctx, credentialsTimeout)
+	defer cancel()
+	creds, err := c.provider.Retrieve(ctx)
+	if err == nil && creds.APIKey == "" {
+		err = ErrNoCredentials
+	}
+
+	c.mu.Lock()
+	c.inFlight = nil
+	if err != nil {
+		c.valid = false
+	} else {
+		c.current, c.valid = creds, true
+		call.key = creds.APIKey
+	}
+	call.err = err
+	c.mu.Unlock()
+	close(call.done)
+}
@@ -0,0 +1,238 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStaticAndEnvCredentials(t *testing.T) {
+	ctx := context.Background()
+	if creds, err := StaticCredentials("k1").Retrieve(ctx); err != nil || creds.APIKey != "k1" {
+		t.Errorf("unexpected static credentials: %v, %v", creds, err)
+	}
+	if _, err := StaticCredentials("").Retrieve(ctx); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected ErrNoCredentials, got %v", err)
+	}
+
+	t.Setenv("TEST_KEYWORDSAI_KEY", "from-env")
+	if creds, err := EnvCredentials("TEST_KEYWORDSAI_KEY").Retrieve(ctx); err != nil || creds.APIKey != "from-env" {
+		t.Errorf("unexpected env credentials: %v, %v", creds, err)
+	}
+	t.Setenv("KEYWORDSAI_API_KEY", "")
+	if _, err := EnvCredentials("").Retrieve(ctx); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("expected ErrNoCredentials for an unset variable, got %v", err)
+	}
+}
+
+func TestFileCredentialsReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(path, []byte("first-key\n"), 0o600)
+	provider := FileCredentials(path)
+
+	creds, err := provider.Retrieve(context.Background())
+	if err != nil || creds.APIKey != "first-key" || creds.Expires.IsZero() {
+		t.Fatalf("unexpected credentials: %v, %v", creds, err)
+	}
+
+	os.WriteFile(path, []byte("second-key"), 0o600)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	if creds, _ := provider.Retrieve(context.Background()); creds.APIKey != "second-key" {
+		t.Errorf("expected the changed file to be read again, got %q", creds.APIKey)
+	}
+}
+
+func TestExecCredentials(t *testing.T) {
+	ctx := context.Background()
+	if creds, err := ExecCredentials("sh", "-c", "echo '  plain-key  '").Retrieve(ctx); err != nil || creds.APIKey != "plain-key" {
+		t.Errorf("unexpected plain credentials: %v, %v", creds, err)
+	}
+
+	creds, err := ExecCredentials("sh", "-c", `echo '{"api_key":"json-key","expires_at":"2030-01-01T00:00:00Z"}'`).Retrieve(ctx)
+	if err != nil || creds.APIKey != "json-key" || creds.Expires.Year() != 2030 {
+		t.Errorf("unexpected JSON credentials: %v, %v", creds, err)
+	}
+
+	if _, err := ExecCredentials("sh", "-c", "echo denied >&2; exit 3").Retrieve(ctx); err == nil {
+		t.Error("expected a failing command to return an error")
+	}
+}
+
+func TestClientCredentialsCacheAndExpiry(t *testing.T) {
+	var seen atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen.Store(r.Header.Get("Authorization"))
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var calls atomic.Int32
+	now := time.Now()
+	provider := CredentialsProviderFunc(func(context.Context) (Credentials, error) {
+		n := calls.Add(1)
+		return Credentials{APIKey: "key-" + strconv.Itoa(int(n)), Expires: now.Add(time.Minute)}, nil
+	})
+	c := New("ignored", WithBaseURL(server.URL), WithCredentials(provider))
+	c.credentials.now = func() time.Time { return now }
+
+	for range 3 {
+		c.Get(context.Background(), "/api/models", nil)
+	}
+	if calls.Load() != 1 || seen.Load() != "Bearer key-1" {
+		t.Errorf("expected one cached retrieval, got %d calls and %v", calls.Load(), seen.Load())
+	}
+
+	now = now.Add(time.Minute)
+	c.Get(context.Background(), "/api/models", nil)
+	if calls.Load() != 2 || seen.Load() != "Bearer key-2" || c.APIKey() != "key-2" {
+		t.Errorf("expected expired credentials to be retrieved again, got %v", seen.Load())
+	}
+}
+
+func TestCredentialsCacheSharesRetrieval(t *testing.T) {
+	var calls atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	cache := &credentialsCache{now: time.Now, provider: CredentialsProviderFunc(func(context.Context) (Credentials, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return Credentials{APIKey: "shared"}, nil
+	})}
+
+	var wg sync.WaitGroup
+	keys := make([]string, 5)
+	for i := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys[i], _ = cache.key(context.Background())
+		}()
+	}
+	<-started
+	// The provider runs without the cache's lock held.
+	if !cache.mu.TryLock() {
+		t.Fatal("expected the lock to be free while the provider runs")
+	}
+	cache.mu.Unlock()
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected one shared retrieval, got %d", calls.Load())
+	}
+	for _, key := range keys {
+		if key != "shared" {
+			t.Errorf("expected every caller to get the shared key, got %v", keys)
+			break
+		}
+	}
+}
+
+func TestCredentialsCacheOutlivesCanceledCaller(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	cache := &credentialsCache{now: time.Now, provider: CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		close(started)
+		select {
+		case <-release:
+			return Credentials{APIKey: "shared"}, nil
+		case <-ctx.Done():
+			return Credentials{}, ctx.Err()
+		}
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := cache.key(ctx)
+		first <- err
+	}()
+	<-started
+	second := make(chan string, 1)
+	go func() {
+		key, _ := cache.key(context.Background())
+		second <- key
+	}()
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the canceled caller to stop waiting, got %v", err)
+	}
+	close(release)
+	if key := <-second; key != "shared" {
+		t.Errorf("expected the other caller to get the key, got %q", key)
+	}
+}
+
+func TestCredentialsProviderUsingItsOwnClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var c *Client
+	c = New("ignored", WithBaseURL(server.URL), WithCredentials(CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		if err := c.Get(ctx, "/api/temporary-keys", nil); err != nil {
+			return Credentials{}, err
+		}
+		return Credentials{APIKey: "minted"}, nil
+	})))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Get(ctx, "/api/models", nil); !errors.Is(err, ErrCredentialsCycle) {
+		t.Errorf("expected ErrCredentialsCycle, got %v", err)
+	}
+}
+
+func TestClientRefreshesOn401(t *testing.T) {
+	var requests atomic.Int32
+	var valid atomic.Value
+	valid.Store("new-key")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer "+valid.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid key"}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	keys := []string{"old-key", "new-key"}
+	var calls atomic.Int32
+	provider := CredentialsProviderFunc(func(context.Context) (Credentials, error) {
+		n := int(calls.Add(1)) - 1
+		return Credentials{APIKey: keys[min(n, len(keys)-1)]}, nil
+	})
+	c := New(WithBaseURL(server.URL), WithCredentials(provider))
+
+	var result map[string]string
+	err := c.Post(context.Background(), "/api/request-logs/create/", map[string]string{"model": "gpt-4"}, &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["model"] != "gpt-4" || requests.Load() != 2 || calls.Load() != 2 {
+		t.Errorf("expected one retry with the body resent, got %v after %d requests", result, requests.Load())
+	}
+
+	// A refresh that yields the same key is not retried.
+	valid.Store("newer-key")
+	requests.Store(0)
+	err = c.Get(context.Background(), "/api/models", nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || requests.Load() != 1 {
+		t.Errorf("expected a single 401, got %v after %d requests", err, requests.Load())
+	}
+}
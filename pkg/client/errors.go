@@ -3,6 +3,7 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // APIError represents an error response from the KeywordsAI API
@@ -11,6 +12,9 @@ type APIError struct {
 	ErrorText  string `json:"error"`
 	Message    string `json:"message"`
 	Details    string `json:"details"`
+
+	// retryAt is when a 429 response said the request may be sent again.
+	retryAt time.Time
 }
 
 func (e *APIError) Error() string {
@@ -1,13 +1,13 @@
 package client
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"strings"
 )
 
 // MultipartField represents a field in a multipart form
@@ -17,57 +17,79 @@ type MultipartField struct {
 	IsFile   bool
 	FileName string
 	Data     []byte
+	// Reader, if set, is streamed as the file contents instead of Data.
+	Reader io.Reader
+	// ContentType is the file part's media type. Defaults to
+	// application/octet-stream.
+	ContentType string
 }
 
-// PostMultipart sends a multipart form request
-func (c *Client) PostMultipart(ctx context.Context, path string, fields []MultipartField, result interface{}) error {
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
 
-	for _, field := range fields {
-		if field.IsFile {
-			part, err := writer.CreateFormFile(field.Name, field.FileName)
-			if err != nil {
-				return fmt.Errorf("failed to create form file: %w", err)
-			}
-			if _, err := part.Write(field.Data); err != nil {
-				return fmt.Errorf("failed to write file data: %w", err)
-			}
-		} else {
-			if err := writer.WriteField(field.Name, field.Value); err != nil {
-				return fmt.Errorf("failed to write field: %w", err)
-			}
-		}
-	}
-
-	if err := writer.Close(); err != nil {
-		return fmt.Errorf("failed to close multipart writer: %w", err)
-	}
+// PostMultipart sends a multipart form request. The body is streamed as it is
+// written, so file fields backed by a Reader are never held in memory. As
+// with the JSON methods, result may be a *string, *[]byte or io.Writer to
+// read a non-JSON response.
+func (c *Client) PostMultipart(ctx context.Context, path string, fields []MultipartField, result interface{}) error {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, &buf)
+	req, err := c.newRequest(ctx, http.MethodPost, path, pr)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		pr.Close()
+		return err
 	}
-
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	resp, err := c.httpClient.Do(req)
+	go func() {
+		pw.CloseWithError(writeMultipart(writer, fields))
+	}()
+
+	resp, err := c.send(req)
+	// Unblock the writer if the request ended before the body was consumed.
+	pr.Close()
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return parseAPIError(resp.StatusCode, bodyBytes)
-	}
+	return decodeResponse(resp.Body, result)
+}
 
-	if result != nil {
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+func writeMultipart(writer *multipart.Writer, fields []MultipartField) error {
+	for _, field := range fields {
+		if !field.IsFile {
+			if err := writer.WriteField(field.Name, field.Value); err != nil {
+				return fmt.Errorf("failed to write field: %w", err)
+			}
+			continue
+		}
+
+		contentType := field.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			quoteEscaper.Replace(field.Name), quoteEscaper.Replace(field.FileName)))
+		h.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(h)
+		if err != nil {
+			return fmt.Errorf("failed to create form file: %w", err)
+		}
+
+		if field.Reader != nil {
+			_, err = io.Copy(part, field.Reader)
+		} else {
+			_, err = part.Write(field.Data)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write file data: %w", err)
 		}
 	}
 
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart writer: %w", err)
+	}
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,373 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Family groups endpoints that share a rate limit.
+type Family string
+
+const (
+	FamilyLogs    Family = "logs"
+	FamilyPrompts Family = "prompts"
+	FamilyAudio   Family = "audio"
+	FamilyDefault Family = "default"
+)
+
+// EndpointFamily returns the family of an API path. Request logs, threads
+// and trace ingestion count as logs.
+func EndpointFamily(path string) Family {
+	if i := strings.Index(path, "/api/"); i >= 0 {
+		path = path[i:]
+	}
+	switch {
+	case strings.HasPrefix(path, "/api/request-logs"),
+		strings.HasPrefix(path, "/api/threads"),
+		strings.HasPrefix(path, "/api/v1/traces"):
+		return FamilyLogs
+	case strings.HasPrefix(path, "/api/prompts"):
+		return FamilyPrompts
+	case strings.HasPrefix(path, "/api/audio"):
+		return FamilyAudio
+	}
+	return FamilyDefault
+}
+
+// Limit is a token-bucket limit on requests and request body bytes. Zero
+// rates are unlimited; bursts default to one second's worth of tokens.
+type Limit struct {
+	RequestsPerSecond float64
+	RequestBurst      int
+	BytesPerSecond    float64
+	ByteBurst         int
+}
+
+// RateLimit configures WithRateLimit. Families without an entry use
+// Default. MaxInFlight caps concurrent requests across all families; a
+// request stays in flight until its response body is closed.
+type RateLimit struct {
+	Default     Limit
+	Families    map[Family]Limit
+	MaxInFlight int
+	// DisableAdaptive turns off slowing down after 429 responses.
+	DisableAdaptive bool
+}
+
+// RateLimitStats reports how requests of one family were limited.
+type RateLimitStats struct {
+	Requests  int64
+	Waited    int64 // requests that had to wait
+	WaitTime  time.Duration
+	MaxWait   time.Duration
+	Throttled int64 // 429 responses
+}
+
+const (
+	minRateFactor      = 1.0 / 64
+	rateFactorRecovery = 0.05
+)
+
+// WithRateLimit limits requests client-side. Requests wait for request and
+// byte tokens of their endpoint family, then for an in-flight slot. Unless
+// DisableAdaptive is set, a 429 response halves the family's rates, which
+// then recover gradually on success, and Retry-After or rate-limit reset
+// headers pause the family until the server's window resets.
+func WithRateLimit(config RateLimit) Option {
+	return func(c *Client) {
+		c.limiter = newRateLimiter(config)
+	}
+}
+
+type rateLimiter struct {
+	config   RateLimit
+	inFlight chan struct{}
+	now      func() time.Time
+
+	mu       sync.Mutex
+	families map[Family]*familyLimiter
+}
+
+type familyLimiter struct {
+	limit    Limit
+	requests bucket
+	bytes    bucket
+
+	// guarded by rateLimiter.mu
+	factor      float64
+	pausedUntil time.Time
+	stats       RateLimitStats
+}
+
+func newRateLimiter(config RateLimit) *rateLimiter {
+	l := &rateLimiter{
+		config:   config,
+		now:      time.Now,
+		families: make(map[Family]*familyLimiter),
+	}
+	if config.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, config.MaxInFlight)
+	}
+	return l
+}
+
+func (l *rateLimiter) family(f Family) *familyLimiter {
+	fl, ok := l.families[f]
+	if !ok {
+		limit, ok := l.config.Families[f]
+		if !ok {
+			limit = l.config.Default
+		}
+		fl = &familyLimiter{
+			limit:    limit,
+			requests: newBucket(limit.RequestsPerSecond, limit.RequestBurst),
+			bytes:    newBucket(limit.BytesPerSecond, limit.ByteBurst),
+			factor:   1,
+		}
+		l.families[f] = fl
+	}
+	return fl
+}
+
+// wait blocks until req may be sent and returns the function that ends it
+// being in flight. Bodies of unknown length are throttled as they are read.
+// A nil limiter does not limit.
+func (l *rateLimiter) wait(req *http.Request) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	ctx := req.Context()
+	f := EndpointFamily(req.URL.Path)
+	start := l.now()
+
+	l.mu.Lock()
+	fl := l.family(f)
+	delay := max(fl.pausedUntil.Sub(start), fl.requests.reserve(start, 1, fl.factor))
+	if req.ContentLength > 0 {
+		delay = max(delay, fl.bytes.reserve(start, float64(req.ContentLength), fl.factor))
+	}
+	l.mu.Unlock()
+
+	err := sleep(ctx, delay)
+	if err == nil && l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	l.record(f, l.now().Sub(start))
+	if err != nil {
+		l.refund(req)
+		return nil, err
+	}
+
+	if req.Body != nil && req.ContentLength <= 0 && fl.bytes.rate > 0 {
+		req.Body = &throttledBody{ReadCloser: req.Body, ctx: ctx, limiter: l, family: fl}
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if l.inFlight != nil {
+				<-l.inFlight
+			}
+		})
+	}, nil
+}
+
+// refund returns the tokens wait took for req, which was not sent, to later
+// requests.
+func (l *rateLimiter) refund(req *http.Request) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fl := l.family(EndpointFamily(req.URL.Path))
+	fl.requests.refund(1)
+	if req.ContentLength > 0 {
+		fl.bytes.refund(float64(req.ContentLength))
+	}
+}
+
+func (l *rateLimiter) record(f Family, waited time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := &l.family(f).stats
+	s.Requests++
+	if waited > time.Millisecond {
+		s.Waited++
+		s.WaitTime += waited
+		s.MaxWait = max(s.MaxWait, waited)
+	}
+}
+
+// observe adapts the family's rates to resp.
+func (l *rateLimiter) observe(req *http.Request, resp *http.Response) {
+	if l == nil {
+		return
+	}
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fl := l.family(EndpointFamily(req.URL.Path))
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		fl.stats.Throttled++
+		if l.config.DisableAdaptive {
+			return
+		}
+		fl.factor = max(fl.factor/2, minRateFactor)
+		if until, ok := resetTime(resp.Header, now); ok && until.After(fl.pausedUntil) {
+			fl.pausedUntil = until
+		}
+		return
+	}
+	if l.config.DisableAdaptive {
+		return
+	}
+	if resp.StatusCode < 400 {
+		fl.factor = min(fl.factor+rateFactorRecovery, 1)
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if until, ok := resetTime(resp.Header, now); ok && until.After(fl.pausedUntil) {
+			fl.pausedUntil = until
+		}
+	}
+}
+
+// resetTime reads when the server's rate-limit window ends from Retry-After
+// (seconds or an HTTP date), or X-RateLimit-Reset and RateLimit-Reset
+// (seconds, or a Unix timestamp for large values).
+func resetTime(h http.Header, now time.Time) (time.Time, bool) {
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil {
+			return now.Add(time.Duration(secs * float64(time.Second))), true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return t, true
+		}
+	}
+	for _, name := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		secs, err := strconv.ParseFloat(h.Get(name), 64)
+		if err != nil {
+			continue
+		}
+		if secs > 1e9 {
+			return time.Unix(0, int64(secs*float64(time.Second))), true
+		}
+		return now.Add(time.Duration(secs * float64(time.Second))), true
+	}
+	return time.Time{}, false
+}
+
+// RateLimitStats returns wait metrics per endpoint family, or nil without
+// WithRateLimit.
+func (c *Client) RateLimitStats() map[Family]RateLimitStats {
+	if c.limiter == nil {
+		return nil
+	}
+	c.limiter.mu.Lock()
+	defer c.limiter.mu.Unlock()
+	stats := make(map[Family]RateLimitStats, len(c.limiter.families))
+	for f, fl := range c.limiter.families {
+		stats[f] = fl.stats
+	}
+	return stats
+}
+
+// bucket is a token bucket that lends tokens: reserve always succeeds and
+// returns how long the caller must wait for its tokens to have accrued, so
+// requests larger than the burst are delayed rather than rejected.
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst int) bucket {
+	b := bucket{rate: rate, burst: float64(burst)}
+	if b.burst <= 0 {
+		b.burst = max(rate, 1)
+	}
+	b.tokens = b.burst
+	return b
+}
+
+// reserve takes n tokens at the bucket's rate scaled by factor.
+func (b *bucket) reserve(now time.Time, n, factor float64) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	rate := b.rate * factor
+	if !b.last.IsZero() {
+		b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*rate, b.burst)
+	}
+	b.last = now
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// refund returns n tokens taken by reserve.
+func (b *bucket) refund(n float64) {
+	if b.rate <= 0 {
+		return
+	}
+	b.tokens = min(b.tokens+n, b.burst)
+}
+
+// throttledBody takes byte tokens for a streamed request body as it is read.
+type throttledBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *rateLimiter
+	family  *familyLimiter
+}
+
+func (t *throttledBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if n > 0 {
+		t.limiter.mu.Lock()
+		delay := t.family.bytes.reserve(t.limiter.now(), float64(n), t.family.factor)
+		t.limiter.mu.Unlock()
+		if serr := sleep(t.ctx, delay); serr != nil {
+			return n, serr
+		}
+	}
+	return n, err
+}
+
+// releaseBody ends a request's time in flight when its body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseBody) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
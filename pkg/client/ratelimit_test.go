@@ -0,0 +1,222 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEndpointFamily(t *testing.T) {
+	tests := map[string]Family{
+		"/api/request-logs/batch/create":  FamilyLogs,
+		"/api/threads/t-1/messages":       FamilyLogs,
+		"/api/v1/traces/ingest":           FamilyLogs,
+		"/api/prompts/p-1/versions":       FamilyPrompts,
+		"/api/audio/speech":               FamilyAudio,
+		"/proxy/api/audio/transcriptions": FamilyAudio,
+		"/api/models":                     FamilyDefault,
+	}
+	for path, want := range tests {
+		if got := EndpointFamily(path); got != want {
+			t.Errorf("EndpointFamily(%q) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestBucketReserve(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBucket(10, 2)
+
+	if b.reserve(now, 1, 1) != 0 || b.reserve(now, 1, 1) != 0 {
+		t.Fatal("expected the burst to be available immediately")
+	}
+	if d := b.reserve(now, 1, 1); d != 100*time.Millisecond {
+		t.Errorf("expected 100ms wait, got %v", d)
+	}
+	// Half the rate doubles the wait; earlier debt is paid first.
+	if d := b.reserve(now, 1, 0.5); d != 400*time.Millisecond {
+		t.Errorf("expected 400ms wait at half rate, got %v", d)
+	}
+	// Requests larger than the burst are delayed, not rejected.
+	big := newBucket(100, 10)
+	if d := big.reserve(now, 60, 1); d != 500*time.Millisecond {
+		t.Errorf("expected 500ms wait for an oversized request, got %v", d)
+	}
+}
+
+func TestRateLimitRefundsCancelledWaits(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRateLimiter(RateLimit{Default: Limit{RequestsPerSecond: 10, RequestBurst: 1}})
+	l.now = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "/api/models", nil)
+	release, err := l.wait(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.wait(req.WithContext(ctx)); err == nil {
+		t.Fatal("expected the cancelled wait to fail")
+	}
+	// Only the first request's token is spent.
+	if d := l.family(FamilyDefault).requests.reserve(now, 1, 1); d != 100*time.Millisecond {
+		t.Errorf("expected 100ms wait after the refund, got %v", d)
+	}
+}
+
+func TestResetTime(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		header http.Header
+		want   time.Time
+	}{
+		{http.Header{"Retry-After": {"2"}}, now.Add(2 * time.Second)},
+		{http.Header{"Retry-After": {now.Add(time.Minute).UTC().Format(http.TimeFormat)}}, now.Add(time.Minute)},
+		{http.Header{"X-Ratelimit-Reset": {"1700000030"}}, now.Add(30 * time.Second)},
+		{http.Header{"Ratelimit-Reset": {"5"}}, now.Add(5 * time.Second)},
+	}
+	for i, tt := range tests {
+		got, ok := resetTime(tt.header, now)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("case %d: got %v, %v, want %v", i, got, ok, tt.want)
+		}
+	}
+	if _, ok := resetTime(http.Header{}, now); ok {
+		t.Error("expected no reset time without headers")
+	}
+}
+
+func TestRateLimitMaxInFlight(t *testing.T) {
+	var current, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		current.Add(-1)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := New("test-key", WithBaseURL(server.URL), WithRateLimit(RateLimit{MaxInFlight: 2}))
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Get(context.Background(), "/api/models", nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > 2 {
+		t.Errorf("expected at most 2 requests in flight, saw %d", peak.Load())
+	}
+	stats := c.RateLimitStats()[FamilyDefault]
+	if stats.Requests != 8 || stats.Waited == 0 || stats.WaitTime <= 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestRateLimitPerFamily(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := New("test-key", WithBaseURL(server.URL), WithRateLimit(RateLimit{
+		Families: map[Family]Limit{
+			FamilyLogs:  {RequestsPerSecond: 20, RequestBurst: 1},
+			FamilyAudio: {BytesPerSecond: 1000, ByteBurst: 100},
+		},
+	}))
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		c.Post(ctx, "/api/request-logs/create/", map[string]string{}, nil)
+		c.Get(ctx, "/api/models", nil)
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected logs requests to be spaced by 50ms, took %v", elapsed)
+	}
+	stats := c.RateLimitStats()
+	if stats[FamilyLogs].Waited != 2 || stats[FamilyDefault].Waited != 0 {
+		t.Errorf("expected only the logs family to wait: %+v", stats)
+	}
+
+	// A streamed body of unknown length is throttled as it is read.
+	start = time.Now()
+	err := c.PostMultipart(ctx, "/api/audio/transcriptions", []MultipartField{
+		{Name: "file", IsFile: true, FileName: "a.wav", Reader: bytes.NewReader(make([]byte, 200))},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected the upload to be throttled, took %v", elapsed)
+	}
+}
+
+func TestRateLimitAdaptive(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0.1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := New("test-key", WithBaseURL(server.URL), WithRateLimit(RateLimit{
+		Default: Limit{RequestsPerSecond: 1000},
+	}))
+	ctx := context.Background()
+
+	err := c.Get(ctx, "/api/models", nil)
+	if apiErr, ok := err.(*APIError); !ok || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected a 429 error, got %v", err)
+	}
+	start := time.Now()
+	if err := c.Get(ctx, "/api/models", nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected to wait for Retry-After, took %v", elapsed)
+	}
+
+	stats := c.RateLimitStats()[FamilyDefault]
+	if stats.Throttled != 1 || stats.MaxWait < 80*time.Millisecond {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	c.limiter.mu.Lock()
+	factor := c.limiter.families[FamilyDefault].factor
+	c.limiter.mu.Unlock()
+	if factor != 0.5+rateFactorRecovery {
+		t.Errorf("expected the rate to be halved and recover one step, got %v", factor)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := c.Get(ctx, "/api/models", nil); err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Errorf("expected a canceled context to abort waiting, got %v", err)
+	}
+}
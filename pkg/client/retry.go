@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// Retry configures WithRetry. Zero backoffs use the defaults noted on each
+// field.
+type Retry struct {
+	// MaxRetries is how many times a failed request is sent again.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry. It doubles on each
+	// further retry. Default 500ms.
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between retries. Default 30s.
+	MaxBackoff time.Duration
+	// NonIdempotent also retries POST and PATCH requests. A request that
+	// failed after the server processed it is then applied twice, e.g. a
+	// log or completion is counted again.
+	NonIdempotent bool
+}
+
+// WithRetry retries requests that fail with a transport error, a 5xx or a
+// 429 response, waiting an exponential backoff with jitter in between. A 429
+// response's Retry-After or rate-limit reset header is waited for instead,
+// unless it is further away than MaxBackoff, in which case the 429 is
+// returned. Only idempotent methods such as GET, PUT and DELETE are retried
+// unless NonIdempotent is set. Requests whose body cannot be sent again, such
+// as streamed uploads, and requests rejected by an open circuit breaker are
+// not retried.
+func WithRetry(config Retry) Option {
+	return func(c *Client) {
+		if config.MinBackoff <= 0 {
+			config.MinBackoff = 500 * time.Millisecond
+		}
+		if config.MaxBackoff <= 0 {
+			config.MaxBackoff = 30 * time.Second
+		}
+		c.retry = config
+	}
+}
+
+// sendRetrying sends req, retrying according to c.retry.
+func (c *Client) sendRetrying(req *http.Request) (*http.Response, error) {
+	resp, err := c.sendOnce(req)
+	if !idempotent(req.Method) && !c.retry.NonIdempotent {
+		return resp, err
+	}
+	for attempt := 0; attempt < c.retry.MaxRetries && retryable(req.Context(), err); attempt++ {
+		if req.Body != nil && req.GetBody == nil {
+			break
+		}
+		delay := c.retry.backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && !apiErr.retryAt.IsZero() {
+			delay = time.Until(apiErr.retryAt)
+			if delay > c.retry.MaxBackoff {
+				break
+			}
+		}
+		if sleep(req.Context(), delay) != nil {
+			break
+		}
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				break
+			}
+			retry.Body = body
+		}
+		resp, err = c.sendOnce(retry)
+	}
+	return resp, err
+}
+
+// idempotent reports whether sending a request with method twice has the
+// same effect as sending it once.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func retryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// backoff returns the delay before retry number attempt, counted from zero:
+// a random duration between half and all of the exponential backoff.
+func (r Retry) backoff(attempt int) time.Duration {
+	d := r.MaxBackoff
+	if attempt < 32 {
+		d = min(r.MinBackoff<<attempt, r.MaxBackoff)
+	}
+	if d <= 0 {
+		d = r.MaxBackoff
+	}
+	return d/2 + rand.N(d/2+1)
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"ok":"yes"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := New("test-key", WithBaseURL(server.URL), WithRetry(Retry{MaxRetries: 3, MinBackoff: time.Millisecond, NonIdempotent: true}))
+	var result map[string]string
+	if err := c.Post(context.Background(), "/api/request-logs/create/", map[string]string{"model": "gpt-4"}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 || result["ok"] != "yes" {
+		t.Fatalf("expected two retries, got %d calls and %v", calls.Load(), result)
+	}
+	for _, body := range bodies {
+		if body != `{"model":"gpt-4"}` {
+			t.Errorf("expected the body to be resent, got %q", body)
+		}
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusBadGateway)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	c := New("test-key", WithBaseURL(server.URL), WithRetry(Retry{MaxRetries: 2, MinBackoff: time.Millisecond}))
+	var apiErr *APIError
+	if err := c.Get(context.Background(), "/api/models", nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected the last error, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+
+	// POSTs are only retried when NonIdempotent is set.
+	calls.Store(0)
+	if err := c.Post(context.Background(), "/api/request-logs/create/", map[string]string{}, nil); err == nil || calls.Load() != 1 {
+		t.Errorf("expected a failed POST not to be retried, got %d calls", calls.Load())
+	}
+
+	// Client errors and streamed bodies are not retried.
+	status.Store(http.StatusBadRequest)
+	calls.Store(0)
+	c.Get(context.Background(), "/api/models", nil)
+	status.Store(http.StatusInternalServerError)
+	req, _ := c.newRequest(context.Background(), http.MethodPut, "/api/audio", io.NopCloser(strings.NewReader("audio")))
+	c.send(req)
+	if calls.Load() != 2 {
+		t.Errorf("expected no retries, got %d calls", calls.Load())
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var retryAfter atomic.Value
+	retryAfter.Store("0.2")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", retryAfter.Load().(string))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := New("test-key", WithBaseURL(server.URL), WithRetry(Retry{MaxRetries: 1, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Second}))
+	start := time.Now()
+	if err := c.Get(context.Background(), "/api/models", nil); err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited < 200*time.Millisecond {
+		t.Errorf("expected the retry to wait for Retry-After, waited %v", waited)
+	}
+
+	// A reset beyond MaxBackoff returns the 429 instead of waiting.
+	calls.Store(0)
+	retryAfter.Store("60")
+	var apiErr *APIError
+	if err := c.Get(context.Background(), "/api/models", nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the 429, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected no retry, got %d calls", calls.Load())
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	r := Retry{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		want *= time.Millisecond
+		if d := r.backoff(attempt); d < want/2 || d > want {
+			t.Errorf("attempt %d: backoff %v outside [%v, %v]", attempt, d, want/2, want)
+		}
+	}
+	if d := r.backoff(100); d > time.Second {
+		t.Errorf("expected large attempts to be capped, got %v", d)
+	}
+}
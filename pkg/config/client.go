@@ -0,0 +1,86 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+)
+
+// ClientOptions returns the client options for c.
+func (c *Config) ClientOptions() ([]client.Option, error) {
+	var opts []client.Option
+	if c.Proxy != "" || c.TLS != (TLS{}) {
+		transport, err := c.transport()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithHTTPClient(&http.Client{Transport: transport}))
+	}
+	// WithTimeout must follow WithHTTPClient.
+	opts = append(opts,
+		client.WithBaseURL(c.BaseURL),
+		client.WithTimeout(c.Timeout),
+		client.WithRetry(c.Retry),
+	)
+	if c.APIKey != "" {
+		opts = append(opts, client.WithAPIKey(c.APIKey))
+	}
+	return opts, nil
+}
+
+// NewClient creates a client from c. opts are applied after c's settings.
+func (c *Config) NewClient(opts ...client.Option) (*client.Client, error) {
+	configured, err := c.ClientOptions()
+	if err != nil {
+		return nil, err
+	}
+	params := make([]interface{}, 0, len(configured)+len(opts))
+	for _, opt := range append(configured, opts...) {
+		params = append(params, opt)
+	}
+	return client.New(params...), nil
+}
+
+func (c *Config) transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.Proxy != "" {
+		proxy, err := url.Parse(c.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("config: invalid proxy: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if c.TLS == (TLS{}) {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.TLS.InsecureSkipVerify}
+	if c.TLS.CAFile != "" {
+		pem, err := os.ReadFile(c.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: failed to read tls.ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("config: no certificates in tls.ca_file %s", c.TLS.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLS.CertFile, c.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
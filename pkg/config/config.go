@@ -0,0 +1,472 @@
+// Package config loads client settings from named profiles in a config file,
+// environment variables and explicit options, and records where each value
+// came from.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+)
+
+// DefaultProfile is used when no profile is selected.
+const DefaultProfile = "default"
+
+var ErrUnknownProfile = errors.New("config: unknown profile")
+
+// Layer is a level of configuration. Later layers override earlier ones.
+type Layer int
+
+const (
+	LayerDefault Layer = iota
+	LayerFile
+	LayerEnv
+	LayerOption
+)
+
+func (l Layer) String() string {
+	switch l {
+	case LayerFile:
+		return "file"
+	case LayerEnv:
+		return "env"
+	case LayerOption:
+		return "option"
+	}
+	return "default"
+}
+
+// Source is where a setting's value came from.
+type Source struct {
+	Layer Layer
+	// Name is the file and profile, the environment variable, or the option.
+	Name string
+}
+
+func (s Source) String() string {
+	if s.Name == "" {
+		return s.Layer.String()
+	}
+	return s.Layer.String() + " " + s.Name
+}
+
+// TLS configures the connection to the API.
+type TLS struct {
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string
+	// CertFile and KeyFile are a PEM client certificate and key for mutual
+	// TLS.
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// Config holds the resolved settings.
+type Config struct {
+	APIKey  string
+	BaseURL string
+	Timeout time.Duration
+	Retry   client.Retry
+	// Proxy is the proxy URL. Empty uses HTTPS_PROXY, HTTP_PROXY and
+	// NO_PROXY.
+	Proxy string
+	TLS   TLS
+
+	// File is the config file that was read, or "" if there was none.
+	File string
+	// Profile is the selected profile.
+	Profile string
+	// Profiles lists the profiles in File.
+	Profiles []string
+	// Sources maps each setting key, such as "retry.max_retries", to where
+	// its value came from.
+	Sources map[string]Source
+}
+
+// Setting is a setting with its value formatted as in a config file.
+type Setting struct {
+	Key    string
+	Value  string
+	Source Source
+}
+
+// setting describes a key shared by config files, environment variables and
+// WithValue.
+type setting struct {
+	key string
+	env []string
+	set func(c *Config, v string) error
+	get func(c *Config) string
+}
+
+var settings = []setting{
+	{"api_key", []string{"KEYWORDSAI_API_KEY"},
+		func(c *Config, v string) error { c.APIKey = v; return nil },
+		func(c *Config) string { return redact(c.APIKey) }},
+	{"base_url", []string{"KEYWORDSAI_BASE_URL", "KEYWORDS_BASE_URL"},
+		func(c *Config, v string) error { c.BaseURL = v; return nil },
+		func(c *Config) string { return c.BaseURL }},
+	{"timeout", []string{"KEYWORDSAI_TIMEOUT"},
+		durationSetter(func(c *Config) *time.Duration { return &c.Timeout }),
+		func(c *Config) string { return c.Timeout.String() }},
+	{"retry.max_retries", []string{"KEYWORDSAI_MAX_RETRIES"},
+		func(c *Config, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("%q is not a non-negative integer", v)
+			}
+			c.Retry.MaxRetries = n
+			return nil
+		},
+		func(c *Config) string { return strconv.Itoa(c.Retry.MaxRetries) }},
+	{"retry.min_backoff", []string{"KEYWORDSAI_RETRY_MIN_BACKOFF"},
+		durationSetter(func(c *Config) *time.Duration { return &c.Retry.MinBackoff }),
+		func(c *Config) string { return c.Retry.MinBackoff.String() }},
+	{"retry.max_backoff", []string{"KEYWORDSAI_RETRY_MAX_BACKOFF"},
+		durationSetter(func(c *Config) *time.Duration { return &c.Retry.MaxBackoff }),
+		func(c *Config) string { return c.Retry.MaxBackoff.String() }},
+	{"retry.non_idempotent", []string{"KEYWORDSAI_RETRY_NON_IDEMPOTENT"},
+		boolSetter(func(c *Config) *bool { return &c.Retry.NonIdempotent }),
+		func(c *Config) string { return strconv.FormatBool(c.Retry.NonIdempotent) }},
+	{"proxy", []string{"KEYWORDSAI_PROXY"},
+		func(c *Config, v string) error { c.Proxy = v; return nil },
+		func(c *Config) string { return c.Proxy }},
+	{"tls.ca_file", []string{"KEYWORDSAI_TLS_CA_FILE"},
+		func(c *Config, v string) error { c.TLS.CAFile = v; return nil },
+		func(c *Config) string { return c.TLS.CAFile }},
+	{"tls.cert_file", []string{"KEYWORDSAI_TLS_CERT_FILE"},
+		func(c *Config, v string) error { c.TLS.CertFile = v; return nil },
+		func(c *Config) string { return c.TLS.CertFile }},
+	{"tls.key_file", []string{"KEYWORDSAI_TLS_KEY_FILE"},
+		func(c *Config, v string) error { c.TLS.KeyFile = v; return nil },
+		func(c *Config) string { return c.TLS.KeyFile }},
+	{"tls.insecure_skip_verify", []string{"KEYWORDSAI_TLS_INSECURE_SKIP_VERIFY"},
+		boolSetter(func(c *Config) *bool { return &c.TLS.InsecureSkipVerify }),
+		func(c *Config) string { return strconv.FormatBool(c.TLS.InsecureSkipVerify) }},
+}
+
+// boolSetter parses booleans such as "true", "1" or "false".
+func boolSetter(field func(c *Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", v)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+// durationSetter parses Go durations such as "1m30s", or a number of
+// seconds.
+func durationSetter(field func(c *Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			secs, ferr := strconv.ParseFloat(v, 64)
+			if ferr != nil {
+				return fmt.Errorf("%q is not a duration", v)
+			}
+			d = time.Duration(secs * float64(time.Second))
+		}
+		if d < 0 {
+			return fmt.Errorf("%q is negative", v)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// redact keeps the last four characters of key.
+func redact(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return strings.Repeat("*", 8) + key[len(key)-4:]
+}
+
+func lookup(key string) *setting {
+	for i := range settings {
+		if settings[i].key == key {
+			return &settings[i]
+		}
+	}
+	return nil
+}
+
+// Settings returns every setting with its source. The API key is redacted.
+func (c *Config) Settings() []Setting {
+	out := make([]Setting, len(settings))
+	for i, s := range settings {
+		out[i] = Setting{Key: s.key, Value: s.get(c), Source: c.Sources[s.key]}
+	}
+	return out
+}
+
+// DefaultPath returns keywordsai/config.yaml in the user's config directory,
+// e.g. ~/.config/keywordsai/config.yaml on Linux.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "keywordsai", "config.yaml")
+}
+
+type value struct {
+	key, value string
+}
+
+type loader struct {
+	file    string
+	fileSet bool
+	profile string
+	values  []value
+}
+
+type Option func(*loader)
+
+// WithFile reads the config file at path instead of KEYWORDSAI_CONFIG_FILE
+// or DefaultPath. An empty path skips the config file.
+func WithFile(path string) Option {
+	return func(l *loader) {
+		l.file, l.fileSet = path, true
+	}
+}
+
+// WithProfile selects a profile, overriding KEYWORDSAI_PROFILE and the file's
+// default_profile.
+func WithProfile(name string) Option {
+	return func(l *loader) {
+		l.profile = name
+	}
+}
+
+func WithAPIKey(key string) Option {
+	return WithValue("api_key", key)
+}
+
+func WithBaseURL(baseURL string) Option {
+	return WithValue("base_url", baseURL)
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return WithValue("timeout", timeout.String())
+}
+
+// WithValue sets a setting by its key, as in a config file, e.g.
+// WithValue("retry.max_retries", "3"). Empty values are ignored, so unset
+// command-line flags can be passed through.
+func WithValue(key, v string) Option {
+	return func(l *loader) {
+		if v != "" {
+			l.values = append(l.values, value{key: key, value: v})
+		}
+	}
+}
+
+// Load resolves settings from, in increasing precedence: defaults, a profile
+// of the config file, environment variables and opts.
+//
+// The file is WithFile, KEYWORDSAI_CONFIG_FILE or DefaultPath; only a
+// missing DefaultPath is not an error. The profile is WithProfile,
+// KEYWORDSAI_PROFILE, the file's default_profile or "default"; only a
+// missing "default" is not an error.
+func Load(opts ...Option) (*Config, error) {
+	l := &loader{}
+	for _, opt := range opts {
+		opt(l)
+	}
+	c := &Config{
+		BaseURL: "https://api.keywordsai.co",
+		Timeout: 30 * time.Second,
+		Retry:   client.Retry{MinBackoff: 500 * time.Millisecond, MaxBackoff: 30 * time.Second},
+		Sources: make(map[string]Source),
+	}
+	for _, s := range settings {
+		c.Sources[s.key] = Source{Layer: LayerDefault}
+	}
+
+	if err := l.loadFile(c); err != nil {
+		return nil, err
+	}
+
+	for _, s := range settings {
+		for _, name := range s.env {
+			v := os.Getenv(name)
+			if v == "" {
+				continue
+			}
+			if err := s.set(c, v); err != nil {
+				return nil, fmt.Errorf("config: %s: %w", name, err)
+			}
+			c.Sources[s.key] = Source{Layer: LayerEnv, Name: name}
+			break
+		}
+	}
+
+	for _, v := range l.values {
+		s := lookup(v.key)
+		if s == nil {
+			return nil, fmt.Errorf("config: unknown setting %q", v.key)
+		}
+		if err := s.set(c, v.value); err != nil {
+			return nil, fmt.Errorf("config: %s: %w", v.key, err)
+		}
+		c.Sources[s.key] = Source{Layer: LayerOption, Name: v.key}
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (l *loader) loadFile(c *Config) error {
+	path, explicit := DefaultPath(), false
+	if l.fileSet {
+		path, explicit = l.file, true
+	} else if env := os.Getenv("KEYWORDSAI_CONFIG_FILE"); env != "" {
+		path, explicit = env, true
+	}
+
+	profile, profileSource := l.profile, "WithProfile"
+	if profile == "" {
+		profile, profileSource = os.Getenv("KEYWORDSAI_PROFILE"), "KEYWORDSAI_PROFILE"
+	}
+
+	var profiles map[string]any
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			c.File = path
+			var defaultProfile string
+			if profiles, defaultProfile, err = parseFile(string(data)); err != nil {
+				return fmt.Errorf("config: %s: %w", path, err)
+			}
+			if profile == "" {
+				profile, profileSource = defaultProfile, "default_profile"
+			}
+			for name := range profiles {
+				c.Profiles = append(c.Profiles, name)
+			}
+			slices.Sort(c.Profiles)
+		case explicit || !errors.Is(err, os.ErrNotExist):
+			return fmt.Errorf("config: %w", err)
+		}
+	}
+
+	if profile == "" {
+		profile = DefaultProfile
+		profileSource = ""
+	}
+	c.Profile = profile
+	p, ok := profiles[profile]
+	if !ok {
+		if profileSource == "" {
+			return nil
+		}
+		if c.File == "" {
+			return fmt.Errorf("%w %q (from %s): no config file", ErrUnknownProfile, profile, profileSource)
+		}
+		return fmt.Errorf("%w %q (from %s) in %s", ErrUnknownProfile, profile, profileSource, c.File)
+	}
+
+	values, _ := p.(map[string]any)
+	flat := make(map[string]string)
+	flatten("", values, flat)
+	name := fmt.Sprintf("%s (profile %s)", c.File, profile)
+	for _, s := range settings {
+		v, ok := flat[s.key]
+		if !ok {
+			continue
+		}
+		delete(flat, s.key)
+		if v == "" {
+			continue
+		}
+		if err := s.set(c, v); err != nil {
+			return fmt.Errorf("config: %s: profile %q: %s: %w", c.File, profile, s.key, err)
+		}
+		c.Sources[s.key] = Source{Layer: LayerFile, Name: name}
+	}
+	for key := range flat {
+		return fmt.Errorf("config: %s: profile %q: unknown setting %q", c.File, profile, key)
+	}
+	return nil
+}
+
+// parseFile returns the profiles and default profile of a config file.
+func parseFile(data string) (map[string]any, string, error) {
+	root, err := parseYAML(data)
+	if err != nil {
+		return nil, "", err
+	}
+	profiles := make(map[string]any)
+	var defaultProfile string
+	for key, v := range root {
+		switch key {
+		case "default_profile":
+			s, ok := v.(string)
+			if !ok {
+				return nil, "", errors.New("default_profile must be a string")
+			}
+			defaultProfile = s
+		case "profiles":
+			if m, ok := v.(map[string]any); ok {
+				profiles = m
+			} else if v != "" {
+				return nil, "", errors.New("profiles must be a mapping")
+			}
+		default:
+			return nil, "", fmt.Errorf("unknown key %q", key)
+		}
+	}
+	return profiles, defaultProfile, nil
+}
+
+func flatten(prefix string, m map[string]any, out map[string]string) {
+	for k, v := range m {
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(prefix+k+".", v, out)
+		case string:
+			out[prefix+k] = v
+		}
+	}
+}
+
+func (c *Config) validate() error {
+	invalid := func(key string, err error) error {
+		return fmt.Errorf("config: invalid %s from %s: %w", key, c.Sources[key], err)
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err == nil && (u.Scheme != "http" && u.Scheme != "https" || u.Host == "") {
+		err = errors.New("must be an http or https URL")
+	}
+	if err != nil {
+		return invalid("base_url", err)
+	}
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		if err == nil && u.Host == "" {
+			err = errors.New("must be a URL such as http://proxy:8080")
+		}
+		if err != nil {
+			return invalid("proxy", err)
+		}
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return errors.New("config: tls.cert_file and tls.key_file must be set together")
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testFile = `# KeywordsAI profiles
+default_profile: staging
+
+profiles:
+  default:
+    api_key: default-key
+  staging:
+    api_key: "staging-key"   # quoted
+    base_url: https://staging.example.com
+    timeout: 10s
+    retry:
+      max_retries: 3
+      min_backoff: 100ms
+  prod:
+    api_key: 'prod-key'
+    proxy: http://proxy.internal:8080
+`
+
+// isolate clears the environment variables Load reads and points the
+// default path at an empty directory.
+func isolate(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	for _, s := range settings {
+		for _, name := range s.env {
+			t.Setenv(name, "")
+		}
+	}
+	t.Setenv("KEYWORDSAI_CONFIG_FILE", "")
+	t.Setenv("KEYWORDSAI_PROFILE", "")
+	return dir
+}
+
+func writeFile(t *testing.T, path, data string) string {
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := isolate(t)
+	path := writeFile(t, filepath.Join(dir, "keywordsai", "config.yaml"), testFile)
+
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.File != path || c.Profile != "staging" || !reflect.DeepEqual(c.Profiles, []string{"default", "prod", "staging"}) {
+		t.Fatalf("unexpected file %q, profile %q, profiles %v", c.File, c.Profile, c.Profiles)
+	}
+	if c.APIKey != "staging-key" || c.BaseURL != "https://staging.example.com" || c.Timeout != 10*time.Second ||
+		c.Retry.MaxRetries != 3 || c.Retry.MinBackoff != 100*time.Millisecond || c.Retry.MaxBackoff != 30*time.Second {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+	fromFile := Source{Layer: LayerFile, Name: path + " (profile staging)"}
+	if c.Sources["api_key"] != fromFile || c.Sources["retry.max_backoff"] != (Source{Layer: LayerDefault}) {
+		t.Errorf("unexpected sources: %v", c.Sources)
+	}
+
+	// Environment variables override the file, and options override both.
+	t.Setenv("KEYWORDSAI_PROFILE", "prod")
+	t.Setenv("KEYWORDS_BASE_URL", "https://env.example.com")
+	t.Setenv("KEYWORDSAI_TIMEOUT", "45")
+	c, err = Load(WithTimeout(time.Minute), WithValue("retry.max_retries", "5"), WithValue("retry.non_idempotent", "true"), WithValue("proxy", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Profile != "prod" || c.APIKey != "prod-key" || c.BaseURL != "https://env.example.com" ||
+		c.Timeout != time.Minute || c.Retry.MaxRetries != 5 || !c.Retry.NonIdempotent || c.Proxy != "http://proxy.internal:8080" {
+		t.Fatalf("unexpected config: %+v", c)
+	}
+	want := map[string]string{
+		"api_key":           "file " + path + " (profile prod)",
+		"base_url":          "env KEYWORDS_BASE_URL",
+		"timeout":           "option timeout",
+		"retry.max_retries": "option retry.max_retries",
+		"retry.min_backoff": "default",
+	}
+	for key, source := range want {
+		if got := c.Sources[key].String(); got != source {
+			t.Errorf("%s: expected source %q, got %q", key, source, got)
+		}
+	}
+
+	for _, s := range c.Settings() {
+		if s.Key == "api_key" && s.Value != "********" {
+			t.Errorf("expected the API key to be redacted, got %q", s.Value)
+		}
+	}
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	dir := isolate(t)
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.File != "" || c.Profile != DefaultProfile || c.BaseURL != "https://api.keywordsai.co" || c.Timeout != 30*time.Second {
+		t.Errorf("unexpected defaults: %+v", c)
+	}
+
+	// Naming a profile or a file that does not exist is an error.
+	if _, err := Load(WithProfile("prod")); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("expected ErrUnknownProfile, got %v", err)
+	}
+	if _, err := Load(WithFile(filepath.Join(dir, "missing.yaml"))); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing file error, got %v", err)
+	}
+	t.Setenv("KEYWORDSAI_CONFIG_FILE", writeFile(t, filepath.Join(dir, "other.yaml"), testFile))
+	if _, err := Load(WithProfile("dev")); err == nil || !strings.Contains(err.Error(), `"dev" (from WithProfile)`) {
+		t.Errorf("expected an unknown profile error naming its source, got %v", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := isolate(t)
+	tests := map[string]string{
+		"unknown setting":     "profiles:\n  default:\n    api_kye: x\n",
+		"unknown key":         "profile:\n  default:\n    api_key: x\n",
+		"invalid duration":    "profiles:\n  default:\n    timeout: soon\n",
+		"invalid base URL":    "profiles:\n  default:\n    base_url: api.keywordsai.co\n",
+		"cert without key":    "profiles:\n  default:\n    tls:\n      cert_file: client.pem\n",
+		"inconsistent indent": "profiles:\n  default:\n    api_key: x\n   timeout: 1s\n",
+	}
+	for name, data := range tests {
+		path := writeFile(t, filepath.Join(dir, "config.yaml"), data)
+		if _, err := Load(WithFile(path)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	t.Setenv("KEYWORDSAI_MAX_RETRIES", "-1")
+	if _, err := Load(WithFile("")); err == nil || !strings.Contains(err.Error(), "KEYWORDSAI_MAX_RETRIES") {
+		t.Errorf("expected an error naming the variable, got %v", err)
+	}
+	t.Setenv("KEYWORDSAI_MAX_RETRIES", "")
+	if _, err := Load(WithFile(""), WithValue("retries", "1")); err == nil {
+		t.Error("expected an unknown option key to fail")
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	got, err := parseYAML("---\na: 1\nb:\n  c: \"x # y\"\n  d: 'it''s' # comment\n\n  e:\nf: ~\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"a": "1",
+		"b": map[string]any{"c": "x # y", "d": "it's", "e": ""},
+		"f": "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"a:\n\t b: 1\n", "a:\n  - x\n", "a: [1, 2]\n", "a: 1\na: 2\n", "just text\n"} {
+		if _, err := parseYAML(bad); err == nil {
+			t.Errorf("expected %q to fail", bad)
+		}
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	isolate(t)
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("KEYWORDSAI_API_KEY", "env-key")
+	c, err := Load(WithBaseURL(server.URL), WithValue("proxy", "http://127.0.0.1:1"), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The proxy is unreachable, so check the transport instead of sending
+	// through it.
+	cl, err := c.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := cl.HTTPClient().Transport.(*http.Transport)
+	proxy, _ := transport.Proxy(httptest.NewRequest(http.MethodGet, "https://api.keywordsai.co", nil))
+	if proxy == nil || proxy.Host != "127.0.0.1:1" || cl.HTTPClient().Timeout != 5*time.Second {
+		t.Errorf("unexpected HTTP client: proxy %v, timeout %v", proxy, cl.HTTPClient().Timeout)
+	}
+
+	c.Proxy = ""
+	cl, err = c.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Get(context.Background(), "/api/models", nil); err != nil {
+		t.Fatal(err)
+	}
+	if cl.BaseURL() != server.URL || auth != "Bearer env-key" {
+		t.Errorf("unexpected base URL %q or authorization %q", cl.BaseURL(), auth)
+	}
+
+	c.TLS.CAFile = filepath.Join(t.TempDir(), "missing.pem")
+	if _, err := c.NewClient(); err == nil {
+		t.Error("expected a missing CA file to fail")
+	}
+}
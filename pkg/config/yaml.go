@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseYAML reads the subset of YAML used by config files: nested mappings
+// with scalar values, indented with spaces, with # comments and single- or
+// double-quoted strings. Mappings are map[string]any and scalars are
+// strings; a key without a value or nested mapping is "".
+func parseYAML(data string) (map[string]any, error) {
+	type level struct {
+		indent int
+		values map[string]any
+	}
+	root := make(map[string]any)
+	// stack holds the mappings enclosing the current line. The root's
+	// indentation is taken from the first key.
+	stack := []level{{indent: -1, values: root}}
+	// open is the mapping of the previous key if it had no value; it is
+	// entered when the next line is indented further.
+	var open map[string]any
+
+	for n, line := range strings.Split(data, "\n") {
+		n++
+		line = strings.TrimRight(line, " \t\r")
+		content := strings.TrimLeft(line, " ")
+		if content == "" || content[0] == '#' || (n == 1 && content == "---") {
+			continue
+		}
+		indent := len(line) - len(content)
+		if content[0] == '\t' {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", n)
+		}
+		if content == "-" || strings.HasPrefix(content, "- ") {
+			return nil, fmt.Errorf("line %d: lists are not supported", n)
+		}
+
+		if stack[0].indent < 0 {
+			stack[0].indent = indent
+		}
+		if open != nil && indent > stack[len(stack)-1].indent {
+			stack = append(stack, level{indent: indent, values: open})
+		}
+		open = nil
+		for len(stack) > 1 && indent < stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		if indent != stack[len(stack)-1].indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", n)
+		}
+
+		key, rest, ok := strings.Cut(content, ":")
+		if !ok || (rest != "" && rest[0] != ' ') {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", n)
+		}
+		key, err := scalar(strings.TrimSpace(key))
+		if err != nil || key == "" {
+			return nil, fmt.Errorf("line %d: invalid key", n)
+		}
+		values := stack[len(stack)-1].values
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %q", n, key)
+		}
+
+		raw := stripComment(strings.TrimSpace(rest))
+		if raw == "" {
+			// Becomes "" unless an indented mapping follows.
+			open = make(map[string]any)
+			values[key] = open
+			continue
+		}
+		value, err := scalar(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		values[key] = value
+	}
+	return emptyToString(root), nil
+}
+
+// emptyToString replaces mappings that no line was indented under with "".
+func emptyToString(m map[string]any) map[string]any {
+	for k, v := range m {
+		if sub, ok := v.(map[string]any); ok {
+			if len(sub) == 0 {
+				m[k] = ""
+			} else {
+				emptyToString(sub)
+			}
+		}
+	}
+	return m
+}
+
+// stripComment removes a trailing # comment outside quotes.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return strings.TrimSpace(s[:i])
+		}
+	}
+	return s
+}
+
+func scalar(s string) (string, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string %s", s)
+		}
+		return v, nil
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case s == "~" || s == "null":
+		return "", nil
+	case strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{"):
+		return "", fmt.Errorf("flow collections are not supported")
+	}
+	return s, nil
+}
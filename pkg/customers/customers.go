@@ -0,0 +1,155 @@
+package customers
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/url"
+
+	"github.com/rizome-dev/go-keywordsai/internal/paging"
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// Budget durations accepted by BudgetDuration. A customer's period budget
+// resets at the start of each duration.
+const (
+	BudgetDaily   = "daily"
+	BudgetWeekly  = "weekly"
+	BudgetMonthly = "monthly"
+	BudgetYearly  = "yearly"
+)
+
+// Summary granularities accepted by CustomerSummaryParams.Granularity.
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+type Service struct {
+	client *client.Client
+}
+
+func NewService(client *client.Client) *Service {
+	return &Service{client: client}
+}
+
+type CreateCustomerRequest struct {
+	CustomerIdentifier string                 `json:"customer_identifier"`
+	Name               *string                `json:"name,omitempty"`
+	Email              *string                `json:"email,omitempty"`
+	PeriodBudget       *float64               `json:"period_budget,omitempty"`
+	BudgetDuration     *string                `json:"budget_duration,omitempty"`
+	TotalBudget        *float64               `json:"total_budget,omitempty"`
+	RateLimit          *int                   `json:"rate_limit,omitempty"`
+	Metadata           map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// UpdateCustomerRequest changes the fields that are set and leaves the rest
+// untouched.
+type UpdateCustomerRequest struct {
+	Name           *string                `json:"name,omitempty"`
+	Email          *string                `json:"email,omitempty"`
+	PeriodBudget   *float64               `json:"period_budget,omitempty"`
+	BudgetDuration *string                `json:"budget_duration,omitempty"`
+	TotalBudget    *float64               `json:"total_budget,omitempty"`
+	RateLimit      *int                   `json:"rate_limit,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// List returns one page of customers matching filter.
+func (s *Service) List(ctx context.Context, filter *types.CustomerFilter) (*types.CustomersResponse, error) {
+	var result types.CustomersResponse
+	return &result, s.client.GetWithQuery(ctx, "/api/users/", filter, &result)
+}
+
+// All iterates over every customer matching filter, fetching pages as needed.
+// Iteration stops after the first error.
+func (s *Service) All(ctx context.Context, filter *types.CustomerFilter) iter.Seq2[types.Customer, error] {
+	return func(yield func(types.Customer, error) bool) {
+		f := types.CustomerFilter{}
+		if filter != nil {
+			f = *filter
+		}
+		if f.Limit == nil {
+			limit := paging.DefaultPageSize
+			f.Limit = &limit
+		}
+		offset := 0
+		if f.Offset != nil {
+			offset = *f.Offset
+		}
+
+		for {
+			f.Offset = &offset
+			page, err := s.List(ctx, &f)
+			if err != nil {
+				yield(types.Customer{}, err)
+				return
+			}
+			for _, customer := range page.Customers {
+				if !yield(customer, nil) {
+					return
+				}
+			}
+			next, ok := paging.Next(offset, len(page.Customers), page.NextOffset, page.TotalCount)
+			if !ok {
+				return
+			}
+			offset = next
+		}
+	}
+}
+
+// ListAll pages through every customer matching filter.
+func (s *Service) ListAll(ctx context.Context, filter *types.CustomerFilter) ([]types.Customer, error) {
+	var all []types.Customer
+	for customer, err := range s.All(ctx, filter) {
+		if err != nil {
+			return all, err
+		}
+		all = append(all, customer)
+	}
+	return all, nil
+}
+
+func (s *Service) Get(ctx context.Context, customerIdentifier string) (*types.Customer, error) {
+	var result types.Customer
+	path := fmt.Sprintf("/api/users/%s/", url.PathEscape(customerIdentifier))
+	return &result, s.client.Get(ctx, path, &result)
+}
+
+func (s *Service) Create(ctx context.Context, req *CreateCustomerRequest) (*types.Customer, error) {
+	var result types.Customer
+	return &result, s.client.Post(ctx, "/api/users/create/", req, &result)
+}
+
+func (s *Service) Update(ctx context.Context, customerIdentifier string, req *UpdateCustomerRequest) (*types.Customer, error) {
+	var result types.Customer
+	path := fmt.Sprintf("/api/users/update/%s/", url.PathEscape(customerIdentifier))
+	return &result, s.client.Patch(ctx, path, req, &result)
+}
+
+// SetBudget sets the budget that resets every duration (one of the Budget*
+// constants).
+func (s *Service) SetBudget(ctx context.Context, customerIdentifier string, amount float64, duration string) (*types.Customer, error) {
+	return s.Update(ctx, customerIdentifier, &UpdateCustomerRequest{
+		PeriodBudget:   &amount,
+		BudgetDuration: &duration,
+	})
+}
+
+// SetRateLimit sets the maximum requests per minute for the customer.
+func (s *Service) SetRateLimit(ctx context.Context, customerIdentifier string, requestsPerMinute int) (*types.Customer, error) {
+	return s.Update(ctx, customerIdentifier, &UpdateCustomerRequest{RateLimit: &requestsPerMinute})
+}
+
+// Summary returns the customer's spend and token usage over the requested
+// time range, broken down per period when params.Granularity is set.
+func (s *Service) Summary(ctx context.Context, customerIdentifier string, params *types.CustomerSummaryParams) (*types.CustomerSummary, error) {
+	var result types.CustomerSummary
+	path := fmt.Sprintf("/api/users/%s/summary/", url.PathEscape(customerIdentifier))
+	return &result, s.client.GetWithQuery(ctx, path, params, &result)
+}
@@ -0,0 +1,191 @@
+package customers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+func TestList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/users/" {
+			t.Errorf("Expected path /api/users/, got %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("search") != "acme" {
+			t.Errorf("Expected search=acme, got %s", q.Get("search"))
+		}
+		if q.Get("over_budget") != "true" {
+			t.Errorf("Expected over_budget=true, got %s", q.Get("over_budget"))
+		}
+		if q.Get("metadata") != `{"plan":"pro"}` {
+			t.Errorf("Expected JSON metadata filter, got %s", q.Get("metadata"))
+		}
+
+		json.NewEncoder(w).Encode(types.CustomersResponse{
+			Customers:  []types.Customer{{CustomerIdentifier: "acme-1", TotalSpend: 12.5}},
+			TotalCount: 1,
+		})
+	}))
+	defer server.Close()
+
+	s := NewService(client.New("test-key", client.WithBaseURL(server.URL)))
+	result, err := s.List(context.Background(), &types.CustomerFilter{
+		Search:     utils.String("acme"),
+		OverBudget: utils.Bool(true),
+		Metadata:   map[string]string{"plan": "pro"},
+	})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(result.Customers) != 1 || result.Customers[0].TotalSpend != 12.5 {
+		t.Errorf("Unexpected customers: %+v", result.Customers)
+	}
+}
+
+func TestAllPaginates(t *testing.T) {
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		offsets = append(offsets, q.Get("offset"))
+		if q.Get("limit") != "2" {
+			t.Errorf("Expected limit=2, got %s", q.Get("limit"))
+		}
+		offset, _ := strconv.Atoi(q.Get("offset"))
+
+		var page []types.Customer
+		for i := offset; i < min(offset+2, 5); i++ {
+			page = append(page, types.Customer{CustomerIdentifier: "customer-" + strconv.Itoa(i)})
+		}
+		json.NewEncoder(w).Encode(types.CustomersResponse{Customers: page, TotalCount: 5})
+	}))
+	defer server.Close()
+
+	s := NewService(client.New("test-key", client.WithBaseURL(server.URL)))
+	all, err := s.ListAll(context.Background(), &types.CustomerFilter{Limit: utils.Int(2)})
+	if err != nil {
+		t.Fatalf("ListAll() error = %v", err)
+	}
+	if len(all) != 5 || all[4].CustomerIdentifier != "customer-4" {
+		t.Errorf("Unexpected customers: %+v", all)
+	}
+	if want := []string{"0", "2", "4"}; len(offsets) != len(want) || offsets[0] != want[0] || offsets[1] != want[1] || offsets[2] != want[2] {
+		t.Errorf("Expected offsets %v, got %v", want, offsets)
+	}
+
+	// Breaking out of the iterator stops fetching.
+	offsets = nil
+	for customer, err := range s.All(context.Background(), &types.CustomerFilter{Limit: utils.Int(2)}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if customer.CustomerIdentifier == "customer-1" {
+			break
+		}
+	}
+	if len(offsets) != 1 {
+		t.Errorf("Expected 1 page request, got %d", len(offsets))
+	}
+}
+
+func TestAllYieldsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"detail":"forbidden"}`))
+	}))
+	defer server.Close()
+
+	s := NewService(client.New("test-key", client.WithBaseURL(server.URL)))
+	var apiErr *client.APIError
+	_, err := s.ListAll(context.Background(), nil)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 APIError, got %v", err)
+	}
+}
+
+func TestCreateAndUpdate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/users/create/":
+			if body["customer_identifier"] != "user/42" || body["rate_limit"] != float64(60) {
+				t.Errorf("Unexpected create body: %v", body)
+			}
+		case r.Method == http.MethodPatch && r.URL.EscapedPath() == "/api/users/update/user%2F42/":
+			if body["period_budget"] != float64(25) || body["budget_duration"] != BudgetMonthly {
+				t.Errorf("Unexpected update body: %v", body)
+			}
+			if _, ok := body["rate_limit"]; ok {
+				t.Error("Expected unset fields to be omitted")
+			}
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.EscapedPath())
+		}
+		json.NewEncoder(w).Encode(types.Customer{CustomerIdentifier: "user/42"})
+	}))
+	defer server.Close()
+
+	s := NewService(client.New("test-key", client.WithBaseURL(server.URL)))
+	if _, err := s.Create(context.Background(), &CreateCustomerRequest{
+		CustomerIdentifier: "user/42",
+		RateLimit:          utils.Int(60),
+	}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := s.SetBudget(context.Background(), "user/42", 25, BudgetMonthly); err != nil {
+		t.Fatalf("SetBudget() error = %v", err)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/users/acme-1/summary/" {
+			t.Errorf("Expected summary path, got %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("start_time") != start.Format(time.RFC3339) || q.Get("granularity") != GranularityWeek {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{
+			"customer_identifier": "acme-1",
+			"total": {"cost": 3.5, "requests": 20, "total_tokens": 5000},
+			"periods": [{"start": "2024-01-01T00:00:00Z", "end": "2024-01-08T00:00:00Z", "cost": 1.25, "requests": 8}],
+			"by_model": {"gpt-4o": {"cost": 3.5, "requests": 20}},
+			"budget_remaining": 16.5
+		}`))
+	}))
+	defer server.Close()
+
+	s := NewService(client.New("test-key", client.WithBaseURL(server.URL)))
+	summary, err := s.Summary(context.Background(), "acme-1", &types.CustomerSummaryParams{
+		StartTime:   &start,
+		EndTime:     &end,
+		Granularity: utils.String(GranularityWeek),
+	})
+	if err != nil {
+		t.Fatalf("Summary() error = %v", err)
+	}
+	if summary.Total.Cost != 3.5 || summary.Total.Requests != 20 {
+		t.Errorf("Unexpected total: %+v", summary.Total)
+	}
+	if len(summary.Periods) != 1 || summary.Periods[0].Cost != 1.25 || !summary.Periods[0].Start.Equal(start) {
+		t.Errorf("Unexpected periods: %+v", summary.Periods)
+	}
+	if summary.ByModel["gpt-4o"].Requests != 20 || summary.BudgetRemaining == nil || *summary.BudgetRemaining != 16.5 {
+		t.Errorf("Unexpected breakdown: %+v", summary)
+	}
+}
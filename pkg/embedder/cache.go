@@ -0,0 +1,181 @@
+package embedder
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// Cache stores embeddings by Key. Caches are best effort: a failed lookup is
+// a miss and a failed store is ignored. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	Get(key string) ([]float32, bool)
+	Set(key string, vector []float32)
+}
+
+// Key identifies the embedding of text by model and dimensions (0 for the
+// model's default). The text is hashed, so keys are fixed-length and safe to
+// use as file names.
+func Key(model string, dimensions int, text string) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.Itoa(dimensions)))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// LRUCache keeps the most recently used embeddings in memory.
+type LRUCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key    string
+	vector []float32
+}
+
+// NewLRUCache creates an in-memory cache holding up to capacity embeddings.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *LRUCache) Get(key string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return slices.Clone(el.Value.(*lruEntry).vector), true
+}
+
+func (c *LRUCache) Set(key string, vector []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*lruEntry).vector = slices.Clone(vector)
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, vector: slices.Clone(vector)})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of cached embeddings.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// DiskCache stores each embedding as a file of little-endian float32 values
+// under a directory, so cached vectors survive restarts.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a cache in dir, creating the directory if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+func (c *DiskCache) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(c.dir, key+".f32")
+	}
+	return filepath.Join(c.dir, key[:2], key+".f32")
+}
+
+func (c *DiskCache) Get(key string) ([]float32, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil || len(data)%4 != 0 {
+		return nil, false
+	}
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return vector, true
+}
+
+func (c *DiskCache) Set(key string, vector []float32) {
+	data := make([]byte, 0, len(vector)*4)
+	for _, v := range vector {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	// Write to a temporary file and rename so readers never see a partial
+	// vector.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Tiered checks caches in order, e.g. an LRUCache in front of a DiskCache.
+// A hit in a later cache is copied into the earlier ones, and stores go to
+// every cache.
+func Tiered(caches ...Cache) Cache {
+	return tiered(caches)
+}
+
+type tiered []Cache
+
+func (t tiered) Get(key string) ([]float32, bool) {
+	for i, c := range t {
+		if vector, ok := c.Get(key); ok {
+			for _, earlier := range t[:i] {
+				earlier.Set(key, vector)
+			}
+			return vector, true
+		}
+	}
+	return nil, false
+}
+
+func (t tiered) Set(key string, vector []float32) {
+	for _, c := range t {
+		c.Set(key, vector)
+	}
+}
@@ -0,0 +1,79 @@
+package embedder
+
+import (
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	if Key("m", 0, "text") == Key("m", 256, "text") {
+		t.Error("expected dimensions to be part of the key")
+	}
+	if Key("m", 0, "text") == Key("n", 0, "text") {
+		t.Error("expected model to be part of the key")
+	}
+	if len(Key("m", 0, "a very long text")) != 64 {
+		t.Error("expected a fixed-length key")
+	}
+}
+
+func TestLRUCacheEvicts(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Set("a", []float32{1})
+	c.Set("b", []float32{2})
+	c.Get("a")
+	c.Set("c", []float32{3})
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v[0] != 1 {
+		t.Errorf("expected a to be kept, got %v", v)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+
+	v, _ := c.Get("c")
+	v[0] = 99
+	if v, _ := c.Get("c"); v[0] != 3 {
+		t.Error("modifying a returned vector should not change the cache")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatalf("NewDiskCache() error = %v", err)
+	}
+	key := Key("m", 0, "hello")
+	c.Set(key, []float32{0.5, -1.25})
+
+	reopened, _ := NewDiskCache(dir)
+	v, ok := reopened.Get(key)
+	if !ok || len(v) != 2 || v[0] != 0.5 || v[1] != -1.25 {
+		t.Errorf("unexpected vector %v, %v", v, ok)
+	}
+	if _, ok := reopened.Get(Key("m", 0, "missing")); ok {
+		t.Error("expected miss for unknown key")
+	}
+}
+
+func TestTieredBackfills(t *testing.T) {
+	mem := NewLRUCache(10)
+	disk, _ := NewDiskCache(t.TempDir())
+	disk.Set("k", []float32{7})
+
+	c := Tiered(mem, disk)
+	if v, ok := c.Get("k"); !ok || v[0] != 7 {
+		t.Fatalf("expected hit from disk, got %v", v)
+	}
+	if _, ok := mem.Get("k"); !ok {
+		t.Error("expected disk hit to be copied into memory")
+	}
+
+	c.Set("j", []float32{8})
+	if _, ok := disk.Get("j"); !ok {
+		t.Error("expected store to reach every tier")
+	}
+}
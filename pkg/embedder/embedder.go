@@ -0,0 +1,295 @@
+// Package embedder creates embeddings for large sets of texts, batching and
+// caching requests to the embeddings endpoint.
+package embedder
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+const (
+	defaultBatchSize   = 256
+	defaultConcurrency = 4
+	// maxBatchSize is the most inputs providers accept in one request.
+	maxBatchSize = 2048
+)
+
+// Embedder turns texts into embedding vectors. Inputs are deduplicated, looked
+// up in the cache, and the rest are sent in batches with bounded concurrency.
+// It is safe for concurrent use.
+type Embedder struct {
+	client         *client.Client
+	model          string
+	dimensions     *int
+	encodingFormat string
+	batchSize      int
+	concurrency    int
+	cache          Cache
+
+	requests    atomic.Int64
+	tokens      atomic.Int64
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
+}
+
+// Option configures an Embedder.
+type Option func(*Embedder)
+
+// WithDimensions requests vectors with n dimensions from models that support
+// shortening them.
+func WithDimensions(n int) Option {
+	return func(e *Embedder) {
+		e.dimensions = &n
+	}
+}
+
+// WithBatchSize sets how many inputs are sent per request. Values above the
+// provider limit of 2048 are capped.
+func WithBatchSize(n int) Option {
+	return func(e *Embedder) {
+		if n > 0 {
+			e.batchSize = min(n, maxBatchSize)
+		}
+	}
+}
+
+// WithConcurrency sets how many requests may be in flight at once.
+func WithConcurrency(n int) Option {
+	return func(e *Embedder) {
+		if n > 0 {
+			e.concurrency = n
+		}
+	}
+}
+
+// WithCache puts cache in front of the embeddings endpoint.
+func WithCache(cache Cache) Option {
+	return func(e *Embedder) {
+		e.cache = cache
+	}
+}
+
+// WithFloatEncoding requests vectors as JSON numbers instead of base64, for
+// providers that do not support encoding_format=base64.
+func WithFloatEncoding() Option {
+	return func(e *Embedder) {
+		e.encodingFormat = "float"
+	}
+}
+
+// New creates an Embedder for model.
+func New(c *client.Client, model string, opts ...Option) *Embedder {
+	e := &Embedder{
+		client:         c,
+		model:          model,
+		encodingFormat: "base64",
+		batchSize:      defaultBatchSize,
+		concurrency:    defaultConcurrency,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Stats reports an Embedder's activity.
+type Stats struct {
+	Requests    int64
+	Tokens      int64
+	CacheHits   int64
+	CacheMisses int64
+}
+
+// Stats returns cumulative counts since the Embedder was created.
+func (e *Embedder) Stats() Stats {
+	return Stats{
+		Requests:    e.requests.Load(),
+		Tokens:      e.tokens.Load(),
+		CacheHits:   e.cacheHits.Load(),
+		CacheMisses: e.cacheMisses.Load(),
+	}
+}
+
+// EmbedOne returns the embedding of a single text.
+func (e *Embedder) EmbedOne(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// Embed returns one embedding per text, in the same order as texts.
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	dims := 0
+	if e.dimensions != nil {
+		dims = *e.dimensions
+	}
+
+	// first maps each distinct text to where it first appears; only those
+	// entries are looked up and sent.
+	vectors := make([][]float32, len(texts))
+	first := make(map[string]int, len(texts))
+	var pending []string
+	for i, text := range texts {
+		if _, seen := first[text]; seen {
+			continue
+		}
+		first[text] = i
+		if e.cache != nil {
+			if vec, ok := e.cache.Get(Key(e.model, dims, text)); ok {
+				e.cacheHits.Add(1)
+				vectors[i] = vec
+				continue
+			}
+			e.cacheMisses.Add(1)
+		}
+		pending = append(pending, text)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, e.concurrency)
+	)
+	for start := 0; start < len(pending); start += e.batchSize {
+		batch := pending[start:min(start+e.batchSize, len(pending))]
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := e.embedBatch(ctx, batch)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			for j, text := range batch {
+				vectors[first[text]] = result[j]
+				if e.cache != nil {
+					e.cache.Set(Key(e.model, dims, text), result[j])
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return fillDuplicates(texts, vectors, first), nil
+}
+
+type embeddingData struct {
+	Embedding json.RawMessage `json:"embedding"`
+	Index     int             `json:"index"`
+}
+
+type embeddingResponse struct {
+	Data  []embeddingData `json:"data"`
+	Usage types.Usage     `json:"usage"`
+}
+
+func (e *Embedder) embedBatch(ctx context.Context, batch []string) ([][]float32, error) {
+	req := &types.EmbeddingRequest{
+		Model:          e.model,
+		Input:          batch,
+		EncodingFormat: &e.encodingFormat,
+		Dimensions:     e.dimensions,
+	}
+
+	var resp embeddingResponse
+	e.requests.Add(1)
+	if err := e.client.Post(ctx, "/api/embeddings", req, &resp); err != nil {
+		return nil, err
+	}
+	e.tokens.Add(int64(resp.Usage.TotalTokens))
+
+	if len(resp.Data) != len(batch) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(resp.Data))
+	}
+	vectors := make([][]float32, len(batch))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(batch) || vectors[d.Index] != nil {
+			return nil, fmt.Errorf("unexpected embedding index %d", d.Index)
+		}
+		vec, err := DecodeEmbedding(d.Embedding)
+		if err != nil {
+			return nil, fmt.Errorf("embedding %d: %w", d.Index, err)
+		}
+		vectors[d.Index] = vec
+	}
+	return vectors, nil
+}
+
+// DecodeEmbedding decodes an embedding returned either as a JSON array of
+// numbers or as a base64 string of little-endian float32 values.
+func DecodeEmbedding(raw json.RawMessage) ([]float32, error) {
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 embedding: %w", err)
+		}
+		if len(data)%4 != 0 {
+			return nil, fmt.Errorf("base64 embedding has %d bytes, not a multiple of 4", len(data))
+		}
+		vec := make([]float32, len(data)/4)
+		for i := range vec {
+			vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+		}
+		return vec, nil
+	}
+
+	var floats []float64
+	if err := json.Unmarshal(raw, &floats); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding: %w", err)
+	}
+	vec := make([]float32, len(floats))
+	for i, f := range floats {
+		vec[i] = float32(f)
+	}
+	return vec, nil
+}
+
+// fillDuplicates gives repeated texts their own copy of the first
+// occurrence's vector.
+func fillDuplicates(texts []string, vectors [][]float32, first map[string]int) [][]float32 {
+	for i, text := range texts {
+		if j := first[text]; j != i {
+			vectors[i] = slices.Clone(vectors[j])
+		}
+	}
+	return vectors
+}
@@ -0,0 +1,183 @@
+package embedder
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// embeddingServer answers each input with the vector [len(input), 1], encoded
+// as requested, listing results in reverse order.
+type embeddingServer struct {
+	requests    atomic.Int32
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+	mu          sync.Mutex
+	inputs      [][]string
+}
+
+func (s *embeddingServer) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embeddings" {
+			t.Errorf("Expected path /api/embeddings, got %s", r.URL.Path)
+		}
+		s.requests.Add(1)
+		n := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		for {
+			m := s.maxInFlight.Load()
+			if n <= m || s.maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var req struct {
+			Input          []string `json:"input"`
+			EncodingFormat string   `json:"encoding_format"`
+			Dimensions     *int     `json:"dimensions"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		s.mu.Lock()
+		s.inputs = append(s.inputs, req.Input)
+		s.mu.Unlock()
+
+		data := make([]map[string]interface{}, 0, len(req.Input))
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			vec := []float32{float32(len(req.Input[i])), 1}
+			var embedding interface{} = vec
+			if req.EncodingFormat == "base64" {
+				buf := make([]byte, 0, 8)
+				for _, v := range vec {
+					buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+				}
+				embedding = base64.StdEncoding.EncodeToString(buf)
+			}
+			data = append(data, map[string]interface{}{"object": "embedding", "index": i, "embedding": embedding})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"object": "list",
+			"data":   data,
+			"usage":  types.Usage{PromptTokens: len(req.Input), TotalTokens: len(req.Input)},
+		})
+	}
+}
+
+func TestEmbedBatchesInOrder(t *testing.T) {
+	srv := &embeddingServer{}
+	server := httptest.NewServer(srv.handler(t))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	e := New(c, "text-embedding-3-small", WithBatchSize(2), WithConcurrency(2))
+
+	texts := []string{"a", "bb", "ccc", "bb", "dddd", "eeeee", "ffffff"}
+	vectors, err := e.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	for i, text := range texts {
+		if len(vectors[i]) != 2 || vectors[i][0] != float32(len(text)) {
+			t.Errorf("vector %d = %v, want [%d 1]", i, vectors[i], len(text))
+		}
+	}
+	if got := srv.requests.Load(); got != 3 {
+		t.Errorf("expected 6 distinct texts in 3 requests, got %d", got)
+	}
+	if got := srv.maxInFlight.Load(); got > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", got)
+	}
+	vectors[3][0] = 42
+	if vectors[1][0] == 42 {
+		t.Error("duplicate texts should not share a vector")
+	}
+	if stats := e.Stats(); stats.Requests != 3 || stats.Tokens != 6 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestEmbedFloatEncoding(t *testing.T) {
+	srv := &embeddingServer{}
+	server := httptest.NewServer(srv.handler(t))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	vec, err := New(c, "text-embedding-3-small", WithFloatEncoding()).EmbedOne(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("EmbedOne() error = %v", err)
+	}
+	if len(vec) != 2 || vec[0] != 5 {
+		t.Errorf("unexpected vector %v", vec)
+	}
+}
+
+func TestEmbedUsesCache(t *testing.T) {
+	srv := &embeddingServer{}
+	server := httptest.NewServer(srv.handler(t))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	cache := NewLRUCache(100)
+	e := New(c, "text-embedding-3-small", WithCache(cache), WithDimensions(2))
+
+	ctx := context.Background()
+	if _, err := e.Embed(ctx, []string{"one", "two"}); err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+	vectors, err := e.Embed(ctx, []string{"two", "three", "one"})
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	if vectors[0][0] != 3 || vectors[1][0] != 5 || vectors[2][0] != 3 {
+		t.Errorf("unexpected vectors %v", vectors)
+	}
+	if len(srv.inputs) != 2 || len(srv.inputs[1]) != 1 || srv.inputs[1][0] != "three" {
+		t.Errorf("expected only the uncached text to be sent, got %v", srv.inputs)
+	}
+	if stats := e.Stats(); stats.CacheHits != 2 || stats.CacheMisses != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if _, ok := cache.Get(Key("text-embedding-3-small", 2, "three")); !ok {
+		t.Error("expected new embedding to be cached")
+	}
+}
+
+func TestEmbedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "input too long"}`))
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	_, err := New(c, "text-embedding-3-small", WithBatchSize(1)).Embed(context.Background(), []string{"a", "b", "c"})
+	if _, ok := err.(*client.APIError); !ok {
+		t.Fatalf("expected *client.APIError, got %v", err)
+	}
+}
+
+func TestDecodeEmbedding(t *testing.T) {
+	vec, err := DecodeEmbedding(json.RawMessage(`"AACAPwAAAMA="`))
+	if err != nil {
+		t.Fatalf("DecodeEmbedding() error = %v", err)
+	}
+	if len(vec) != 2 || vec[0] != 1 || vec[1] != -2 {
+		t.Errorf("unexpected vector %v", vec)
+	}
+	if _, err := DecodeEmbedding(json.RawMessage(`"AAA="`)); err == nil {
+		t.Error("expected error for truncated base64 vector")
+	}
+}
@@ -3,10 +3,7 @@ package integrations
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 
 	"github.com/rizome-dev/go-keywordsai/pkg/client"
 	"github.com/rizome-dev/go-keywordsai/pkg/types"
@@ -21,41 +18,35 @@ func NewService(client *client.Client) *Service {
 }
 
 func (s *Service) TextToSpeech(ctx context.Context, req *types.TTSRequest) ([]byte, error) {
-	// TTS returns audio data, so we need custom handling
 	var buf bytes.Buffer
-	body, _ := json.Marshal(req)
-	
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", s.client.BaseURL()+"/api/audio/speech", bytes.NewReader(body))
+	_, err := s.TextToSpeechTo(ctx, &buf, req)
 	if err != nil {
 		return nil, err
 	}
-	
-	httpReq.Header.Set("Authorization", "Bearer "+s.client.APIKey())
-	httpReq.Header.Set("Content-Type", "application/json")
-	
-	resp, err := s.client.HTTPClient().Do(httpReq)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode >= 400 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: status=%d, body=%s", resp.StatusCode, string(bodyBytes))
-	}
-	
-	_, err = io.Copy(&buf, resp.Body)
-	return buf.Bytes(), err
+	return buf.Bytes(), nil
 }
 
+// SpeechToText transcribes in-memory audio. The format is sniffed from the
+// data and assumed to be WAV when unrecognised. See SpeechToTextReader for
+// streaming uploads and large files.
+//
+// With the verbose_json response format the result includes segments, and
+// words when requested through TimestampGranularities. With the text, srt and
+// vtt formats the raw response body is returned in Text.
 func (s *Service) SpeechToText(ctx context.Context, audioData []byte, req *types.STTRequest) (*types.STTResponse, error) {
-	// Build multipart fields
-	fields := []client.MultipartField{
-		{Name: "model", Value: req.Model},
-		{Name: "file", IsFile: true, FileName: "audio.wav", Data: audioData},
+	format := sniffAudioFormat(audioData)
+	if format == "" {
+		return s.transcribe(ctx, bytes.NewReader(audioData), "audio.wav", audioContentTypes["wav"], req)
 	}
+	return s.SpeechToTextReader(ctx, AudioFile{
+		Reader: bytes.NewReader(audioData),
+		Format: format,
+		Size:   int64(len(audioData)),
+	}, req)
+}
 
-	// Add optional fields
+func sttFields(req *types.STTRequest) []client.MultipartField {
+	var fields []client.MultipartField
 	if req.ResponseFormat != nil {
 		fields = append(fields, client.MultipartField{Name: "response_format", Value: *req.ResponseFormat})
 	}
@@ -68,10 +59,10 @@ func (s *Service) SpeechToText(ctx context.Context, audioData []byte, req *types
 	if req.Prompt != nil {
 		fields = append(fields, client.MultipartField{Name: "prompt", Value: *req.Prompt})
 	}
-
-	var result types.STTResponse
-	err := s.client.PostMultipart(ctx, "/api/audio/transcriptions", fields, &result)
-	return &result, err
+	for _, granularity := range req.TimestampGranularities {
+		fields = append(fields, client.MultipartField{Name: "timestamp_granularities[]", Value: granularity})
+	}
+	return fields
 }
 
 func (s *Service) CreateEmbeddings(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
@@ -0,0 +1,86 @@
+package integrations
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// defaultSpeechFormat is the audio format the API uses when the request sets
+// no ResponseFormat.
+const defaultSpeechFormat = "mp3"
+
+var speechContentTypes = map[string]string{
+	"mp3":  "audio/mpeg",
+	"opus": "audio/ogg",
+	"aac":  "audio/aac",
+	"flac": "audio/flac",
+	"wav":  "audio/wav",
+	"pcm":  "audio/pcm",
+}
+
+// SpeechStream is synthesized audio read while it is still being generated.
+// The caller must close it; closing early or cancelling the request context
+// stops the download.
+type SpeechStream struct {
+	io.ReadCloser
+	// ContentType is the response's media type, or the one implied by Format
+	// when the server does not send one.
+	ContentType string
+	// Format is the requested response format, e.g. "mp3" or "opus".
+	Format string
+}
+
+// SpeechInfo describes audio written by TextToSpeechTo.
+type SpeechInfo struct {
+	ContentType string
+	Format      string
+	Bytes       int64
+}
+
+// TextToSpeechStream starts speech synthesis and returns the audio as it
+// arrives instead of buffering it. The client's timeout only applies until the
+// response headers arrive, so long narrations are not cut off; use a ctx
+// deadline to bound the whole download.
+func (s *Service) TextToSpeechStream(ctx context.Context, req *types.TTSRequest) (*SpeechStream, error) {
+	resp, err := s.client.Stream(ctx, http.MethodPost, "/api/audio/speech", req)
+	if err != nil {
+		return nil, err
+	}
+
+	format := defaultSpeechFormat
+	if req.ResponseFormat != nil && *req.ResponseFormat != "" {
+		format = *req.ResponseFormat
+	}
+	return &SpeechStream{
+		ReadCloser:  resp.Body,
+		ContentType: speechContentType(resp.Header.Get("Content-Type"), format),
+		Format:      format,
+	}, nil
+}
+
+// TextToSpeechTo synthesizes speech and copies the audio into w as it arrives.
+func (s *Service) TextToSpeechTo(ctx context.Context, w io.Writer, req *types.TTSRequest) (*SpeechInfo, error) {
+	stream, err := s.TextToSpeechStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	n, err := io.Copy(w, stream)
+	info := &SpeechInfo{ContentType: stream.ContentType, Format: stream.Format, Bytes: n}
+	return info, err
+}
+
+func speechContentType(header, format string) string {
+	if mediaType, _, err := mime.ParseMediaType(header); err == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	if ct, ok := speechContentTypes[format]; ok {
+		return ct
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,153 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+func TestTextToSpeechTo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-key" {
+			t.Errorf("Expected auth header, got %s", auth)
+		}
+		w.Header().Set("Content-Type", "audio/ogg; codecs=opus")
+		w.Write([]byte("chunk-1"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("chunk-2"))
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	s := NewService(c)
+
+	var buf bytes.Buffer
+	info, err := s.TextToSpeechTo(context.Background(), &buf, &types.TTSRequest{
+		Model:          "tts-1",
+		Input:          "Hello",
+		Voice:          "alloy",
+		ResponseFormat: utils.String("opus"),
+	})
+	if err != nil {
+		t.Fatalf("TextToSpeechTo() error = %v", err)
+	}
+	if buf.String() != "chunk-1chunk-2" || info.Bytes != int64(buf.Len()) {
+		t.Errorf("unexpected audio %q (%d bytes reported)", buf.String(), info.Bytes)
+	}
+	if info.ContentType != "audio/ogg" || info.Format != "opus" {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
+
+func TestTextToSpeechStreamDefaultsFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("audio"))
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	stream, err := NewService(c).TextToSpeechStream(context.Background(), &types.TTSRequest{Model: "tts-1", Input: "Hi", Voice: "alloy"})
+	if err != nil {
+		t.Fatalf("TextToSpeechStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	if stream.Format != "mp3" || stream.ContentType != "audio/mpeg" {
+		t.Errorf("unexpected format %s / %s", stream.Format, stream.ContentType)
+	}
+}
+
+func TestTextToSpeechStreamAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "invalid voice"}`))
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	_, err := NewService(c).TextToSpeechStream(context.Background(), &types.TTSRequest{Voice: "nope"})
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected *client.APIError with status 400, got %v", err)
+	}
+}
+
+func TestTextToSpeechStreamCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	stream, err := NewService(c).TextToSpeechStream(ctx, &types.TTSRequest{Model: "tts-1", Input: "Hi", Voice: "alloy"})
+	if err != nil {
+		t.Fatalf("TextToSpeechStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	first := make([]byte, 5)
+	if _, err := io.ReadFull(stream, first); err != nil || string(first) != "first" {
+		t.Fatalf("expected first chunk before the response completes, got %q, %v", first, err)
+	}
+
+	cancel()
+	if _, err := io.ReadAll(stream); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled after cancel, got %v", err)
+	}
+}
+
+func TestTextToSpeechStreamOutlivesClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Slow-Headers") != "" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("chunk-1"))
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("chunk-2"))
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL), client.WithTimeout(50*time.Millisecond))
+	var buf bytes.Buffer
+	if _, err := NewService(c).TextToSpeechTo(context.Background(), &buf, &types.TTSRequest{Model: "tts-1", Input: "Hi", Voice: "alloy"}); err != nil {
+		t.Fatalf("TextToSpeechTo() error = %v", err)
+	}
+	if buf.String() != "chunk-1chunk-2" {
+		t.Errorf("expected the full body despite the client timeout, got %q", buf.String())
+	}
+
+	// The timeout still bounds the wait for headers.
+	slow := client.New("test-key", client.WithBaseURL(server.URL),
+		client.WithHTTPClient(&http.Client{Transport: headerTransport{"X-Slow-Headers", "1"}}), client.WithTimeout(50*time.Millisecond))
+	if _, err := NewService(slow).TextToSpeechStream(context.Background(), &types.TTSRequest{Model: "tts-1", Input: "Hi", Voice: "alloy"}); err == nil {
+		t.Error("expected slow response headers to time out")
+	}
+}
+
+type headerTransport struct{ key, value string }
+
+func (h headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set(h.key, h.value)
+	return http.DefaultTransport.RoundTrip(r)
+}
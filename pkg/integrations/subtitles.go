@@ -0,0 +1,42 @@
+package integrations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// ToSRT renders transcription segments as SubRip subtitles.
+func ToSRT(segments []types.STTSegment) string {
+	var b strings.Builder
+	for i, seg := range segments {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n", i+1,
+			subtitleTimestamp(seg.Start, ","), subtitleTimestamp(seg.End, ","), strings.TrimSpace(seg.Text))
+	}
+	return b.String()
+}
+
+// ToWebVTT renders transcription segments as WebVTT captions.
+func ToWebVTT(segments []types.STTSegment) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for _, seg := range segments {
+		fmt.Fprintf(&b, "\n%s --> %s\n%s\n",
+			subtitleTimestamp(seg.Start, "."), subtitleTimestamp(seg.End, "."), strings.TrimSpace(seg.Text))
+	}
+	return b.String()
+}
+
+// subtitleTimestamp formats seconds as HH:MM:SS followed by sep and
+// milliseconds.
+func subtitleTimestamp(seconds float64, sep string) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	ms := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,31 @@
+package integrations
+
+import (
+	"testing"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+var testSegments = []types.STTSegment{
+	{ID: 0, Start: 0, End: 1.5, Text: " Hello there."},
+	{ID: 1, Start: 1.5, End: 3661.25, Text: " General Kenobi. "},
+}
+
+func TestToSRT(t *testing.T) {
+	want := "1\n00:00:00,000 --> 00:00:01,500\nHello there.\n\n" +
+		"2\n00:00:01,500 --> 01:01:01,250\nGeneral Kenobi.\n"
+	if got := ToSRT(testSegments); got != want {
+		t.Errorf("ToSRT() = %q, want %q", got, want)
+	}
+}
+
+func TestToWebVTT(t *testing.T) {
+	want := "WEBVTT\n\n00:00:00.000 --> 00:00:01.500\nHello there.\n\n" +
+		"00:00:01.500 --> 01:01:01.250\nGeneral Kenobi.\n"
+	if got := ToWebVTT(testSegments); got != want {
+		t.Errorf("ToWebVTT() = %q, want %q", got, want)
+	}
+	if got := ToWebVTT(nil); got != "WEBVTT\n" {
+		t.Errorf("ToWebVTT(nil) = %q", got)
+	}
+}
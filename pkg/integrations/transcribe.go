@@ -0,0 +1,402 @@
+package integrations
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+// DefaultUploadLimit is the largest file the transcription endpoint accepts.
+const DefaultUploadLimit = 25 << 20
+
+var (
+	// ErrUnknownAudioFormat is returned when the audio format is neither given
+	// nor recognisable from the file name or contents.
+	ErrUnknownAudioFormat = errors.New("unknown audio format")
+	// ErrAudioTooLarge is returned for audio above the upload limit that
+	// cannot be split. Only PCM WAV audio is split automatically.
+	ErrAudioTooLarge = errors.New("audio exceeds upload limit")
+)
+
+var audioContentTypes = map[string]string{
+	"mp3":  "audio/mpeg",
+	"mp4":  "audio/mp4",
+	"m4a":  "audio/mp4",
+	"ogg":  "audio/ogg",
+	"webm": "audio/webm",
+	"flac": "audio/flac",
+	"wav":  "audio/wav",
+}
+
+// AudioFile is audio to transcribe, read as it is uploaded.
+type AudioFile struct {
+	Reader io.Reader
+	// Name is the file name sent with the upload. Defaults to "audio.<format>".
+	Name string
+	// Format is the audio format: mp3, mp4, m4a, ogg, webm, flac or wav. When
+	// empty it is taken from Name's extension or sniffed from the contents.
+	Format string
+	// Size is the file size in bytes, or 0 if unknown. It lets files that
+	// cannot be split fail before uploading.
+	Size int64
+}
+
+// STTOption configures SpeechToTextReader.
+type STTOption func(*sttConfig)
+
+type sttConfig struct {
+	uploadLimit int64
+}
+
+// WithUploadLimit overrides DefaultUploadLimit. WAV audio larger than limit
+// is transcribed in several requests.
+func WithUploadLimit(limit int64) STTOption {
+	return func(c *sttConfig) {
+		c.uploadLimit = limit
+	}
+}
+
+// SpeechToTextReader transcribes audio streamed from a reader. WAV audio above
+// the upload limit is split near its quietest points, transcribed piece by
+// piece and stitched back into one response.
+func (s *Service) SpeechToTextReader(ctx context.Context, audio AudioFile, req *types.STTRequest, opts ...STTOption) (*types.STTResponse, error) {
+	cfg := sttConfig{uploadLimit: DefaultUploadLimit}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	br := bufio.NewReader(audio.Reader)
+	format := strings.ToLower(strings.TrimPrefix(audio.Format, "."))
+	if format == "" {
+		format = strings.ToLower(strings.TrimPrefix(path.Ext(audio.Name), "."))
+	}
+	if _, ok := audioContentTypes[format]; !ok {
+		head, _ := br.Peek(16)
+		format = sniffAudioFormat(head)
+	}
+	if format == "" {
+		return nil, ErrUnknownAudioFormat
+	}
+	name := audio.Name
+	if name == "" {
+		name = "audio." + format
+	}
+
+	if format == "wav" {
+		return s.transcribeWAV(ctx, br, name, req, cfg.uploadLimit)
+	}
+	if audio.Size > cfg.uploadLimit {
+		return nil, fmt.Errorf("%w: %s audio is %d bytes, limit is %d", ErrAudioTooLarge, format, audio.Size, cfg.uploadLimit)
+	}
+	return s.transcribe(ctx, br, name, audioContentTypes[format], req)
+}
+
+// transcribe uploads one file. For the text, srt and vtt response formats the
+// raw body is returned in Text.
+func (s *Service) transcribe(ctx context.Context, r io.Reader, name, contentType string, req *types.STTRequest) (*types.STTResponse, error) {
+	fields := []client.MultipartField{
+		{Name: "model", Value: req.Model},
+		{Name: "file", IsFile: true, FileName: name, ContentType: contentType, Reader: r},
+	}
+	fields = append(fields, sttFields(req)...)
+
+	if isTextFormat(responseFormat(req)) {
+		var text string
+		err := s.client.PostMultipart(ctx, "/api/audio/transcriptions", fields, &text)
+		return &types.STTResponse{Text: text}, err
+	}
+
+	var result types.STTResponse
+	err := s.client.PostMultipart(ctx, "/api/audio/transcriptions", fields, &result)
+	return &result, err
+}
+
+func responseFormat(req *types.STTRequest) string {
+	if req.ResponseFormat == nil {
+		return ""
+	}
+	return *req.ResponseFormat
+}
+
+func isTextFormat(format string) bool {
+	return format == "text" || format == "srt" || format == "vtt"
+}
+
+// transcribeWAV uploads a WAV file as-is when it fits the limit and otherwise
+// splits its PCM data into separately transcribed pieces.
+func (s *Service) transcribeWAV(ctx context.Context, r io.Reader, name string, req *types.STTRequest, limit int64) (*types.STTResponse, error) {
+	var header bytes.Buffer
+	wav, err := readWAVHeader(io.TeeReader(r, &header))
+	if err != nil {
+		return nil, err
+	}
+	if wav.dataSize > 0 && int64(header.Len())+wav.dataSize <= limit {
+		return s.transcribe(ctx, io.MultiReader(&header, r), name, audioContentTypes["wav"], req)
+	}
+	if wav.dataSize == 0 {
+		// The length is unknown, so buffer up to the limit to find out
+		// whether the file needs splitting at all.
+		var head bytes.Buffer
+		if _, err := io.Copy(&head, io.LimitReader(r, max(limit-int64(header.Len())+1, 0))); err != nil {
+			return nil, fmt.Errorf("failed to read audio: %w", err)
+		}
+		if int64(header.Len()+head.Len()) <= limit {
+			return s.transcribe(ctx, io.MultiReader(&header, &head), name, audioContentTypes["wav"], req)
+		}
+		r = io.MultiReader(&head, r)
+	}
+	if wav.audioFormat != wavFormatPCM && wav.audioFormat != wavFormatExtensible {
+		return nil, fmt.Errorf("%w: WAV audio format %d cannot be split", ErrAudioTooLarge, wav.audioFormat)
+	}
+
+	data := r
+	if wav.dataSize > 0 {
+		data = io.LimitReader(r, wav.dataSize)
+	}
+	chunkSize := (limit - int64(wav.chunkHeaderLen())) / int64(wav.blockAlign) * int64(wav.blockAlign)
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("%w: limit %d is too small to split WAV audio", ErrAudioTooLarge, limit)
+	}
+
+	// Subtitles can't be joined as text, so request segments and render
+	// them once the parts are stitched together.
+	subtitles := responseFormat(req)
+	if subtitles == "srt" || subtitles == "vtt" {
+		verbose := *req
+		verbose.ResponseFormat = utils.String("verbose_json")
+		req = &verbose
+	}
+
+	var parts []*types.STTResponse
+	var durations []float64
+	buf := make([]byte, chunkSize)
+	carry := 0
+	for {
+		n, err := io.ReadFull(data, buf[carry:])
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return nil, fmt.Errorf("failed to read audio: %w", err)
+		}
+		chunk := buf[:carry+n]
+		if len(chunk) == 0 {
+			break
+		}
+
+		cut := len(chunk)
+		if !eof {
+			cut = wav.quietCut(chunk)
+		}
+		var piece bytes.Buffer
+		wav.writeHeader(&piece, cut)
+		piece.Write(chunk[:cut])
+
+		resp, err := s.transcribe(ctx, &piece, name, audioContentTypes["wav"], req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transcribe audio part %d: %w", len(parts)+1, err)
+		}
+		parts = append(parts, resp)
+		durations = append(durations, float64(cut)/float64(wav.byteRate))
+
+		carry = copy(buf, chunk[cut:])
+		if eof && carry == 0 {
+			break
+		}
+	}
+	result := stitchTranscripts(parts, durations)
+	switch subtitles {
+	case "srt":
+		result = &types.STTResponse{Text: ToSRT(result.Segments)}
+	case "vtt":
+		result = &types.STTResponse{Text: ToWebVTT(result.Segments)}
+	}
+	return result, nil
+}
+
+// stitchTranscripts joins the transcripts of consecutive audio pieces,
+// shifting segment and word timestamps by the length of the preceding pieces.
+// durations holds each piece's exact length.
+func stitchTranscripts(parts []*types.STTResponse, durations []float64) *types.STTResponse {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+
+	result := &types.STTResponse{Task: parts[0].Task, Metadata: map[string]interface{}{"chunks": len(parts)}}
+	texts := make([]string, 0, len(parts))
+	offset := 0.0
+	for i, part := range parts {
+		if text := strings.TrimSpace(part.Text); text != "" {
+			texts = append(texts, text)
+		}
+		if result.Language == "" {
+			result.Language = part.Language
+		}
+		if part.Duration > 0 {
+			result.Duration += part.Duration
+		} else {
+			result.Duration += durations[i]
+		}
+		for _, seg := range part.Segments {
+			seg.ID = len(result.Segments)
+			seg.Start += offset
+			seg.End += offset
+			result.Segments = append(result.Segments, seg)
+		}
+		for _, word := range part.Words {
+			word.Start += offset
+			word.End += offset
+			result.Words = append(result.Words, word)
+		}
+		offset += durations[i]
+	}
+	result.Text = strings.Join(texts, " ")
+	return result
+}
+
+// sniffAudioFormat recognises an audio container from its first bytes.
+func sniffAudioFormat(head []byte) string {
+	switch {
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return "wav"
+	case len(head) >= 4 && string(head[:4]) == "fLaC":
+		return "flac"
+	case len(head) >= 4 && string(head[:4]) == "OggS":
+		return "ogg"
+	case len(head) >= 4 && bytes.Equal(head[:4], []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return "webm"
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		if string(head[8:11]) == "M4A" {
+			return "m4a"
+		}
+		return "mp4"
+	case len(head) >= 3 && string(head[:3]) == "ID3":
+		return "mp3"
+	case len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0 && head[1]&0x06 != 0:
+		// MPEG frame sync with a non-zero layer; ADTS AAC uses layer 0.
+		return "mp3"
+	}
+	return ""
+}
+
+const (
+	wavFormatPCM        = 1
+	wavFormatExtensible = 0xFFFE
+)
+
+type wavInfo struct {
+	fmtChunk      []byte
+	audioFormat   uint16
+	byteRate      uint32
+	blockAlign    uint16
+	bitsPerSample uint16
+	// dataSize is the length of the data chunk, or 0 if the header does not
+	// say (as in WAV files written while streaming).
+	dataSize int64
+}
+
+// readWAVHeader reads a RIFF header up to the start of the data chunk.
+func readWAVHeader(r io.Reader) (*wavInfo, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, fmt.Errorf("failed to read WAV header: %w", err)
+	}
+	if string(riff[:4]) != "RIFF" || string(riff[8:]) != "WAVE" {
+		return nil, fmt.Errorf("%w: not a RIFF/WAVE file", ErrUnknownAudioFormat)
+	}
+
+	info := &wavInfo{}
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return nil, fmt.Errorf("failed to read WAV chunk: %w", err)
+		}
+		id := string(hdr[:4])
+		size := binary.LittleEndian.Uint32(hdr[4:])
+
+		if id == "data" {
+			if info.fmtChunk == nil {
+				return nil, errors.New("WAV data chunk precedes fmt chunk")
+			}
+			if size != 0 && size != 0xFFFFFFFF {
+				info.dataSize = int64(size)
+			}
+			return info, nil
+		}
+
+		body := make([]byte, int64(size)+int64(size&1))
+		if _, err := io.ReadFull(r, body); err != nil {
+			return nil, fmt.Errorf("failed to read WAV %q chunk: %w", id, err)
+		}
+		if id == "fmt " {
+			if size < 16 {
+				return nil, errors.New("WAV fmt chunk is too short")
+			}
+			info.fmtChunk = body[:size]
+			info.audioFormat = binary.LittleEndian.Uint16(body[0:])
+			info.byteRate = binary.LittleEndian.Uint32(body[8:])
+			info.blockAlign = binary.LittleEndian.Uint16(body[12:])
+			info.bitsPerSample = binary.LittleEndian.Uint16(body[14:])
+			if info.blockAlign == 0 || info.byteRate == 0 {
+				return nil, errors.New("WAV fmt chunk has zero block align or byte rate")
+			}
+		}
+	}
+}
+
+func (w *wavInfo) chunkHeaderLen() int {
+	return 12 + 8 + len(w.fmtChunk) + 8
+}
+
+// writeHeader writes a minimal WAV header for dataLen bytes of audio.
+func (w *wavInfo) writeHeader(buf *bytes.Buffer, dataLen int) {
+	le := binary.LittleEndian
+	buf.WriteString("RIFF")
+	buf.Write(le.AppendUint32(nil, uint32(w.chunkHeaderLen()-8+dataLen)))
+	buf.WriteString("WAVEfmt ")
+	buf.Write(le.AppendUint32(nil, uint32(len(w.fmtChunk))))
+	buf.Write(w.fmtChunk)
+	buf.WriteString("data")
+	buf.Write(le.AppendUint32(nil, uint32(dataLen)))
+}
+
+// quietCut picks where to end a chunk: the start of the quietest 20ms window
+// in its last two seconds (at most a quarter of the chunk), so that a word is
+// unlikely to be cut in half. Only 16-bit audio is analysed; other depths are
+// cut at the end of the chunk.
+func (w *wavInfo) quietCut(chunk []byte) int {
+	align := int(w.blockAlign)
+	end := len(chunk) / align * align
+	if w.bitsPerSample != 16 {
+		return end
+	}
+
+	window := max(int(w.byteRate)/50/align*align, align)
+	search := min(int(w.byteRate)*2, end/4) / align * align
+	best, bestEnergy := end, int64(-1)
+	for start := end - search; start+window <= end; start += window {
+		var energy int64
+		for i := start; i+1 < start+window; i += 2 {
+			sample := int64(int16(binary.LittleEndian.Uint16(chunk[i:])))
+			if sample < 0 {
+				sample = -sample
+			}
+			energy += sample
+		}
+		if bestEnergy < 0 || energy < bestEnergy {
+			best, bestEnergy = start, energy
+		}
+	}
+	if best == 0 {
+		return end
+	}
+	return best
+}
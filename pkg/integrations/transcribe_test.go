@@ -0,0 +1,334 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+func TestSpeechToTextReaderSniffsFormat(t *testing.T) {
+	audio := append([]byte("ID3\x04\x00\x00"), bytes.Repeat([]byte{0}, 64)...)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength != -1 {
+			t.Errorf("expected a streamed body, got Content-Length %d", r.ContentLength)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Expected file upload, got error: %v", err)
+		}
+		defer file.Close()
+		if header.Filename != "audio.mp3" {
+			t.Errorf("Expected filename audio.mp3, got %s", header.Filename)
+		}
+		if ct := header.Header.Get("Content-Type"); ct != "audio/mpeg" {
+			t.Errorf("Expected content type audio/mpeg, got %s", ct)
+		}
+		data, _ := io.ReadAll(file)
+		if !bytes.Equal(data, audio) {
+			t.Errorf("uploaded data does not match")
+		}
+		json.NewEncoder(w).Encode(types.STTResponse{Text: "hello"})
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	result, err := NewService(c).SpeechToTextReader(context.Background(),
+		AudioFile{Reader: bytes.NewReader(audio)}, &types.STTRequest{Model: "whisper-1"})
+	if err != nil {
+		t.Fatalf("SpeechToTextReader() error = %v", err)
+	}
+	if result.Text != "hello" {
+		t.Errorf("Expected text hello, got %s", result.Text)
+	}
+}
+
+func TestSpeechToTextReaderUsesName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Expected file upload, got error: %v", err)
+		}
+		if header.Filename != "meeting.m4a" || header.Header.Get("Content-Type") != "audio/mp4" {
+			t.Errorf("unexpected file part %s %s", header.Filename, header.Header.Get("Content-Type"))
+		}
+		json.NewEncoder(w).Encode(types.STTResponse{Text: "ok"})
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	_, err := NewService(c).SpeechToTextReader(context.Background(),
+		AudioFile{Reader: strings.NewReader("not sniffable"), Name: "meeting.m4a"}, &types.STTRequest{Model: "whisper-1"})
+	if err != nil {
+		t.Fatalf("SpeechToTextReader() error = %v", err)
+	}
+}
+
+func TestSpeechToTextReaderErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request expected")
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	s := NewService(c)
+	req := &types.STTRequest{Model: "whisper-1"}
+
+	_, err := s.SpeechToTextReader(context.Background(), AudioFile{Reader: strings.NewReader("???")}, req)
+	if !errors.Is(err, ErrUnknownAudioFormat) {
+		t.Errorf("expected ErrUnknownAudioFormat, got %v", err)
+	}
+
+	_, err = s.SpeechToTextReader(context.Background(),
+		AudioFile{Reader: strings.NewReader("fLaC"), Size: 2000}, req, WithUploadLimit(1000))
+	if !errors.Is(err, ErrAudioTooLarge) {
+		t.Errorf("expected ErrAudioTooLarge, got %v", err)
+	}
+}
+
+func TestSpeechToTextReaderSplitsWAV(t *testing.T) {
+	const rate = 8000
+	// 3s of tone with a short pause at 1.6s-1.8s.
+	samples := make([]int16, 3*rate)
+	for i := range samples {
+		if i >= 16*rate/10 && i < 18*rate/10 {
+			continue
+		}
+		samples[i] = int16(8000 * math.Sin(float64(i)/5))
+	}
+	audio := testWAV(rate, samples)
+
+	var parts []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Expected file upload, got error: %v", err)
+		}
+		defer file.Close()
+		wav, err := readWAVHeader(file)
+		if err != nil {
+			t.Fatalf("part is not a valid WAV file: %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		if int64(len(data)) != wav.dataSize {
+			t.Errorf("data chunk size %d does not match %d bytes sent", wav.dataSize, len(data))
+		}
+		parts = append(parts, len(data))
+		json.NewEncoder(w).Encode(types.STTResponse{Text: fmt.Sprintf(" part %d ", len(parts)), Language: "en"})
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	result, err := NewService(c).SpeechToTextReader(context.Background(),
+		AudioFile{Reader: bytes.NewReader(audio), Name: "call.wav"},
+		&types.STTRequest{Model: "whisper-1"},
+		WithUploadLimit(44+2*rate*2))
+	if err != nil {
+		t.Fatalf("SpeechToTextReader() error = %v", err)
+	}
+
+	if len(parts) != 2 {
+		t.Fatalf("expected 2 parts, got %v", parts)
+	}
+	if parts[0] != 16*rate/10*2 {
+		t.Errorf("expected first part to end at the pause (%d bytes), got %d", 16*rate/10*2, parts[0])
+	}
+	if parts[0]+parts[1] != len(samples)*2 {
+		t.Errorf("expected all audio to be sent, got %v", parts)
+	}
+	if result.Text != "part 1 part 2" || result.Language != "en" {
+		t.Errorf("unexpected stitched result %+v", result)
+	}
+	if math.Abs(result.Duration-3) > 1e-9 {
+		t.Errorf("Duration = %v, want 3", result.Duration)
+	}
+}
+
+func TestSpeechToTextReaderSmallWAV(t *testing.T) {
+	audio := testWAV(8000, make([]int16, 800))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Expected file upload, got error: %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		if !bytes.Equal(data, audio) {
+			t.Errorf("expected WAV file to be uploaded unchanged")
+		}
+		json.NewEncoder(w).Encode(types.STTResponse{Text: "short", Duration: 0.1})
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	result, err := NewService(c).SpeechToText(context.Background(), audio, &types.STTRequest{Model: "whisper-1"})
+	if err != nil {
+		t.Fatalf("SpeechToText() error = %v", err)
+	}
+	if result.Text != "short" {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestSpeechToTextReaderStreamedNonPCMWAV(t *testing.T) {
+	// A float WAV written while streaming, with no data chunk size.
+	audio := testWAV(8000, make([]int16, 800))
+	binary.LittleEndian.PutUint16(audio[20:], 3)
+	binary.LittleEndian.PutUint32(audio[40:], 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Expected file upload, got error: %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		if !bytes.Equal(data, audio) {
+			t.Errorf("expected WAV file to be uploaded unchanged")
+		}
+		json.NewEncoder(w).Encode(types.STTResponse{Text: "short"})
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	s := NewService(c)
+	req := &types.STTRequest{Model: "whisper-1"}
+	result, err := s.SpeechToTextReader(context.Background(),
+		AudioFile{Reader: bytes.NewReader(audio), Name: "stream.wav"}, req, WithUploadLimit(int64(len(audio))))
+	if err != nil {
+		t.Fatalf("SpeechToTextReader() error = %v", err)
+	}
+	if result.Text != "short" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	_, err = s.SpeechToTextReader(context.Background(),
+		AudioFile{Reader: bytes.NewReader(audio), Name: "stream.wav"}, req, WithUploadLimit(int64(len(audio)-1)))
+	if !errors.Is(err, ErrAudioTooLarge) {
+		t.Errorf("expected ErrAudioTooLarge above the limit, got %v", err)
+	}
+}
+
+func TestSpeechToTextVerboseJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		if got := r.MultipartForm.Value["timestamp_granularities[]"]; len(got) != 2 || got[1] != "word" {
+			t.Errorf("unexpected timestamp granularities %v", got)
+		}
+		w.Write([]byte(`{"task":"transcribe","language":"english","duration":1.2,"text":"Hi you",
+			"segments":[{"id":0,"start":0,"end":1.2,"text":" Hi you","avg_logprob":-0.2}],
+			"words":[{"word":"Hi","start":0,"end":0.4},{"word":"you","start":0.5,"end":1.1}]}`))
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	result, err := NewService(c).SpeechToText(context.Background(), []byte("audio"), &types.STTRequest{
+		Model:                  "whisper-1",
+		ResponseFormat:         utils.String("verbose_json"),
+		TimestampGranularities: []string{"segment", "word"},
+	})
+	if err != nil {
+		t.Fatalf("SpeechToText() error = %v", err)
+	}
+	if len(result.Segments) != 1 || result.Segments[0].AvgLogprob != -0.2 {
+		t.Errorf("unexpected segments %+v", result.Segments)
+	}
+	if len(result.Words) != 2 || result.Words[1].Word != "you" {
+		t.Errorf("unexpected words %+v", result.Words)
+	}
+}
+
+func TestSpeechToTextTextFormats(t *testing.T) {
+	const srt = "1\n00:00:00,000 --> 00:00:01,000\nHi\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(srt))
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	result, err := NewService(c).SpeechToText(context.Background(), []byte("audio"), &types.STTRequest{
+		Model:          "whisper-1",
+		ResponseFormat: utils.String("srt"),
+	})
+	if err != nil {
+		t.Fatalf("SpeechToText() error = %v", err)
+	}
+	if result.Text != srt {
+		t.Errorf("expected raw subtitles in Text, got %q", result.Text)
+	}
+}
+
+func TestSpeechToTextReaderSplitsWAVSubtitles(t *testing.T) {
+	const rate = 8000
+	audio := testWAV(rate, make([]int16, 3*rate))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if format := r.FormValue("response_format"); format != "verbose_json" {
+			t.Errorf("expected parts to request verbose_json, got %s", format)
+		}
+		json.NewEncoder(w).Encode(types.STTResponse{
+			Text:     "words",
+			Segments: []types.STTSegment{{Start: 0.5, End: 1, Text: "words"}},
+			Words:    []types.STTWord{{Word: "words", Start: 0.5, End: 1}},
+		})
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	s := NewService(c)
+	result, err := s.SpeechToTextReader(context.Background(),
+		AudioFile{Reader: bytes.NewReader(audio)},
+		&types.STTRequest{Model: "whisper-1", ResponseFormat: utils.String("vtt")},
+		WithUploadLimit(44+2*rate*2))
+	if err != nil {
+		t.Fatalf("SpeechToTextReader() error = %v", err)
+	}
+
+	// Silence throughout, so the first part is cut at the start of the
+	// search window: 1.5s into the audio.
+	want := "WEBVTT\n\n00:00:00.500 --> 00:00:01.000\nwords\n\n00:00:02.000 --> 00:00:02.500\nwords\n"
+	if result.Text != want {
+		t.Errorf("unexpected subtitles %q, want %q", result.Text, want)
+	}
+
+	stitched := stitchTranscripts([]*types.STTResponse{
+		{Segments: []types.STTSegment{{ID: 0, Start: 0, End: 1}}, Words: []types.STTWord{{Start: 0.2, End: 0.4}}},
+		{Segments: []types.STTSegment{{ID: 0, Start: 0, End: 1}}, Words: []types.STTWord{{Start: 0.2, End: 0.4}}},
+	}, []float64{1.5, 1.5})
+	if stitched.Segments[1].ID != 1 || stitched.Segments[1].Start != 1.5 || stitched.Words[1].Start != 1.7 {
+		t.Errorf("expected second part's timestamps to be offset, got %+v %+v", stitched.Segments, stitched.Words)
+	}
+}
+
+func testWAV(rate int, samples []int16) []byte {
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	dataLen := len(samples) * 2
+	buf.WriteString("RIFF")
+	binary.Write(&buf, le, uint32(36+dataLen))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, le, uint32(16))
+	binary.Write(&buf, le, uint16(wavFormatPCM))
+	binary.Write(&buf, le, uint16(1))
+	binary.Write(&buf, le, uint32(rate))
+	binary.Write(&buf, le, uint32(rate*2))
+	binary.Write(&buf, le, uint16(2))
+	binary.Write(&buf, le, uint16(16))
+	buf.WriteString("data")
+	binary.Write(&buf, le, uint32(dataLen))
+	binary.Write(&buf, le, samples)
+	return buf.Bytes()
+}
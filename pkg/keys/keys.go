@@ -3,6 +3,7 @@ package keys
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/rizome-dev/go-keywordsai/pkg/client"
@@ -51,4 +52,92 @@ func (s *Service) Update(ctx context.Context, keyID string, updates map[string]i
 func (s *Service) Delete(ctx context.Context, keyID string) error {
 	path := fmt.Sprintf("/api/temporary-keys/%s", keyID)
 	return s.client.Delete(ctx, path, nil)
-}
\ No newline at end of file
+}
+
+const (
+	// defaultTemporaryKeyTTL is the lifetime of keys minted by
+	// TemporaryKeyCredentials when no TTL is given.
+	defaultTemporaryKeyTTL = time.Hour
+	// defaultRevokeDelay is how long a superseded temporary key stays valid.
+	defaultRevokeDelay = 5 * time.Minute
+	revokeTimeout      = 30 * time.Second
+)
+
+type temporaryKeys struct {
+	revokeDelay time.Duration
+	onError     func(keyID string, err error)
+}
+
+// TemporaryKeyOption configures TemporaryKeyCredentials.
+type TemporaryKeyOption func(*temporaryKeys)
+
+// WithRevokeDelay sets how long a superseded key stays valid before it is
+// deleted, so requests still using it, such as streamed uploads that cannot
+// be retried, can finish. The default is five minutes.
+func WithRevokeDelay(d time.Duration) TemporaryKeyOption {
+	return func(t *temporaryKeys) {
+		if d >= 0 {
+			t.revokeDelay = d
+		}
+	}
+}
+
+// WithRevokeErrorHandler calls fn when a superseded key could not be deleted.
+// The key then stays valid until it expires.
+func WithRevokeErrorHandler(fn func(keyID string, err error)) TemporaryKeyOption {
+	return func(t *temporaryKeys) {
+		t.onError = fn
+	}
+}
+
+// TemporaryKeyCredentials returns a client.CredentialsProvider that mints a
+// temporary key from template through service, valid for ttl (one hour if
+// not positive). A new key is minted when 90% of ttl has passed or the
+// current key is rejected, so a client created with
+// client.WithCredentials never holds a long-lived key. The key a new one
+// replaces is deleted after WithRevokeDelay. template.ExpiresAt is ignored.
+//
+// service must use a different client, authenticated with a master key, from
+// the one these credentials are installed on; otherwise minting fails with
+// client.ErrCredentialsCycle.
+func TemporaryKeyCredentials(service *Service, template CreateKeyRequest, ttl time.Duration, opts ...TemporaryKeyOption) client.CredentialsProvider {
+	if ttl <= 0 {
+		ttl = defaultTemporaryKeyTTL
+	}
+	t := &temporaryKeys{revokeDelay: defaultRevokeDelay}
+	for _, opt := range opts {
+		opt(t)
+	}
+	var (
+		mu       sync.Mutex
+		previous string
+	)
+	return client.CredentialsProviderFunc(func(ctx context.Context) (client.Credentials, error) {
+		req := template
+		req.ExpiresAt = time.Now().Add(ttl)
+		key, err := service.Create(ctx, &req)
+		if err != nil {
+			return client.Credentials{}, fmt.Errorf("failed to create temporary key: %w", err)
+		}
+		mu.Lock()
+		superseded := previous
+		previous = key.ID
+		mu.Unlock()
+		if superseded != "" {
+			time.AfterFunc(t.revokeDelay, func() { t.revoke(service, superseded) })
+		}
+		expires := key.ExpiresAt
+		if expires.IsZero() {
+			expires = req.ExpiresAt
+		}
+		return client.Credentials{APIKey: key.Key, Expires: expires.Add(-ttl / 10)}, nil
+	})
+}
+
+func (t *temporaryKeys) revoke(service *Service, keyID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), revokeTimeout)
+	defer cancel()
+	if err := service.Delete(ctx, keyID); err != nil && t.onError != nil {
+		t.onError(keyID, fmt.Errorf("failed to delete superseded temporary key: %w", err))
+	}
+}
@@ -3,8 +3,10 @@ package keys
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -215,4 +217,63 @@ func stringPtr(s string) *string {
 
 func intPtr(i int) *int {
 	return &i
-}
\ No newline at end of file
+}
+
+func TestTemporaryKeyCredentials(t *testing.T) {
+	minted := 0
+	deleted := make(chan string, 2)
+	var deleteStatus atomic.Int32
+	deleteStatus.Store(http.StatusNoContent)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(int(deleteStatus.Load()))
+			deleted <- r.URL.Path
+			return
+		}
+		var req CreateKeyRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.ExpiresAt.IsZero() || len(req.AllowedEndpoints) != 1 {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		minted++
+		json.NewEncoder(w).Encode(types.TemporaryKey{ID: fmt.Sprintf("key_%d", minted), Key: fmt.Sprintf("tmp_%d", minted), ExpiresAt: req.ExpiresAt})
+	}))
+	defer server.Close()
+
+	failures := make(chan string, 1)
+	s := NewService(client.New("master-key", client.WithBaseURL(server.URL)))
+	provider := TemporaryKeyCredentials(s, CreateKeyRequest{AllowedEndpoints: []string{"/api/request-logs"}}, time.Hour,
+		WithRevokeDelay(10*time.Millisecond),
+		WithRevokeErrorHandler(func(keyID string, err error) { failures <- keyID }))
+
+	creds, err := provider.Retrieve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.APIKey != "tmp_1" {
+		t.Errorf("expected the minted key, got %q", creds.APIKey)
+	}
+	if until := time.Until(creds.Expires); until < 50*time.Minute || until > 55*time.Minute {
+		t.Errorf("expected a refresh 6 minutes before expiry, got %v", until)
+	}
+	rotated := time.Now()
+	if creds, _ := provider.Retrieve(context.Background()); creds.APIKey != "tmp_2" {
+		t.Errorf("expected a new key on every retrieval, got %q", creds.APIKey)
+	}
+	// The superseded key is deleted after the revoke delay.
+	if path := <-deleted; path != "/api/temporary-keys/key_1" || time.Since(rotated) < 10*time.Millisecond {
+		t.Errorf("expected key_1 to be deleted after the delay, got %s", path)
+	}
+
+	deleteStatus.Store(http.StatusInternalServerError)
+	provider.Retrieve(context.Background())
+	<-deleted
+	select {
+	case keyID := <-failures:
+		if keyID != "key_2" {
+			t.Errorf("expected the failure for key_2, got %s", keyID)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the failed delete to be reported")
+	}
+}
@@ -0,0 +1,310 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/tracing"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+const (
+	defaultBatchSize     = 500
+	defaultFlushInterval = 5 * time.Second
+	defaultQueueSize     = 10000
+	maxBatchSize         = 5000
+	defaultDedupCapacity = 100000
+)
+
+var (
+	// ErrBatcherClosed is returned when adding to a closed Batcher.
+	ErrBatcherClosed = errors.New("logs: batcher is closed")
+	// ErrQueueFull is returned when the Batcher's queue has no room for another log.
+	ErrQueueFull = errors.New("logs: batcher queue is full")
+	// ErrNilLog is returned when adding a nil log.
+	ErrNilLog = errors.New("logs: log is nil")
+)
+
+// Batcher queues logs in memory and submits them in the background with
+// BatchCreate, either when a batch fills up or on a fixed interval.
+// It is safe for concurrent use.
+type Batcher struct {
+	service       *Service
+	batchSize     int
+	flushInterval time.Duration
+	queueSize     int
+	onError       func(error, []types.RequestLog)
+	fallback      func([]types.RequestLog) error
+	seen          *seenSet
+
+	queue   chan types.RequestLog
+	flushes chan chan error
+	done    chan struct{}
+
+	mu      sync.RWMutex
+	closed  bool
+	stopped chan struct{}
+
+	dropped    atomic.Int64
+	duplicates atomic.Int64
+	diverted   atomic.Int64
+}
+
+// BatcherOption configures a Batcher.
+type BatcherOption func(*Batcher)
+
+// WithBatchSize sets how many logs are sent per BatchCreate call. Values above
+// the API maximum of 5000 are capped.
+func WithBatchSize(size int) BatcherOption {
+	return func(b *Batcher) {
+		if size > 0 {
+			b.batchSize = min(size, maxBatchSize)
+		}
+	}
+}
+
+// WithFlushInterval sets how often a partial batch is sent.
+func WithFlushInterval(interval time.Duration) BatcherOption {
+	return func(b *Batcher) {
+		if interval > 0 {
+			b.flushInterval = interval
+		}
+	}
+}
+
+// WithQueueSize sets how many logs can be waiting to be sent before Add
+// starts returning ErrQueueFull.
+func WithQueueSize(size int) BatcherOption {
+	return func(b *Batcher) {
+		if size > 0 {
+			b.queueSize = size
+		}
+	}
+}
+
+// WithBatchErrorHandler sets a callback for batches that failed to send.
+func WithBatchErrorHandler(fn func(error, []types.RequestLog)) BatcherOption {
+	return func(b *Batcher) {
+		b.onError = fn
+	}
+}
+
+// WithFallback hands batches to fn instead of failing them when the client's
+// circuit breaker is open (see client.WithCircuitBreaker), so flushing never
+// waits on a degraded API. fn can spool the logs for a later retry, or return
+// nil to drop them. If fn returns an error, the batch fails as usual.
+func WithFallback(fn func([]types.RequestLog) error) BatcherOption {
+	return func(b *Batcher) {
+		b.fallback = fn
+	}
+}
+
+// WithDeduplication drops logs whose IdempotencyKey was already added within
+// window, remembering at most capacity keys (100000 if capacity is not
+// positive). A window of 0 keeps keys until capacity forces them out. Only
+// logs with a caller-supplied IdempotencyKey or Timestamp are checked; equal
+// logs without either are separate usage. Logs with a Timestamp but no key
+// get a derived one, which is also sent. Keys of batches that fail to send
+// are forgotten, so logs can be added again.
+func WithDeduplication(capacity int, window time.Duration) BatcherOption {
+	return func(b *Batcher) {
+		if capacity <= 0 {
+			capacity = defaultDedupCapacity
+		}
+		b.seen = newSeenSet(capacity, window)
+	}
+}
+
+// NewBatcher creates a Batcher that submits logs through service and starts
+// its background worker. Call Close to flush and stop it.
+func NewBatcher(service *Service, opts ...BatcherOption) *Batcher {
+	b := &Batcher{
+		service:       service,
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+		queueSize:     defaultQueueSize,
+		flushes:       make(chan chan error),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	b.queue = make(chan types.RequestLog, b.queueSize)
+	go b.run()
+	return b
+}
+
+// Add queues a copy of log for delivery without blocking. Logs created under a
+// tracing span in ctx are annotated with its trace. With WithDeduplication,
+// duplicates are dropped without error.
+func (b *Batcher) Add(ctx context.Context, log *types.RequestLog) error {
+	if log == nil {
+		return ErrNilLog
+	}
+	entry := *log
+	dedup := b.seen != nil && (entry.IdempotencyKey != nil && *entry.IdempotencyKey != "" || entry.Timestamp != nil)
+	if dedup {
+		// Derive the key before Annotate adds a random span ID.
+		entry = *withIdempotencyKey(&entry)
+	}
+	tracing.Annotate(ctx, &entry)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrBatcherClosed
+	}
+	var key string
+	if dedup {
+		key = *entry.IdempotencyKey
+		if !b.seen.add(key) {
+			b.duplicates.Add(1)
+			return nil
+		}
+	}
+	select {
+	case b.queue <- entry:
+		return nil
+	default:
+		if key != "" {
+			b.seen.remove(key)
+		}
+		b.dropped.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// Flush sends everything queued so far and waits for it to be submitted.
+func (b *Batcher) Flush(ctx context.Context) error {
+	result := make(chan error, 1)
+	select {
+	case b.flushes <- result:
+	case <-b.stopped:
+		return ErrBatcherClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting logs, sends what is queued and stops the worker.
+func (b *Batcher) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dropped returns how many logs were rejected because the queue was full.
+func (b *Batcher) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// Duplicates returns how many logs were dropped by WithDeduplication.
+func (b *Batcher) Duplicates() int64 {
+	return b.duplicates.Load()
+}
+
+// Diverted returns how many logs were handed to the WithFallback function.
+func (b *Batcher) Diverted() int64 {
+	return b.diverted.Load()
+}
+
+func (b *Batcher) run() {
+	defer close(b.stopped)
+
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]types.RequestLog, 0, b.batchSize)
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := b.send(batch)
+		batch = make([]types.RequestLog, 0, b.batchSize)
+		return err
+	}
+
+	for {
+		select {
+		case log := <-b.queue:
+			batch = append(batch, log)
+			if len(batch) >= b.batchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case result := <-b.flushes:
+			var errs []error
+			for drained := false; !drained; {
+				select {
+				case log := <-b.queue:
+					batch = append(batch, log)
+					if len(batch) >= b.batchSize {
+						errs = append(errs, send())
+					}
+				default:
+					drained = true
+				}
+			}
+			errs = append(errs, send())
+			result <- errors.Join(errs...)
+		case <-b.done:
+			for {
+				select {
+				case log := <-b.queue:
+					batch = append(batch, log)
+					if len(batch) >= b.batchSize {
+						send()
+					}
+				default:
+					send()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (b *Batcher) send(batch []types.RequestLog) error {
+	err := b.service.BatchCreate(context.Background(), batch)
+	if err != nil && b.seen != nil {
+		for i := range batch {
+			if key := batch[i].IdempotencyKey; key != nil {
+				b.seen.remove(*key)
+			}
+		}
+	}
+	if errors.Is(err, client.ErrCircuitOpen) && b.fallback != nil {
+		ferr := b.fallback(batch)
+		if ferr == nil {
+			b.diverted.Add(int64(len(batch)))
+			return nil
+		}
+		err = errors.Join(err, ferr)
+	}
+	if err != nil && b.onError != nil {
+		b.onError(err, batch)
+	}
+	return err
+}
@@ -0,0 +1,198 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]types.RequestLog
+}
+
+func (r *batchRecorder) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var payload types.BatchRequestLogsPayload
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		r.mu.Lock()
+		r.batches = append(r.batches, payload.Logs)
+		r.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (r *batchRecorder) count() (batches, logs int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range r.batches {
+		logs += len(b)
+	}
+	return len(r.batches), logs
+}
+
+func TestBatcherSendsFullBatches(t *testing.T) {
+	rec := &batchRecorder{}
+	server := httptest.NewServer(rec.handler(t))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	b := NewBatcher(NewService(c), WithBatchSize(2), WithFlushInterval(time.Hour))
+
+	for i := 0; i < 5; i++ {
+		if err := b.Add(context.Background(), &types.RequestLog{Model: "gpt-4"}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	batches, logs := rec.count()
+	if batches != 3 || logs != 5 {
+		t.Errorf("got %d batches with %d logs, want 3 batches with 5 logs", batches, logs)
+	}
+
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := b.Add(context.Background(), &types.RequestLog{}); !errors.Is(err, ErrBatcherClosed) {
+		t.Errorf("expected ErrBatcherClosed, got %v", err)
+	}
+}
+
+func TestBatcherFlushesOnInterval(t *testing.T) {
+	rec := &batchRecorder{}
+	server := httptest.NewServer(rec.handler(t))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	b := NewBatcher(NewService(c), WithFlushInterval(10*time.Millisecond))
+	defer b.Close(context.Background())
+
+	b.Add(context.Background(), &types.RequestLog{Model: "gpt-4"})
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, logs := rec.count(); logs == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("log was not flushed on interval")
+}
+
+func TestBatcherCloseDrainsQueue(t *testing.T) {
+	rec := &batchRecorder{}
+	server := httptest.NewServer(rec.handler(t))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	b := NewBatcher(NewService(c), WithFlushInterval(time.Hour))
+	for i := 0; i < 10; i++ {
+		b.Add(context.Background(), &types.RequestLog{Model: "gpt-4"})
+	}
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, logs := rec.count(); logs != 10 {
+		t.Errorf("expected 10 logs delivered on close, got %d", logs)
+	}
+}
+
+func TestBatcherErrorHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var failed []types.RequestLog
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	b := NewBatcher(NewService(c), WithBatchErrorHandler(func(err error, batch []types.RequestLog) {
+		failed = append(failed, batch...)
+	}))
+	defer b.Close(context.Background())
+
+	b.Add(context.Background(), &types.RequestLog{Model: "gpt-4"})
+	if err := b.Flush(context.Background()); err == nil {
+		t.Fatal("expected Flush() to report the failed batch")
+	}
+	if len(failed) != 1 {
+		t.Errorf("expected error handler to receive 1 log, got %d", len(failed))
+	}
+}
+
+func TestBatcherQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(block)
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	b := NewBatcher(NewService(c), WithBatchSize(1), WithQueueSize(1), WithFlushInterval(time.Hour))
+
+	var full bool
+	for i := 0; i < 10; i++ {
+		if err := b.Add(context.Background(), &types.RequestLog{}); errors.Is(err, ErrQueueFull) {
+			full = true
+			break
+		}
+	}
+	if !full {
+		t.Fatal("expected ErrQueueFull")
+	}
+	if b.Dropped() == 0 {
+		t.Error("expected Dropped() to count rejected logs")
+	}
+}
+
+func TestBatcherRejectsNilLog(t *testing.T) {
+	b := NewBatcher(NewService(client.New("test-key")), WithFlushInterval(time.Hour))
+	defer b.Close(context.Background())
+	if err := b.Add(context.Background(), nil); !errors.Is(err, ErrNilLog) {
+		t.Errorf("expected ErrNilLog, got %v", err)
+	}
+}
+
+func TestBatcherFallbackOnOpenCircuit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL),
+		client.WithCircuitBreaker(client.CircuitBreaker{MinRequests: 1}))
+	var spooled []types.RequestLog
+	b := NewBatcher(NewService(c), WithFlushInterval(time.Hour), WithFallback(func(batch []types.RequestLog) error {
+		spooled = append(spooled, batch...)
+		return nil
+	}))
+	defer b.Close(context.Background())
+
+	b.Add(context.Background(), &types.RequestLog{Model: "gpt-4"})
+	if err := b.Flush(context.Background()); err == nil {
+		t.Fatal("expected the first batch to fail and open the circuit")
+	}
+
+	b.Add(context.Background(), &types.RequestLog{Model: "gpt-4"})
+	b.Add(context.Background(), &types.RequestLog{Model: "gpt-4"})
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("expected the open circuit to divert to the fallback, got %v", err)
+	}
+	if len(spooled) != 2 || b.Diverted() != 2 {
+		t.Errorf("expected 2 diverted logs, got %d and %d", len(spooled), b.Diverted())
+	}
+}
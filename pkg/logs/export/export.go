@@ -0,0 +1,364 @@
+// Package export pages through request logs and writes them to files as
+// JSONL, flattened CSV or Parquet. Files rotate by size or by time window,
+// and progress is recorded in a checkpoint so an interrupted export resumes
+// where the last completed file ended.
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/internal/paging"
+	"github.com/rizome-dev/go-keywordsai/pkg/logs"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// Format is the encoding of exported files.
+type Format string
+
+const (
+	FormatJSONL   Format = "jsonl"
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+)
+
+var (
+	ErrUnknownFormat      = errors.New("export: unknown format")
+	ErrCheckpointMismatch = errors.New("export: checkpoint was written for a different filter")
+)
+
+// Checkpoint records the progress of an export. Offset counts the logs of
+// the filtered listing that are in committed files; logs of a file that was
+// not finished are exported again on resume.
+type Checkpoint struct {
+	Filter        json.RawMessage `json:"filter"`
+	Offset        int             `json:"offset"`
+	LastTimestamp *time.Time      `json:"last_timestamp,omitempty"`
+	Files         []string        `json:"files"`
+	Seq           int             `json:"seq"`
+	Complete      bool            `json:"complete"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// LoadCheckpoint reads a checkpoint file. A missing file yields an empty
+// checkpoint. Nightly jobs can use LastTimestamp of a complete checkpoint as
+// the StartTime of the next export.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Checkpoint{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("export: failed to read checkpoint: %w", err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("export: failed to parse checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// Save writes the checkpoint atomically.
+func (cp *Checkpoint) Save(path string) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("export: failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("export: failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Result summarizes one Run.
+type Result struct {
+	Logs       int
+	Files      []string
+	Checkpoint *Checkpoint
+}
+
+type Exporter struct {
+	service        *logs.Service
+	dir            string
+	format         Format
+	prefix         string
+	maxFileSize    int64
+	rotateInterval time.Duration
+	pageSize       int
+	checkpointPath string
+}
+
+// Option configures an Exporter.
+type Option func(*Exporter)
+
+// WithFormat sets the file format. JSONL is the default.
+func WithFormat(format Format) Option {
+	return func(e *Exporter) {
+		e.format = format
+	}
+}
+
+// WithFilePrefix sets the prefix of file names, "logs" by default.
+func WithFilePrefix(prefix string) Option {
+	return func(e *Exporter) {
+		e.prefix = prefix
+	}
+}
+
+// WithMaxFileSize starts a new file once the current one reaches size bytes.
+// For Parquet the size is estimated from the buffered values.
+func WithMaxFileSize(size int64) Option {
+	return func(e *Exporter) {
+		e.maxFileSize = size
+	}
+}
+
+// WithRotateInterval starts a new file whenever a log's timestamp falls into
+// a different window of the given length, e.g. time.Hour for hourly files.
+// Windows are aligned to the Unix epoch in UTC.
+func WithRotateInterval(interval time.Duration) Option {
+	return func(e *Exporter) {
+		e.rotateInterval = interval
+	}
+}
+
+// WithPageSize sets how many logs are fetched per request.
+func WithPageSize(size int) Option {
+	return func(e *Exporter) {
+		e.pageSize = size
+	}
+}
+
+// WithCheckpoint persists progress to path after every completed file, and
+// resumes from it when a previous run did not complete.
+func WithCheckpoint(path string) Option {
+	return func(e *Exporter) {
+		e.checkpointPath = path
+	}
+}
+
+// New creates an Exporter writing files into dir.
+func New(service *logs.Service, dir string, opts ...Option) *Exporter {
+	e := &Exporter{
+		service:  service,
+		dir:      dir,
+		format:   FormatJSONL,
+		prefix:   "logs",
+		pageSize: paging.DefaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Run exports the logs matching filter. With a checkpoint from an incomplete
+// run for the same filter, the export continues after the last completed
+// file; a complete checkpoint starts a new export, continuing the file
+// sequence. On error, the files completed so far are kept and recorded.
+func (e *Exporter) Run(ctx context.Context, filter *types.LogFilter) (*Result, error) {
+	switch e.format {
+	case FormatJSONL, FormatCSV, FormatParquet:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, e.format)
+	}
+	if err := os.MkdirAll(e.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("export: failed to create directory: %w", err)
+	}
+
+	f := types.LogFilter{}
+	if filter != nil {
+		f = *filter
+	}
+	f.Offset, f.Limit = nil, nil
+	key, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+
+	cp := &Checkpoint{}
+	if e.checkpointPath != "" {
+		if cp, err = LoadCheckpoint(e.checkpointPath); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case cp.Filter == nil || cp.Complete:
+		*cp = Checkpoint{Filter: key, Seq: cp.Seq}
+	case string(cp.Filter) != string(key):
+		return nil, ErrCheckpointMismatch
+	}
+
+	run := &run{Exporter: e, cp: cp, result: &Result{Checkpoint: cp}}
+	err = run.export(ctx, f)
+	if err != nil {
+		run.abort()
+		return run.result, err
+	}
+	if err := run.commit(); err != nil {
+		return run.result, err
+	}
+	cp.Complete = true
+	return run.result, run.save()
+}
+
+// run holds the state of one Run.
+type run struct {
+	*Exporter
+	cp     *Checkpoint
+	result *Result
+
+	file     *os.File
+	buf      *bufio.Writer
+	w        fileWriter
+	name     string
+	window   time.Time
+	position int // offset in the listing of the next log to write
+	pending  int // logs in the open file
+	lastTS   *time.Time
+}
+
+func (r *run) export(ctx context.Context, f types.LogFilter) error {
+	limit := r.pageSize
+	f.Limit = &limit
+	offset := r.cp.Offset
+	r.position = offset
+	r.lastTS = r.cp.LastTimestamp
+
+	for {
+		f.Offset = &offset
+		page, err := r.service.List(ctx, &f)
+		if err != nil {
+			return err
+		}
+		r.position = offset
+		for i := range page.Logs {
+			if err := r.write(&page.Logs[i]); err != nil {
+				return err
+			}
+		}
+		next, ok := paging.Next(offset, len(page.Logs), page.NextOffset, page.TotalCount)
+		if !ok {
+			return nil
+		}
+		offset = next
+	}
+}
+
+func (r *run) write(log *types.RequestLog) error {
+	var window time.Time
+	if log.Timestamp != nil && r.rotateInterval > 0 {
+		window = log.Timestamp.UTC().Truncate(r.rotateInterval)
+	}
+	if r.w != nil {
+		rotate := r.maxFileSize > 0 && r.w.Size() >= r.maxFileSize
+		if !window.IsZero() && !window.Equal(r.window) {
+			rotate = true
+		}
+		if rotate {
+			if err := r.commit(); err != nil {
+				return err
+			}
+		}
+	}
+	if r.w == nil {
+		if err := r.open(log, window); err != nil {
+			return err
+		}
+	}
+	if err := r.w.Write(log); err != nil {
+		return fmt.Errorf("export: failed to write log: %w", err)
+	}
+	r.pending++
+	r.position++
+	if log.Timestamp != nil && (r.lastTS == nil || log.Timestamp.After(*r.lastTS)) {
+		ts := log.Timestamp.UTC()
+		r.lastTS = &ts
+	}
+	return nil
+}
+
+func (r *run) open(log *types.RequestLog, window time.Time) error {
+	r.cp.Seq++
+	stamp := window
+	if stamp.IsZero() && log.Timestamp != nil {
+		stamp = log.Timestamp.UTC()
+	}
+	if stamp.IsZero() {
+		r.name = fmt.Sprintf("%s-%06d.%s", r.prefix, r.cp.Seq, r.format)
+	} else {
+		r.name = fmt.Sprintf("%s-%s-%06d.%s", r.prefix, stamp.Format("20060102T150405Z"), r.cp.Seq, r.format)
+	}
+	file, err := os.Create(filepath.Join(r.dir, r.name+".tmp"))
+	if err != nil {
+		return fmt.Errorf("export: failed to create file: %w", err)
+	}
+	r.file = file
+	r.buf = bufio.NewWriter(file)
+	r.w = newFileWriter(r.format, r.buf)
+	r.window = window
+	return nil
+}
+
+// commit finishes the open file, moves it into place and records it in the
+// checkpoint.
+func (r *run) commit() error {
+	if r.w == nil {
+		return nil
+	}
+	tmp := r.file.Name()
+	err := r.w.Close()
+	if err == nil {
+		err = r.buf.Flush()
+	}
+	if err == nil {
+		err = r.file.Sync()
+	}
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.w, r.file, r.buf = nil, nil, nil
+	if err == nil {
+		err = os.Rename(tmp, filepath.Join(r.dir, r.name))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("export: failed to write %s: %w", r.name, err)
+	}
+
+	r.cp.Files = append(r.cp.Files, r.name)
+	r.cp.Offset = r.position
+	r.cp.LastTimestamp = r.lastTS
+	r.result.Files = append(r.result.Files, r.name)
+	r.result.Logs += r.pending
+	r.pending = 0
+	return r.save()
+}
+
+// abort discards the open file after a failure.
+func (r *run) abort() {
+	if r.file == nil {
+		return
+	}
+	r.file.Close()
+	os.Remove(r.file.Name())
+	r.w, r.file, r.buf = nil, nil, nil
+}
+
+func (r *run) save() error {
+	if r.checkpointPath == "" {
+		return nil
+	}
+	r.cp.UpdatedAt = time.Now().UTC()
+	return r.cp.Save(r.checkpointPath)
+}
@@ -0,0 +1,226 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/logs"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+var start = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// testLogs returns n logs, two per hour starting at start.
+func testLogs(n int) []types.RequestLog {
+	all := make([]types.RequestLog, n)
+	for i := range all {
+		ts := start.Add(time.Duration(i) * 30 * time.Minute)
+		all[i] = types.RequestLog{
+			Model:          "gpt-4o",
+			Timestamp:      &ts,
+			CustomerParams: &types.CustomerParams{CustomerIdentifier: "acme"},
+			PromptTokens:   utils.Int(10 * i),
+			Cost:           utils.Float64(0.5),
+			Tags:           []string{"prod"},
+			PromptMessages: []types.Message{{Role: "user", Content: "hi " + strconv.Itoa(i)}},
+		}
+	}
+	return all
+}
+
+// testServer serves all in pages. failAt makes the first request for that
+// offset fail.
+func testServer(t *testing.T, all []types.RequestLog, failAt int) (*logs.Service, *[]int) {
+	var offsets []int
+	var failed atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offsets = append(offsets, offset)
+		if offset == failAt && !failed.Swap(true) {
+			http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+			return
+		}
+		end := min(offset+limit, len(all))
+		json.NewEncoder(w).Encode(types.LogsResponse{Logs: all[offset:end], TotalCount: len(all)})
+	}))
+	t.Cleanup(server.Close)
+	return logs.NewService(client.New("test-key", client.WithBaseURL(server.URL))), &offsets
+}
+
+func readJSONL(t *testing.T, path string) []types.RequestLog {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var out []types.RequestLog
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var log types.RequestLog
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, log)
+	}
+	return out
+}
+
+func TestExportJSONLRotatesByInterval(t *testing.T) {
+	service, _ := testServer(t, testLogs(6), -1)
+	dir := t.TempDir()
+	cpPath := filepath.Join(dir, "checkpoint.json")
+
+	res, err := New(service, dir, WithRotateInterval(time.Hour), WithPageSize(4), WithCheckpoint(cpPath)).
+		Run(context.Background(), &types.LogFilter{Model: utils.String("gpt-4o")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"logs-20240301T000000Z-000001.jsonl",
+		"logs-20240301T010000Z-000002.jsonl",
+		"logs-20240301T020000Z-000003.jsonl",
+	}
+	if res.Logs != 6 || len(res.Files) != len(want) {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	for i, name := range want {
+		if res.Files[i] != name {
+			t.Errorf("file %d: expected %s, got %s", i, name, res.Files[i])
+		}
+		got := readJSONL(t, filepath.Join(dir, name))
+		if len(got) != 2 || !got[0].Timestamp.Equal(start.Add(time.Duration(i)*time.Hour)) {
+			t.Errorf("unexpected contents of %s: %+v", name, got)
+		}
+	}
+
+	cp, err := LoadCheckpoint(cpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cp.Complete || cp.Offset != 6 || len(cp.Files) != 3 || !cp.LastTimestamp.Equal(start.Add(150*time.Minute)) {
+		t.Errorf("unexpected checkpoint: %+v", cp)
+	}
+}
+
+func TestExportCSVRotatesBySize(t *testing.T) {
+	service, _ := testServer(t, testLogs(5), -1)
+	dir := t.TempDir()
+
+	res, err := New(service, dir, WithFormat(FormatCSV), WithMaxFileSize(1)).Run(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Files) != 5 {
+		t.Fatalf("expected one file per log, got %v", res.Files)
+	}
+
+	f, err := os.Open(filepath.Join(dir, res.Files[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(records[0]) != len(Schema) {
+		t.Fatalf("expected header and one row, got %v", records)
+	}
+	row := map[string]string{}
+	for i, name := range records[0] {
+		row[name] = records[1][i]
+	}
+	if row["timestamp"] != "2024-03-01T01:00:00Z" || row["prompt_tokens"] != "20" || row["customer_identifier"] != "acme" ||
+		row["tags"] != `["prod"]` || row["cost"] != "0.5" || row["failed"] != "" {
+		t.Errorf("unexpected row: %v", row)
+	}
+}
+
+func TestExportResumesFromCheckpoint(t *testing.T) {
+	all := testLogs(6)
+	service, offsets := testServer(t, all, 4)
+	dir := t.TempDir()
+	cpPath := filepath.Join(dir, "checkpoint.json")
+	exporter := New(service, dir, WithRotateInterval(time.Hour), WithPageSize(2), WithCheckpoint(cpPath))
+
+	res, err := exporter.Run(context.Background(), nil)
+	if err == nil {
+		t.Fatal("expected the failing page to abort the export")
+	}
+	if len(res.Files) != 1 {
+		t.Errorf("expected only the first hour to be committed, got %v", res.Files)
+	}
+	cp, _ := LoadCheckpoint(cpPath)
+	if cp.Complete || cp.Offset != 2 {
+		t.Errorf("unexpected checkpoint after failure: %+v", cp)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) != 0 {
+		t.Errorf("expected partial files to be removed, got %v", tmp)
+	}
+
+	*offsets = nil
+	res, err = exporter.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*offsets)[0] != 2 {
+		t.Errorf("expected resume at offset 2, got %v", *offsets)
+	}
+	if res.Logs != 4 || len(res.Checkpoint.Files) != 3 || !res.Checkpoint.Complete {
+		t.Errorf("unexpected result after resume: %+v %+v", res, res.Checkpoint)
+	}
+
+	var exported []types.RequestLog
+	for _, name := range res.Checkpoint.Files {
+		exported = append(exported, readJSONL(t, filepath.Join(dir, name))...)
+	}
+	if len(exported) != len(all) {
+		t.Fatalf("expected every log exactly once, got %d", len(exported))
+	}
+	for i := range exported {
+		if !exported[i].Timestamp.Equal(*all[i].Timestamp) {
+			t.Errorf("log %d out of order", i)
+		}
+	}
+
+	// A completed checkpoint starts over but keeps numbering files.
+	res, err = exporter.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Logs != 6 || res.Files[0] != "logs-20240301T000000Z-000004.jsonl" {
+		t.Errorf("unexpected result of new export: %+v", res)
+	}
+}
+
+func TestExportCheckpointMismatch(t *testing.T) {
+	service, _ := testServer(t, testLogs(2), -1)
+	dir := t.TempDir()
+	cpPath := filepath.Join(dir, "checkpoint.json")
+	(&Checkpoint{Filter: json.RawMessage(`{"model":"other"}`), Offset: 1}).Save(cpPath)
+
+	_, err := New(service, dir, WithCheckpoint(cpPath)).Run(context.Background(), nil)
+	if !errors.Is(err, ErrCheckpointMismatch) {
+		t.Errorf("expected ErrCheckpointMismatch, got %v", err)
+	}
+	_, err = New(service, dir, WithFormat("xml")).Run(context.Background(), nil)
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -0,0 +1,337 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// parquetWriter buffers logs column by column and writes a Parquet file with
+// a single row group on Close. Every column is optional, uncompressed and
+// PLAIN encoded, which all Parquet readers support.
+type parquetWriter struct {
+	w       io.Writer
+	columns []parquetColumn
+	rows    int
+	size    int64
+}
+
+type parquetColumn struct {
+	*Column
+	defined []bool
+	values  bytes.Buffer
+	bits    []bool // BOOLEAN values, bit-packed on write
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	pw := &parquetWriter{w: w, columns: make([]parquetColumn, len(Schema))}
+	for i := range Schema {
+		pw.columns[i].Column = &Schema[i]
+	}
+	return pw
+}
+
+func (pw *parquetWriter) Write(log *types.RequestLog) error {
+	for i := range pw.columns {
+		c := &pw.columns[i]
+		v, ok, err := c.value(log)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", c.Name, err)
+		}
+		c.defined = append(c.defined, ok)
+		if !ok {
+			continue
+		}
+		before := c.values.Len()
+		switch v := v.(type) {
+		case string:
+			binary.Write(&c.values, binary.LittleEndian, uint32(len(v)))
+			c.values.WriteString(v)
+		case int64:
+			binary.Write(&c.values, binary.LittleEndian, v)
+		case float64:
+			binary.Write(&c.values, binary.LittleEndian, math.Float64bits(v))
+		case bool:
+			c.bits = append(c.bits, v)
+		case time.Time:
+			binary.Write(&c.values, binary.LittleEndian, v.UnixMicro())
+		}
+		pw.size += int64(c.values.Len()-before) + 1
+	}
+	pw.rows++
+	return nil
+}
+
+// Size estimates the file size from the buffered values.
+func (pw *parquetWriter) Size() int64 {
+	return pw.size
+}
+
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMicros = 10
+	convertedJSON            = 19
+
+	repetitionOptional = 1
+	encodingPlain      = 0
+	encodingRLE        = 3
+	pageTypeData       = 0
+)
+
+func physicalType(k kind) int32 {
+	switch k {
+	case kindInt64, kindTimestamp:
+		return parquetInt64
+	case kindFloat64:
+		return parquetDouble
+	case kindBool:
+		return parquetBoolean
+	}
+	return parquetByteArray
+}
+
+func convertedType(k kind) (int32, bool) {
+	switch k {
+	case kindString:
+		return convertedUTF8, true
+	case kindJSON:
+		return convertedJSON, true
+	case kindTimestamp:
+		return convertedTimestampMicros, true
+	}
+	return 0, false
+}
+
+type chunkMeta struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+func (pw *parquetWriter) Close() error {
+	bw := bufio.NewWriter(pw.w)
+	bw.WriteString("PAR1")
+	offset := int64(4)
+
+	chunks := make([]chunkMeta, len(pw.columns))
+	var total int64
+	for i := range pw.columns {
+		page := pw.columns[i].page()
+		header := pageHeader(len(page), len(pw.columns[i].defined))
+		bw.Write(header)
+		bw.Write(page)
+
+		size := int64(len(header) + len(page))
+		chunks[i] = chunkMeta{offset: offset, size: size, values: int64(len(pw.columns[i].defined))}
+		offset += size
+		total += size
+	}
+
+	footer := pw.fileMetaData(chunks, total)
+	bw.Write(footer)
+	binary.Write(bw, binary.LittleEndian, uint32(len(footer)))
+	bw.WriteString("PAR1")
+	return bw.Flush()
+}
+
+// page returns the data page body: definition levels, then the non-null
+// values.
+func (c *parquetColumn) page() []byte {
+	levels := rleLevels(c.defined)
+	page := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+	page = append(page, levels...)
+	if c.kind == kindBool {
+		packed := make([]byte, (len(c.bits)+7)/8)
+		for i, b := range c.bits {
+			if b {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		return append(page, packed...)
+	}
+	return append(page, c.values.Bytes()...)
+}
+
+// rleLevels encodes definition levels with bit width 1 as RLE runs of the
+// RLE/bit-packing hybrid encoding.
+func rleLevels(defined []bool) []byte {
+	var out []byte
+	for i := 0; i < len(defined); {
+		j := i
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if defined[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+func pageHeader(size, values int) []byte {
+	var t thriftWriter
+	t.i32(1, pageTypeData)
+	t.i32(2, int32(size))
+	t.i32(3, int32(size))
+	t.beginStruct(5)
+	t.i32(1, int32(values))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.endStruct()
+	t.stop()
+	return t.buf
+}
+
+func (pw *parquetWriter) fileMetaData(chunks []chunkMeta, total int64) []byte {
+	var t thriftWriter
+	t.i32(1, 1)
+
+	t.beginList(2, thriftStruct, len(pw.columns)+1)
+	t.beginElement()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(pw.columns)))
+	t.endElement()
+	for _, c := range pw.columns {
+		t.beginElement()
+		t.i32(1, physicalType(c.kind))
+		t.i32(3, repetitionOptional)
+		t.binary(4, c.Name)
+		if ct, ok := convertedType(c.kind); ok {
+			t.i32(6, ct)
+		}
+		t.endElement()
+	}
+
+	t.i64(3, int64(pw.rows))
+
+	t.beginList(4, thriftStruct, 1)
+	t.beginElement()
+	t.beginList(1, thriftStruct, len(pw.columns))
+	for i, c := range pw.columns {
+		t.beginElement()
+		t.i64(2, chunks[i].offset)
+		t.beginStruct(3)
+		t.i32(1, physicalType(c.kind))
+		t.beginList(2, thriftI32, 2)
+		t.varint(encodingPlain)
+		t.varint(encodingRLE)
+		t.beginList(3, thriftBinary, 1)
+		t.rawBinary(c.Name)
+		t.i32(4, 0) // UNCOMPRESSED
+		t.i64(5, chunks[i].values)
+		t.i64(6, chunks[i].size)
+		t.i64(7, chunks[i].size)
+		t.i64(9, chunks[i].offset)
+		t.endStruct()
+		t.endElement()
+	}
+	t.i64(2, total)
+	t.i64(3, int64(pw.rows))
+	t.endElement()
+
+	t.binary(6, "go-keywordsai")
+	t.stop()
+	return t.buf
+}
+
+// Thrift compact protocol type IDs.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the subset of the Thrift compact protocol used by
+// Parquet metadata.
+type thriftWriter struct {
+	buf    []byte
+	last   int16
+	parent []int16
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(int64(id))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.buf = binary.AppendUvarint(t.buf, uint64((v<<1)^(v>>63)))
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.rawBinary(s)
+}
+
+func (t *thriftWriter) rawBinary(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+func (t *thriftWriter) beginList(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+	} else {
+		t.buf = append(t.buf, 0xf0|elem)
+		t.buf = binary.AppendUvarint(t.buf, uint64(n))
+	}
+}
+
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElement()
+}
+
+func (t *thriftWriter) endStruct() {
+	t.endElement()
+}
+
+// beginElement starts a struct inside a list, which has no field header.
+func (t *thriftWriter) beginElement() {
+	t.parent = append(t.parent, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) endElement() {
+	t.stop()
+	t.last = t.parent[len(t.parent)-1]
+	t.parent = t.parent[:len(t.parent)-1]
+}
+
+func (t *thriftWriter) stop() {
+	t.buf = append(t.buf, 0)
+}
@@ -0,0 +1,191 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+// thriftReader decodes Thrift compact structs into maps keyed by field ID,
+// lists into slices, integers into int64 and binaries into strings.
+type thriftReader struct {
+	t    *testing.T
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.t.Fatalf("bad varint at %d", r.pos)
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case thriftI32, thriftI64:
+		v := r.uvarint()
+		return int64(v>>1) ^ -int64(v&1)
+	case thriftBinary:
+		n := int(r.uvarint())
+		s := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		header := r.data[r.pos]
+		r.pos++
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	}
+	r.t.Fatalf("unsupported thrift type %d", typ)
+	return nil
+}
+
+func (r *thriftReader) structure() map[int16]interface{} {
+	fields := map[int16]interface{}{}
+	var last int16
+	for {
+		header := r.data[r.pos]
+		r.pos++
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			v := r.uvarint()
+			id = int16(int64(v>>1) ^ -int64(v&1))
+		}
+		fields[id] = r.value(header & 0x0f)
+		last = id
+	}
+}
+
+// readLevels decodes RLE runs of bit width 1.
+func readLevels(t *testing.T, data []byte, n int) []bool {
+	var out []bool
+	for pos := 0; len(out) < n; {
+		header, k := binary.Uvarint(data[pos:])
+		pos += k
+		if header&1 != 0 {
+			t.Fatal("unexpected bit-packed run")
+		}
+		for range header >> 1 {
+			out = append(out, data[pos] == 1)
+		}
+		pos++
+	}
+	return out
+}
+
+func TestParquetWriter(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC)
+	input := []types.RequestLog{
+		{Model: "gpt-4o", Timestamp: &ts, Cost: utils.Float64(0.25), Latency: utils.Int(120), Failed: utils.Bool(true), Tags: []string{"a"}},
+		{Model: "claude", Stream: utils.Bool(true)},
+		{Cost: utils.Float64(1.5), Failed: utils.Bool(false)},
+	}
+
+	var buf bytes.Buffer
+	pw := newParquetWriter(&buf)
+	for i := range input {
+		if err := pw.Write(&input[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pw.Size() == 0 {
+		t.Error("expected a size estimate")
+	}
+	if err := pw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatal("missing magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := &thriftReader{t: t, data: data[len(data)-8-footerLen : len(data)-8]}
+	meta := footer.structure()
+	if footer.pos != footerLen {
+		t.Errorf("footer has %d trailing bytes", footerLen-footer.pos)
+	}
+	if meta[3].(int64) != 3 {
+		t.Errorf("expected 3 rows, got %v", meta[3])
+	}
+
+	schema := meta[2].([]interface{})
+	if len(schema) != len(Schema)+1 || schema[0].(map[int16]interface{})[5].(int64) != int64(len(Schema)) {
+		t.Fatalf("unexpected schema root: %v", schema[0])
+	}
+	byName := map[string]int{}
+	for i, el := range schema[1:] {
+		el := el.(map[int16]interface{})
+		byName[el[4].(string)] = i
+		if el[3].(int64) != repetitionOptional {
+			t.Errorf("expected %s to be optional", el[4])
+		}
+	}
+	ts0 := schema[1+byName["timestamp"]].(map[int16]interface{})
+	if ts0[1].(int64) != parquetInt64 || ts0[6].(int64) != convertedTimestampMicros {
+		t.Errorf("unexpected timestamp element: %v", ts0)
+	}
+
+	chunks := meta[4].([]interface{})[0].(map[int16]interface{})[1].([]interface{})
+	column := func(name string) (levels []bool, values []byte) {
+		chunk := chunks[byName[name]].(map[int16]interface{})
+		cm := chunk[3].(map[int16]interface{})
+		if cm[3].([]interface{})[0].(string) != name {
+			t.Fatalf("unexpected path %v", cm[3])
+		}
+		offset := int(cm[9].(int64))
+		page := &thriftReader{t: t, data: data[offset : offset+int(cm[7].(int64))]}
+		header := page.structure()
+		body := page.data[page.pos:]
+		if int(header[3].(int64)) != len(body) {
+			t.Fatalf("%s: page size %v, body %d", name, header[3], len(body))
+		}
+		n := int(binary.LittleEndian.Uint32(body))
+		return readLevels(t, body[4:4+n], 3), body[4+n:]
+	}
+
+	levels, values := column("model")
+	if levels[0] != true || levels[1] != true || levels[2] != false {
+		t.Errorf("unexpected model levels: %v", levels)
+	}
+	if !bytes.Equal(values, []byte("\x06\x00\x00\x00gpt-4o\x06\x00\x00\x00claude")) {
+		t.Errorf("unexpected model values: %q", values)
+	}
+
+	levels, values = column("cost")
+	if levels[1] || len(values) != 16 || math.Float64frombits(binary.LittleEndian.Uint64(values[8:])) != 1.5 {
+		t.Errorf("unexpected cost column: %v %v", levels, values)
+	}
+
+	_, values = column("timestamp")
+	if int64(binary.LittleEndian.Uint64(values)) != ts.UnixMicro() {
+		t.Errorf("unexpected timestamp value")
+	}
+
+	levels, values = column("failed")
+	if !levels[0] || levels[1] || !levels[2] || !bytes.Equal(values, []byte{0x01}) {
+		t.Errorf("unexpected failed column: %v %v", levels, values)
+	}
+}
@@ -0,0 +1,130 @@
+package export
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// kind is the physical type of a column.
+type kind int
+
+const (
+	kindString kind = iota
+	kindJSON
+	kindInt64
+	kindFloat64
+	kindBool
+	kindTimestamp
+)
+
+// Column describes one field of the flattened schema shared by the CSV and
+// Parquet formats.
+type Column struct {
+	Name string
+	kind kind
+	get  func(log *types.RequestLog) (interface{}, bool)
+}
+
+// Schema is the flattened column layout of exported logs. Columns are only
+// ever appended, so files from older exports stay readable with newer
+// schemas. Nested fields are stored as JSON text. ExtraHeaders is left out
+// because headers often carry credentials.
+var Schema = []Column{
+	{"timestamp", kindTimestamp, func(l *types.RequestLog) (interface{}, bool) { return deref(l.Timestamp) }},
+	{"model", kindString, func(l *types.RequestLog) (interface{}, bool) { return l.Model, l.Model != "" }},
+	{"provider", kindString, func(l *types.RequestLog) (interface{}, bool) { return deref(l.Provider) }},
+	{"customer_identifier", kindString, func(l *types.RequestLog) (interface{}, bool) {
+		if l.CustomerParams == nil {
+			return nil, false
+		}
+		return l.CustomerParams.CustomerIdentifier, true
+	}},
+	{"category", kindString, func(l *types.RequestLog) (interface{}, bool) { return deref(l.Category) }},
+	{"prompt_tokens", kindInt64, func(l *types.RequestLog) (interface{}, bool) {
+		if l.PromptTokens != nil {
+			return int64(*l.PromptTokens), true
+		}
+		if l.Usage != nil {
+			return int64(l.Usage.PromptTokens), true
+		}
+		return nil, false
+	}},
+	{"completion_tokens", kindInt64, func(l *types.RequestLog) (interface{}, bool) {
+		if l.CompletionTokens != nil {
+			return int64(*l.CompletionTokens), true
+		}
+		if l.Usage != nil {
+			return int64(l.Usage.CompletionTokens), true
+		}
+		return nil, false
+	}},
+	{"cost", kindFloat64, func(l *types.RequestLog) (interface{}, bool) { return deref(l.Cost) }},
+	{"latency", kindInt64, func(l *types.RequestLog) (interface{}, bool) { return derefInt(l.Latency) }},
+	{"failed", kindBool, func(l *types.RequestLog) (interface{}, bool) { return deref(l.Failed) }},
+	{"status_code", kindInt64, func(l *types.RequestLog) (interface{}, bool) { return derefInt(l.StatusCode) }},
+	{"error", kindString, func(l *types.RequestLog) (interface{}, bool) { return deref(l.Error) }},
+	{"stream", kindBool, func(l *types.RequestLog) (interface{}, bool) { return deref(l.Stream) }},
+	{"tags", kindJSON, func(l *types.RequestLog) (interface{}, bool) { return l.Tags, len(l.Tags) > 0 }},
+	{"thread_identifier", kindString, func(l *types.RequestLog) (interface{}, bool) { return deref(l.ThreadIdentifier) }},
+	{"trace_unique_id", kindString, func(l *types.RequestLog) (interface{}, bool) { return deref(l.TraceUniqueID) }},
+	{"span_unique_id", kindString, func(l *types.RequestLog) (interface{}, bool) { return deref(l.SpanUniqueID) }},
+	{"span_parent_id", kindString, func(l *types.RequestLog) (interface{}, bool) { return deref(l.SpanParentID) }},
+	{"span_name", kindString, func(l *types.RequestLog) (interface{}, bool) { return deref(l.SpanName) }},
+	{"span_workflow_name", kindString, func(l *types.RequestLog) (interface{}, bool) { return deref(l.SpanWorkflowName) }},
+	{"prompt_messages", kindJSON, func(l *types.RequestLog) (interface{}, bool) { return l.PromptMessages, len(l.PromptMessages) > 0 }},
+	{"completion_message", kindJSON, func(l *types.RequestLog) (interface{}, bool) { return l.CompletionMessage, l.CompletionMessage != nil }},
+	{"tool_calls", kindJSON, func(l *types.RequestLog) (interface{}, bool) { return l.ToolCalls, len(l.ToolCalls) > 0 }},
+	{"metadata", kindJSON, func(l *types.RequestLog) (interface{}, bool) { return l.Metadata, len(l.Metadata) > 0 }},
+	{"request_params", kindJSON, func(l *types.RequestLog) (interface{}, bool) { return l.RequestParams, len(l.RequestParams) > 0 }},
+}
+
+// value returns the column's value for log converted to its storage type:
+// string (also for JSON columns), int64, float64, bool or time.Time.
+func (c *Column) value(log *types.RequestLog) (interface{}, bool, error) {
+	v, ok := c.get(log)
+	if !ok {
+		return nil, false, nil
+	}
+	if c.kind == kindJSON {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, false, err
+		}
+		return string(data), true, nil
+	}
+	return v, true, nil
+}
+
+// text formats a value for CSV. Timestamps use RFC 3339 with nanoseconds.
+func (c *Column) text(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	}
+	return ""
+}
+
+func deref[T any](p *T) (interface{}, bool) {
+	if p == nil {
+		return nil, false
+	}
+	return *p, true
+}
+
+func derefInt(p *int) (interface{}, bool) {
+	if p == nil {
+		return nil, false
+	}
+	return int64(*p), true
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// fileWriter encodes logs into one output file. Size reports the bytes
+// written (or buffered, for Parquet) so far and drives size-based rotation.
+type fileWriter interface {
+	Write(log *types.RequestLog) error
+	Size() int64
+	Close() error
+}
+
+func newFileWriter(format Format, w io.Writer) fileWriter {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatParquet:
+		return newParquetWriter(w)
+	}
+	return newJSONLWriter(w)
+}
+
+// countingWriter tracks the bytes passed to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type jsonlWriter struct {
+	counter *countingWriter
+	enc     *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	counter := &countingWriter{w: w}
+	return &jsonlWriter{counter: counter, enc: json.NewEncoder(counter)}
+}
+
+func (j *jsonlWriter) Write(log *types.RequestLog) error {
+	return j.enc.Encode(log)
+}
+
+func (j *jsonlWriter) Size() int64 {
+	return j.counter.n
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
+
+type csvWriter struct {
+	counter *countingWriter
+	cw      *csv.Writer
+	header  bool
+	record  []string
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	counter := &countingWriter{w: w}
+	return &csvWriter{counter: counter, cw: csv.NewWriter(counter), record: make([]string, len(Schema))}
+}
+
+func (c *csvWriter) Write(log *types.RequestLog) error {
+	if !c.header {
+		for i := range Schema {
+			c.record[i] = Schema[i].Name
+		}
+		if err := c.cw.Write(c.record); err != nil {
+			return err
+		}
+		c.header = true
+	}
+	for i := range Schema {
+		col := &Schema[i]
+		v, ok, err := col.value(log)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", col.Name, err)
+		}
+		c.record[i] = ""
+		if ok {
+			c.record[i] = col.text(v)
+		}
+	}
+	if err := c.cw.Write(c.record); err != nil {
+		return err
+	}
+	// csv.Writer buffers internally; flush per row so Size is exact. The
+	// destination is buffered by the exporter.
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+func (c *csvWriter) Size() int64 {
+	return c.counter.n
+}
+
+func (c *csvWriter) Close() error {
+	c.cw.Flush()
+	return c.cw.Error()
+}
@@ -0,0 +1,116 @@
+package logs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// IdempotencyKey returns the key that identifies repeated deliveries of log:
+// the caller-supplied IdempotencyKey if set, otherwise a SHA-256 hash of the
+// log's JSON encoding. Logs that differ in any field, including Timestamp,
+// get different keys; logs without a Timestamp that are otherwise equal get
+// the same one.
+func IdempotencyKey(log *types.RequestLog) string {
+	if log.IdempotencyKey != nil && *log.IdempotencyKey != "" {
+		return *log.IdempotencyKey
+	}
+	data, err := json.Marshal(log)
+	if err != nil {
+		// Only unsupported values in Metadata or RequestParams get here; such
+		// a log cannot be sent either, so any stable key will do.
+		data = []byte(err.Error())
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// withIdempotencyKey returns log with its IdempotencyKey set, copying it if
+// the key has to be derived. A log without a Timestamp is stamped with the
+// current time first, so separate calls with equal content are not mistaken
+// for repeated deliveries.
+func withIdempotencyKey(log *types.RequestLog) *types.RequestLog {
+	if log.IdempotencyKey != nil && *log.IdempotencyKey != "" {
+		return log
+	}
+	keyed := *log
+	if keyed.Timestamp == nil {
+		now := time.Now().UTC()
+		keyed.Timestamp = &now
+	}
+	key := IdempotencyKey(&keyed)
+	keyed.IdempotencyKey = &key
+	return &keyed
+}
+
+// seenSet remembers idempotency keys for a window, holding at most capacity
+// keys. When full, the oldest key is forgotten first.
+type seenSet struct {
+	capacity int
+	window   time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	added map[string]time.Time
+	order []seenEntry
+	head  int
+}
+
+type seenEntry struct {
+	key string
+	at  time.Time
+}
+
+func newSeenSet(capacity int, window time.Duration) *seenSet {
+	return &seenSet{
+		capacity: capacity,
+		window:   window,
+		now:      time.Now,
+		added:    make(map[string]time.Time),
+	}
+}
+
+// add records key and reports whether it was not seen within the window.
+func (s *seenSet) add(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.evict(now)
+	if _, ok := s.added[key]; ok {
+		return false
+	}
+	s.added[key] = now
+	s.order = append(s.order, seenEntry{key, now})
+	return true
+}
+
+// remove forgets key so a later delivery is not treated as a duplicate.
+func (s *seenSet) remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.added, key)
+}
+
+func (s *seenSet) evict(now time.Time) {
+	for s.head < len(s.order) {
+		e := s.order[s.head]
+		expired := s.window > 0 && now.Sub(e.at) >= s.window
+		if !expired && len(s.order)-s.head < s.capacity {
+			break
+		}
+		// Skip entries for keys that were removed or added again since.
+		if at, ok := s.added[e.key]; ok && at.Equal(e.at) {
+			delete(s.added, e.key)
+		}
+		s.head++
+	}
+	if s.head > len(s.order)/2 {
+		s.order = append(s.order[:0], s.order[s.head:]...)
+		s.head = 0
+	}
+}
@@ -0,0 +1,193 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/tracing"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	a := &types.RequestLog{Model: "gpt-4", Cost: utils.Float64(0.1)}
+	b := &types.RequestLog{Model: "gpt-4", Cost: utils.Float64(0.1)}
+	c := &types.RequestLog{Model: "gpt-4", Cost: utils.Float64(0.2)}
+
+	if IdempotencyKey(a) != IdempotencyKey(b) {
+		t.Error("expected identical logs to share a key")
+	}
+	if IdempotencyKey(a) == IdempotencyKey(c) {
+		t.Error("expected different logs to get different keys")
+	}
+	if len(IdempotencyKey(a)) != 64 {
+		t.Errorf("expected a hex SHA-256, got %q", IdempotencyKey(a))
+	}
+	c.IdempotencyKey = utils.String("order-42")
+	if IdempotencyKey(c) != "order-42" {
+		t.Error("expected the caller-supplied key to win")
+	}
+}
+
+func TestServiceWithIdempotencyKeys(t *testing.T) {
+	var got []types.RequestLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload types.BatchRequestLogsPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		got = payload.Logs
+	}))
+	defer server.Close()
+
+	service := NewService(client.New("test-key", client.WithBaseURL(server.URL)), WithIdempotencyKeys())
+	logs := []types.RequestLog{{Model: "gpt-4"}, {Model: "gpt-4", IdempotencyKey: utils.String("mine")}}
+	if err := service.BatchCreate(context.Background(), logs); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].IdempotencyKey == nil || got[0].Timestamp == nil || *got[1].IdempotencyKey != "mine" {
+		t.Fatalf("unexpected keys sent: %+v", got)
+	}
+	// The key is derived after the log is stamped with the current time.
+	sent := got[0]
+	sent.IdempotencyKey = nil
+	if *got[0].IdempotencyKey != IdempotencyKey(&sent) {
+		t.Errorf("expected the key of the stamped log, got %s", *got[0].IdempotencyKey)
+	}
+	if logs[0].IdempotencyKey != nil || logs[0].Timestamp != nil {
+		t.Error("expected the caller's log to be left unchanged")
+	}
+}
+
+func TestSeenSet(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newSeenSet(2, time.Minute)
+	s.now = func() time.Time { return now }
+
+	if !s.add("a") || s.add("a") {
+		t.Fatal("expected the second add to be a duplicate")
+	}
+	now = now.Add(time.Minute)
+	if !s.add("a") {
+		t.Error("expected the key to expire after the window")
+	}
+
+	s.add("b")
+	s.add("c") // evicts "a"
+	if !s.add("a") {
+		t.Error("expected the oldest key to be evicted at capacity")
+	}
+
+	s.remove("a")
+	if !s.add("a") {
+		t.Error("expected a removed key to be accepted again")
+	}
+}
+
+func TestBatcherDeduplication(t *testing.T) {
+	var fail atomic.Bool
+	rec := &batchRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rec.handler(t)(w, r)
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	b := NewBatcher(NewService(c), WithDeduplication(100, time.Hour), WithFlushInterval(time.Hour))
+	defer b.Close(context.Background())
+
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	log := &types.RequestLog{Model: "gpt-4", Cost: utils.Float64(0.5), Timestamp: &at}
+	for range 3 {
+		if err := b.Add(context.Background(), log); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, n := rec.count(); n != 1 || b.Duplicates() != 2 {
+		t.Errorf("expected 1 log and 2 duplicates, got %d and %d", n, b.Duplicates())
+	}
+	if key := rec.batches[0][0].IdempotencyKey; key == nil || *key != IdempotencyKey(log) {
+		t.Errorf("expected the derived key to be sent, got %v", key)
+	}
+
+	// Equal logs without a Timestamp or key are separate usage, and are sent
+	// without a key.
+	untimed := &types.RequestLog{Model: "gpt-4", Cost: utils.Float64(0.5)}
+	b.Add(context.Background(), untimed)
+	b.Add(context.Background(), untimed)
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, n := rec.count(); n != 3 || b.Duplicates() != 2 || rec.batches[1][0].IdempotencyKey != nil {
+		t.Errorf("expected logs without a timestamp to be kept, got %d logs and %d duplicates", n, b.Duplicates())
+	}
+
+	// A failed batch must not block the caller's retry.
+	retry := &types.RequestLog{Model: "gpt-4", Cost: utils.Float64(1), IdempotencyKey: utils.String("retry-1")}
+	fail.Store(true)
+	b.Add(context.Background(), retry)
+	if err := b.Flush(context.Background()); err == nil {
+		t.Fatal("expected the flush to fail")
+	}
+	fail.Store(false)
+	b.Add(context.Background(), retry)
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, n := rec.count(); n != 4 {
+		t.Errorf("expected the retried log to be delivered, got %d logs", n)
+	}
+}
+
+func TestIdempotencyKeysUnderSpan(t *testing.T) {
+	rec := &batchRecorder{}
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/request-logs/create/" {
+			var log types.RequestLog
+			json.NewDecoder(r.Body).Decode(&log)
+			sent = append(sent, *log.IdempotencyKey)
+			return
+		}
+		rec.handler(t)(w, r)
+	}))
+	defer server.Close()
+
+	ctx, span := tracing.StartSpan(context.Background(), "agent-run")
+	defer span.End()
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	log := &types.RequestLog{Model: "gpt-4", Timestamp: &at}
+
+	service := NewService(client.New("test-key", client.WithBaseURL(server.URL)), WithIdempotencyKeys())
+	b := NewBatcher(service, WithDeduplication(100, time.Hour), WithFlushInterval(time.Hour))
+	defer b.Close(context.Background())
+	b.Add(ctx, log)
+	b.Add(ctx, log)
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, n := rec.count(); n != 1 || b.Duplicates() != 1 {
+		t.Errorf("expected the second add to be dropped, got %d logs and %d duplicates", n, b.Duplicates())
+	}
+
+	// Each Create annotates a new span ID but sends the same key.
+	for range 2 {
+		if err := service.Create(ctx, log); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sent) != 2 || sent[0] != sent[1] || sent[0] != IdempotencyKey(log) {
+		t.Errorf("expected the same key on every Create, got %v", sent)
+	}
+}
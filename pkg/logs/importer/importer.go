@@ -0,0 +1,304 @@
+// Package importer backfills request logs from files: JSONL of
+// types.RequestLog, OpenAI chat completions request/response pairs and
+// OpenAI Batch API output. Records are validated and de-duplicated, then
+// submitted with BatchCreate; a cursor file makes interrupted imports
+// resumable.
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/logs"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+const (
+	defaultBatchSize     = 500
+	maxBatchSize         = 5000
+	defaultDedupCapacity = 100000
+)
+
+var (
+	ErrMissingModel    = errors.New("importer: model is required")
+	ErrMissingMessages = errors.New("importer: prompt or completion messages are required")
+	ErrMissingRole     = errors.New("importer: message role is required")
+	ErrNegativeValue   = errors.New("importer: tokens, cost and latency must not be negative")
+)
+
+// Validate reports whether log can be submitted.
+func Validate(log *types.RequestLog) error {
+	if log.Model == "" {
+		return ErrMissingModel
+	}
+	if len(log.PromptMessages) == 0 && log.CompletionMessage == nil {
+		return ErrMissingMessages
+	}
+	for _, m := range log.PromptMessages {
+		if m.Role == "" {
+			return ErrMissingRole
+		}
+	}
+	if negative(log.PromptTokens) || negative(log.CompletionTokens) || negative(log.Latency) ||
+		(log.Cost != nil && *log.Cost < 0) {
+		return ErrNegativeValue
+	}
+	return nil
+}
+
+func negative(p *int) bool {
+	return p != nil && *p < 0
+}
+
+// Progress counts the records of a source. It doubles as the resumable
+// cursor: Records is the number of records already handled.
+type Progress struct {
+	Records    int `json:"records"`
+	Imported   int `json:"imported"`
+	Duplicates int `json:"duplicates"`
+	Invalid    int `json:"invalid"`
+	Batches    int `json:"batches"`
+}
+
+type Importer struct {
+	service    *logs.Service
+	batchSize  int
+	cursorPath string
+	onProgress func(Progress)
+	onInvalid  func(err error)
+	dedup      int
+}
+
+// Option configures an Importer.
+type Option func(*Importer)
+
+// WithBatchSize sets how many logs are sent per BatchCreate call. Values above
+// the API maximum of 5000 are capped.
+func WithBatchSize(size int) Option {
+	return func(i *Importer) {
+		if size > 0 {
+			i.batchSize = min(size, maxBatchSize)
+		}
+	}
+}
+
+// WithCursor persists progress to path after every submitted batch. A later
+// Run with the same path skips the records already handled.
+func WithCursor(path string) Option {
+	return func(i *Importer) {
+		i.cursorPath = path
+	}
+}
+
+// WithProgress calls fn after every submitted batch.
+func WithProgress(fn func(Progress)) Option {
+	return func(i *Importer) {
+		i.onProgress = fn
+	}
+}
+
+// WithInvalidHandler calls fn for every record that is skipped because it
+// could not be parsed or failed validation. The error is a *RecordError.
+func WithInvalidHandler(fn func(err error)) Option {
+	return func(i *Importer) {
+		i.onInvalid = fn
+	}
+}
+
+// WithDeduplication sets how many keys are remembered to detect duplicate
+// records (100000 if capacity is not positive). Each key costs roughly 150
+// bytes; once full, the oldest key is forgotten, so duplicates further apart
+// than capacity records are both submitted.
+func WithDeduplication(capacity int) Option {
+	return func(i *Importer) {
+		if capacity <= 0 {
+			capacity = defaultDedupCapacity
+		}
+		i.dedup = capacity
+	}
+}
+
+// WithoutDeduplication submits every valid record, including duplicates.
+func WithoutDeduplication() Option {
+	return func(i *Importer) {
+		i.dedup = 0
+	}
+}
+
+func New(service *logs.Service, opts ...Option) *Importer {
+	i := &Importer{service: service, batchSize: defaultBatchSize, dedup: defaultDedupCapacity}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// Run reads src to the end and submits its logs. Records with the same
+// logs.IdempotencyKey as one of the last remembered records are skipped (see
+// WithDeduplication), counting records skipped on resume, so a source should
+// be imported with a single cursor.
+// On error the cursor stays at the last submitted batch.
+func (i *Importer) Run(ctx context.Context, src Source) (*Progress, error) {
+	progress := &Progress{}
+	if i.cursorPath != "" {
+		cursor, err := LoadCursor(i.cursorPath)
+		if err != nil {
+			return nil, err
+		}
+		progress = cursor
+	}
+
+	skip := progress.Records
+	seen := newKeySet(i.dedup)
+	batch := make([]types.RequestLog, 0, i.batchSize)
+	record := 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+		log, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var recErr *RecordError
+		if err != nil && !errors.As(err, &recErr) {
+			return progress, err
+		}
+		record++
+		resumed := record <= skip
+
+		if err == nil {
+			if verr := Validate(log); verr != nil {
+				err = &RecordError{Record: record, Err: verr}
+			}
+		}
+		if err != nil {
+			if !resumed {
+				progress.Invalid++
+				if i.onInvalid != nil {
+					i.onInvalid(err)
+				}
+			}
+			continue
+		}
+
+		if !seen.add(logs.IdempotencyKey(log)) {
+			if !resumed {
+				progress.Duplicates++
+			}
+			continue
+		}
+		if resumed {
+			continue
+		}
+
+		batch = append(batch, *log)
+		if len(batch) == i.batchSize {
+			if err := i.submit(ctx, batch, progress, record); err != nil {
+				return progress, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := i.submit(ctx, batch, progress, record); err != nil {
+		return progress, err
+	}
+	return progress, nil
+}
+
+// submit sends batch and advances the cursor to record. An empty batch only
+// saves the cursor, so trailing invalid or duplicate records are not read
+// again.
+func (i *Importer) submit(ctx context.Context, batch []types.RequestLog, progress *Progress, record int) error {
+	if record <= progress.Records {
+		return nil
+	}
+	if len(batch) > 0 {
+		if err := i.service.BatchCreate(ctx, batch); err != nil {
+			return fmt.Errorf("importer: failed to submit records up to %d: %w", record, err)
+		}
+		progress.Imported += len(batch)
+		progress.Batches++
+	}
+	progress.Records = record
+	if i.cursorPath != "" {
+		if err := saveCursor(i.cursorPath, progress); err != nil {
+			return err
+		}
+	}
+	if i.onProgress != nil {
+		i.onProgress(*progress)
+	}
+	return nil
+}
+
+// LoadCursor reads the progress saved by WithCursor. A missing file yields
+// zero progress.
+func LoadCursor(path string) (*Progress, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Progress{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("importer: failed to read cursor: %w", err)
+	}
+	var p Progress
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("importer: failed to parse cursor: %w", err)
+	}
+	return &p, nil
+}
+
+func saveCursor(path string, p *Progress) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("importer: failed to write cursor: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("importer: failed to write cursor: %w", err)
+	}
+	return nil
+}
+
+// keySet remembers up to capacity keys, forgetting the oldest first. A zero
+// capacity remembers nothing.
+type keySet struct {
+	capacity int
+	keys     map[string]struct{}
+	order    []string
+	head     int
+}
+
+func newKeySet(capacity int) *keySet {
+	return &keySet{capacity: capacity, keys: make(map[string]struct{})}
+}
+
+// add records key and reports whether it was not already remembered.
+func (s *keySet) add(key string) bool {
+	if s.capacity == 0 {
+		return true
+	}
+	if _, ok := s.keys[key]; ok {
+		return false
+	}
+	if len(s.keys) == s.capacity {
+		delete(s.keys, s.order[s.head])
+		s.head++
+		if s.head > len(s.order)/2 {
+			s.order = append(s.order[:0], s.order[s.head:]...)
+			s.head = 0
+		}
+	}
+	s.keys[key] = struct{}{}
+	s.order = append(s.order, key)
+	return true
+}
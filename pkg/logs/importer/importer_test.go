@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/logs"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+// testServer records submitted batches. The batch numbered failAt (1-based)
+// fails once.
+func testServer(t *testing.T, failAt int) (*logs.Service, *[][]types.RequestLog) {
+	var batches [][]types.RequestLog
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == failAt {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"bad batch"}`))
+			return
+		}
+		var payload types.BatchRequestLogsPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		batches = append(batches, payload.Logs)
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return logs.NewService(client.New("test-key", client.WithBaseURL(server.URL))), &batches
+}
+
+func jsonlInput(n int) string {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, `{"model":"gpt-4o","prompt_messages":[{"role":"user","content":"msg %d"}]}`+"\n", i)
+	}
+	return b.String()
+}
+
+func TestValidate(t *testing.T) {
+	msgs := []types.Message{{Role: "user", Content: "hi"}}
+	tests := []struct {
+		log  types.RequestLog
+		want error
+	}{
+		{types.RequestLog{Model: "gpt-4o", PromptMessages: msgs}, nil},
+		{types.RequestLog{Model: "gpt-4o", CompletionMessage: &msgs[0]}, nil},
+		{types.RequestLog{PromptMessages: msgs}, ErrMissingModel},
+		{types.RequestLog{Model: "gpt-4o"}, ErrMissingMessages},
+		{types.RequestLog{Model: "gpt-4o", PromptMessages: []types.Message{{Content: "x"}}}, ErrMissingRole},
+		{types.RequestLog{Model: "gpt-4o", PromptMessages: msgs, Cost: utils.Float64(-1)}, ErrNegativeValue},
+	}
+	for i, tt := range tests {
+		if err := Validate(&tt.log); err != tt.want {
+			t.Errorf("case %d: expected %v, got %v", i, tt.want, err)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	service, batches := testServer(t, 0)
+	input := jsonlInput(5) +
+		`{"model":"gpt-4o","prompt_messages":[{"role":"user","content":"msg 1"}]}` + "\n" + // duplicate
+		`{"prompt_messages":[{"role":"user","content":"no model"}]}` + "\n" +
+		"garbage\n"
+
+	var updates []Progress
+	var invalid []error
+	p, err := New(service,
+		WithBatchSize(2),
+		WithProgress(func(p Progress) { updates = append(updates, p) }),
+		WithInvalidHandler(func(err error) { invalid = append(invalid, err) }),
+	).Run(context.Background(), JSONL(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Progress{Records: 8, Imported: 5, Duplicates: 1, Invalid: 2, Batches: 3}
+	if *p != want {
+		t.Errorf("expected %+v, got %+v", want, *p)
+	}
+	if len(*batches) != 3 || len((*batches)[2]) != 1 {
+		t.Errorf("unexpected batches: %v", *batches)
+	}
+	if len(updates) != 3 || updates[len(updates)-1] != want {
+		t.Errorf("unexpected progress updates: %+v", updates)
+	}
+	var recErr *RecordError
+	if len(invalid) != 2 || !errors.As(invalid[0], &recErr) || recErr.Record != 7 || !errors.Is(invalid[0], ErrMissingModel) {
+		t.Errorf("unexpected invalid records: %v", invalid)
+	}
+}
+
+func TestImportDeduplicationCapacity(t *testing.T) {
+	// msg 0 comes back after two other records.
+	input := jsonlInput(3) + `{"model":"gpt-4o","prompt_messages":[{"role":"user","content":"msg 0"}]}` + "\n"
+	tests := []struct {
+		opt  Option
+		want int
+	}{
+		{WithDeduplication(3), 1},
+		{WithDeduplication(2), 0},
+		{WithoutDeduplication(), 0},
+	}
+	for i, tt := range tests {
+		service, _ := testServer(t, 0)
+		p, err := New(service, tt.opt).Run(context.Background(), JSONL(strings.NewReader(input)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Duplicates != tt.want || p.Imported != 4-tt.want {
+			t.Errorf("case %d: expected %d duplicates, got %+v", i, tt.want, *p)
+		}
+	}
+}
+
+func TestImportResumesFromCursor(t *testing.T) {
+	service, batches := testServer(t, 2)
+	cursor := filepath.Join(t.TempDir(), "cursor.json")
+	input := jsonlInput(5) + `{"model":"gpt-4o","prompt_messages":[{"role":"user","content":"msg 0"}]}` + "\n"
+	importer := New(service, WithBatchSize(2), WithCursor(cursor))
+
+	_, err := importer.Run(context.Background(), JSONL(strings.NewReader(input)))
+	if err == nil {
+		t.Fatal("expected the second batch to fail")
+	}
+	saved, err := LoadCursor(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Records != 2 || saved.Imported != 2 {
+		t.Errorf("expected the cursor after the first batch, got %+v", saved)
+	}
+
+	p, err := importer.Run(context.Background(), JSONL(strings.NewReader(input)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Records != 6 || p.Imported != 5 || p.Duplicates != 1 || p.Batches != 3 {
+		t.Errorf("unexpected progress after resume: %+v", p)
+	}
+
+	var contents []interface{}
+	for _, batch := range *batches {
+		for _, log := range batch {
+			contents = append(contents, log.PromptMessages[0].Content)
+		}
+	}
+	if fmt.Sprint(contents) != "[msg 0 msg 1 msg 2 msg 3 msg 4]" {
+		t.Errorf("expected every log once, got %v", contents)
+	}
+}
@@ -0,0 +1,278 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+// maxLineSize bounds a single record; chat transcripts can be large.
+const maxLineSize = 64 << 20
+
+// Source yields request logs one record at a time. Next returns io.EOF after
+// the last record, and a *RecordError for a record that cannot be mapped;
+// the importer skips those and keeps reading.
+type Source interface {
+	Next() (*types.RequestLog, error)
+}
+
+// RecordError reports a malformed or invalid record. Record is 1-based.
+type RecordError struct {
+	Record int
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("importer: record %d: %v", e.Record, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// lineSource decodes non-blank lines with parse.
+type lineSource struct {
+	scanner *bufio.Scanner
+	record  int
+	parse   func(line []byte) (*types.RequestLog, error)
+}
+
+func newLineSource(r io.Reader, parse func([]byte) (*types.RequestLog, error)) *lineSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+	return &lineSource{scanner: scanner, parse: parse}
+}
+
+func (s *lineSource) Next() (*types.RequestLog, error) {
+	for s.scanner.Scan() {
+		line := bytes.TrimSpace(s.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		s.record++
+		log, err := s.parse(line)
+		if err != nil {
+			return nil, &RecordError{Record: s.record, Err: err}
+		}
+		return log, nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("importer: failed to read source: %w", err)
+	}
+	return nil, io.EOF
+}
+
+// JSONL reads one types.RequestLog per line, e.g. the output of the
+// logs/export package.
+func JSONL(r io.Reader) Source {
+	return newLineSource(r, func(line []byte) (*types.RequestLog, error) {
+		var log types.RequestLog
+		if err := json.Unmarshal(line, &log); err != nil {
+			return nil, err
+		}
+		return &log, nil
+	})
+}
+
+// OpenAIPair is one line of the request/response pairs format: a chat
+// completions request body and the response it got. LatencyMS and Timestamp
+// are optional; without a timestamp the response's created time is used.
+type OpenAIPair struct {
+	Request   json.RawMessage `json:"request"`
+	Response  json.RawMessage `json:"response"`
+	LatencyMS *int            `json:"latency_ms,omitempty"`
+	Timestamp *time.Time      `json:"timestamp,omitempty"`
+}
+
+// OpenAIPairs reads OpenAIPair lines.
+func OpenAIPairs(r io.Reader) Source {
+	return newLineSource(r, func(line []byte) (*types.RequestLog, error) {
+		var pair OpenAIPair
+		if err := json.Unmarshal(line, &pair); err != nil {
+			return nil, err
+		}
+		if len(pair.Request) == 0 {
+			return nil, errors.New("missing request")
+		}
+		log, err := fromChatCompletion(pair.Request, pair.Response, 0)
+		if err != nil {
+			return nil, err
+		}
+		log.Latency = pair.LatencyMS
+		if pair.Timestamp != nil {
+			log.Timestamp = pair.Timestamp
+		}
+		return log, nil
+	})
+}
+
+type batchInputLine struct {
+	CustomID string          `json:"custom_id"`
+	Body     json.RawMessage `json:"body"`
+}
+
+type batchOutputLine struct {
+	ID       string `json:"id"`
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		RequestID  string          `json:"request_id"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *openAIError `json:"error"`
+}
+
+type openAIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// OpenAIBatch reads an OpenAI Batch API output file. Output lines only carry
+// responses, so the prompts are joined from the batch input file by
+// custom_id when input is non-nil; the input file is read into memory
+// first. Records without a matching input keep the model and completion
+// only.
+func OpenAIBatch(output, input io.Reader) (Source, error) {
+	requests := map[string]json.RawMessage{}
+	if input != nil {
+		in := newLineSource(input, func(line []byte) (*types.RequestLog, error) {
+			var l batchInputLine
+			if err := json.Unmarshal(line, &l); err != nil {
+				return nil, err
+			}
+			requests[l.CustomID] = l.Body
+			return nil, nil
+		})
+		for {
+			_, err := in.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("importer: failed to read batch input: %w", err)
+			}
+		}
+	}
+
+	return newLineSource(output, func(line []byte) (*types.RequestLog, error) {
+		var l batchOutputLine
+		if err := json.Unmarshal(line, &l); err != nil {
+			return nil, err
+		}
+		var body json.RawMessage
+		status := 0
+		if l.Response != nil {
+			body, status = l.Response.Body, l.Response.StatusCode
+		}
+		log, err := fromChatCompletion(requests[l.CustomID], body, status)
+		if err != nil {
+			return nil, err
+		}
+		if l.Error != nil {
+			log.Failed = utils.Bool(true)
+			log.Error = &l.Error.Message
+		}
+		if log.Metadata == nil {
+			log.Metadata = map[string]interface{}{}
+		}
+		log.Metadata["openai_batch_request_id"] = l.ID
+		log.Metadata["custom_id"] = l.CustomID
+		return log, nil
+	}), nil
+}
+
+type chatResponse struct {
+	ID      string `json:"id"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Role      string           `json:"role"`
+			Content   interface{}      `json:"content"`
+			ToolCalls []types.ToolCall `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage *types.Usage `json:"usage"`
+	Error *openAIError `json:"error"`
+}
+
+// fromChatCompletion maps a chat completions request and response body to a
+// RequestLog. Either may be empty. Request fields other than model,
+// messages, user and stream are kept as RequestParams.
+func fromChatCompletion(request, response json.RawMessage, statusCode int) (*types.RequestLog, error) {
+	log := &types.RequestLog{Provider: utils.String("openai")}
+
+	if len(request) > 0 && string(request) != "null" {
+		var req struct {
+			Model    string          `json:"model"`
+			Messages []types.Message `json:"messages"`
+			User     string          `json:"user"`
+			Stream   *bool           `json:"stream"`
+		}
+		if err := json.Unmarshal(request, &req); err != nil {
+			return nil, fmt.Errorf("invalid request: %w", err)
+		}
+		var params map[string]interface{}
+		if err := json.Unmarshal(request, &params); err != nil {
+			return nil, fmt.Errorf("invalid request: %w", err)
+		}
+		for _, k := range []string{"model", "messages", "user", "stream"} {
+			delete(params, k)
+		}
+		log.Model = req.Model
+		log.PromptMessages = req.Messages
+		log.Stream = req.Stream
+		if len(params) > 0 {
+			log.RequestParams = params
+		}
+		if req.User != "" {
+			log.CustomerParams = &types.CustomerParams{CustomerIdentifier: req.User}
+		}
+	}
+
+	if len(response) > 0 && string(response) != "null" {
+		var resp chatResponse
+		if err := json.Unmarshal(response, &resp); err != nil {
+			return nil, fmt.Errorf("invalid response: %w", err)
+		}
+		if resp.Model != "" {
+			log.Model = resp.Model
+		}
+		if len(resp.Choices) > 0 {
+			m := resp.Choices[0].Message
+			log.CompletionMessage = &types.Message{Role: m.Role, Content: m.Content}
+			log.ToolCalls = m.ToolCalls
+		}
+		if resp.Usage != nil {
+			log.Usage = resp.Usage
+			log.PromptTokens = utils.Int(resp.Usage.PromptTokens)
+			log.CompletionTokens = utils.Int(resp.Usage.CompletionTokens)
+		}
+		if resp.Created > 0 {
+			ts := time.Unix(resp.Created, 0).UTC()
+			log.Timestamp = &ts
+		}
+		if resp.ID != "" {
+			log.Metadata = map[string]interface{}{"openai_id": resp.ID}
+		}
+		if resp.Error != nil {
+			log.Failed = utils.Bool(true)
+			log.Error = &resp.Error.Message
+		}
+	}
+
+	if statusCode != 0 {
+		log.StatusCode = utils.Int(statusCode)
+		if statusCode >= 400 {
+			log.Failed = utils.Bool(true)
+		}
+	}
+	return log, nil
+}
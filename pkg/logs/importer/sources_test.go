@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+func readAll(t *testing.T, src Source) ([]*types.RequestLog, []error) {
+	t.Helper()
+	var out []*types.RequestLog
+	var errs []error
+	for {
+		log, err := src.Next()
+		if err == io.EOF {
+			return out, errs
+		}
+		var recErr *RecordError
+		if errors.As(err, &recErr) {
+			errs = append(errs, err)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, log)
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	input := `{"model":"gpt-4o","prompt_messages":[{"role":"user","content":"hi"}],"cost":0.1}
+
+not json
+{"model":"claude","prompt_messages":[]}
+`
+	got, errs := readAll(t, JSONL(strings.NewReader(input)))
+	if len(got) != 2 || got[0].Model != "gpt-4o" || *got[0].Cost != 0.1 || got[1].Model != "claude" {
+		t.Errorf("unexpected logs: %+v", got)
+	}
+	var recErr *RecordError
+	if len(errs) != 1 || !errors.As(errs[0], &recErr) || recErr.Record != 2 {
+		t.Errorf("expected record 2 to fail, got %v", errs)
+	}
+}
+
+func TestOpenAIPairs(t *testing.T) {
+	input := `{"request":{"model":"gpt-4o","messages":[{"role":"system","content":"be brief"},{"role":"user","content":"hi"}],"temperature":0.2,"user":"acme","stream":false},` +
+		`"response":{"id":"chatcmpl-1","created":1709294400,"model":"gpt-4o-2024-05-13","choices":[{"message":{"role":"assistant","content":"hello","tool_calls":[{"id":"call_1","type":"function","function":{"name":"f","arguments":"{}"}}]}}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}},` +
+		`"latency_ms":420}
+{"request":{"model":"gpt-4o","messages":[{"role":"user","content":"x"}]},"response":{"error":{"message":"rate limited","type":"requests"}}}
+{"response":{}}`
+
+	got, errs := readAll(t, OpenAIPairs(strings.NewReader(input)))
+	if len(got) != 2 || len(errs) != 1 {
+		t.Fatalf("expected 2 logs and 1 error, got %d and %v", len(got), errs)
+	}
+	log := got[0]
+	if log.Model != "gpt-4o-2024-05-13" || len(log.PromptMessages) != 2 || log.CompletionMessage.Content != "hello" {
+		t.Errorf("unexpected mapping: %+v", log)
+	}
+	if *log.PromptTokens != 12 || *log.CompletionTokens != 3 || *log.Latency != 420 || *log.Provider != "openai" {
+		t.Errorf("unexpected usage: %+v", log)
+	}
+	if !log.Timestamp.Equal(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected timestamp %v", log.Timestamp)
+	}
+	if log.CustomerParams.CustomerIdentifier != "acme" || log.RequestParams["temperature"] != 0.2 || len(log.RequestParams) != 1 {
+		t.Errorf("unexpected params: %+v %v", log.CustomerParams, log.RequestParams)
+	}
+	if len(log.ToolCalls) != 1 || log.ToolCalls[0].Function.Name != "f" || log.Metadata["openai_id"] != "chatcmpl-1" {
+		t.Errorf("unexpected tool calls or metadata: %+v %v", log.ToolCalls, log.Metadata)
+	}
+	if failed := got[1]; failed.Failed == nil || !*failed.Failed || *failed.Error != "rate limited" {
+		t.Errorf("expected a failed log, got %+v", failed)
+	}
+}
+
+func TestOpenAIBatch(t *testing.T) {
+	input := `{"custom_id":"req-1","method":"POST","url":"/v1/chat/completions","body":{"model":"gpt-4o-mini","messages":[{"role":"user","content":"one"}],"max_tokens":10}}
+{"custom_id":"req-2","method":"POST","url":"/v1/chat/completions","body":{"model":"gpt-4o-mini","messages":[{"role":"user","content":"two"}]}}`
+	output := `{"id":"batch_req_1","custom_id":"req-1","response":{"status_code":200,"request_id":"r1","body":{"id":"chatcmpl-1","created":1709294400,"model":"gpt-4o-mini","choices":[{"message":{"role":"assistant","content":"1"}}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}},"error":null}
+{"id":"batch_req_2","custom_id":"req-2","response":{"status_code":500,"request_id":"r2","body":{"error":{"message":"server error"}}},"error":null}
+{"id":"batch_req_3","custom_id":"req-3","response":null,"error":{"code":"batch_expired","message":"expired"}}`
+
+	src, err := OpenAIBatch(strings.NewReader(output), strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, errs := readAll(t, src)
+	if len(got) != 3 || len(errs) != 0 {
+		t.Fatalf("expected 3 logs, got %d and %v", len(got), errs)
+	}
+	if got[0].PromptMessages[0].Content != "one" || got[0].RequestParams["max_tokens"] != float64(10) ||
+		*got[0].StatusCode != 200 || got[0].Metadata["custom_id"] != "req-1" || got[0].Metadata["openai_id"] != "chatcmpl-1" {
+		t.Errorf("unexpected first log: %+v", got[0])
+	}
+	if !*got[1].Failed || *got[1].StatusCode != 500 || got[1].Model != "gpt-4o-mini" {
+		t.Errorf("unexpected second log: %+v", got[1])
+	}
+	if !*got[2].Failed || *got[2].Error != "expired" || got[2].Model != "" {
+		t.Errorf("unexpected third log: %+v", got[2])
+	}
+}
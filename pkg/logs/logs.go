@@ -3,20 +3,90 @@ package logs
 import (
 	"context"
 	"fmt"
+	"net/url"
 
 	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/tracing"
 	"github.com/rizome-dev/go-keywordsai/pkg/types"
 )
 
 type Service struct {
-	client *client.Client
+	client          *client.Client
+	redactor        *Redactor
+	sampler         *Sampler
+	idempotencyKeys bool
 }
 
-func NewService(client *client.Client) *Service {
-	return &Service{client: client}
+// Option configures a Service.
+type Option func(*Service)
+
+// WithRedactor runs r over every log before Create and BatchCreate send it.
+func WithRedactor(r *Redactor) Option {
+	return func(s *Service) {
+		s.redactor = r
+	}
+}
+
+// WithSampler drops logs that sampler does not keep before Create and
+// BatchCreate send them, and records the sample rate in each kept log's Metadata.
+func WithSampler(sampler *Sampler) Option {
+	return func(s *Service) {
+		s.sampler = sampler
+	}
+}
+
+// WithIdempotencyKeys sets IdempotencyKey on every log Create and BatchCreate
+// send without one, so repeated deliveries of the same log, e.g. after a
+// retry, can be recognized. Logs without a Timestamp are stamped with the
+// current time first. See IdempotencyKey for how keys are derived.
+func WithIdempotencyKeys() Option {
+	return func(s *Service) {
+		s.idempotencyKeys = true
+	}
+}
+
+func NewService(client *client.Client, opts ...Option) *Service {
+	s := &Service{client: client}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// prepare applies idempotency keys, annotates log with the trace in ctx and
+// applies sampling and redaction. It returns false if the log was sampled out
+// and should not be sent.
+func (s *Service) prepare(ctx context.Context, log *types.RequestLog) (*types.RequestLog, bool) {
+	// Keys are derived before Annotate adds a random span ID, so the same
+	// log gets the same key on every call.
+	if s.idempotencyKeys {
+		log = withIdempotencyKey(log)
+	}
+	if tracing.SpanFromContext(ctx) != nil {
+		annotated := *log
+		tracing.Annotate(ctx, &annotated)
+		log = &annotated
+	}
+	if s.sampler != nil {
+		keep, rate := s.sampler.Sample(log)
+		if !keep {
+			return nil, false
+		}
+		log = withSampleRate(log, rate)
+	}
+	if s.redactor != nil {
+		log, _ = s.redactor.Redact(log)
+	}
+	return log, true
 }
 
+// Create sends a single log. Logs created under a tracing span are annotated
+// with its trace; logs dropped by the Sampler are skipped without error.
 func (s *Service) Create(ctx context.Context, log *types.RequestLog) error {
+	log, ok := s.prepare(ctx, log)
+	if !ok {
+		return nil
+	}
 	return s.client.Post(ctx, "/api/request-logs/create/", log, nil)
 }
 
@@ -25,6 +95,19 @@ func (s *Service) BatchCreate(ctx context.Context, logs []types.RequestLog) erro
 		return fmt.Errorf("batch size exceeds maximum of 5000 logs")
 	}
 
+	if s.sampler != nil || s.redactor != nil || s.idempotencyKeys || tracing.SpanFromContext(ctx) != nil {
+		prepared := make([]types.RequestLog, 0, len(logs))
+		for i := range logs {
+			if log, ok := s.prepare(ctx, &logs[i]); ok {
+				prepared = append(prepared, *log)
+			}
+		}
+		if len(prepared) == 0 {
+			return nil
+		}
+		logs = prepared
+	}
+
 	payload := types.BatchRequestLogsPayload{
 		Logs: logs,
 	}
@@ -48,13 +131,16 @@ func (s *Service) Update(ctx context.Context, logID string, updates map[string]i
 	return s.client.Patch(ctx, path, updates, nil)
 }
 
+// ListThreads returns the threads for a customer, or all threads if
+// customerIdentifier is empty.
+//
+// Deprecated: use threads.Service, which supports filtering, pagination and
+// message listing.
 func (s *Service) ListThreads(ctx context.Context, customerIdentifier string) ([]types.Thread, error) {
 	var result []types.Thread
 	path := "/api/threads"
 	if customerIdentifier != "" {
-		// TODO: Add query parameter support
-		payload := map[string]string{"customer_identifier": customerIdentifier}
-		return result, s.client.Post(ctx, path, payload, &result)
+		path += "?customer_identifier=" + url.QueryEscape(customerIdentifier)
 	}
 	return result, s.client.Get(ctx, path, &result)
 }
\ No newline at end of file
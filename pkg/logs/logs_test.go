@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/tracing"
 	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
 )
 
 func TestCreate(t *testing.T) {
@@ -146,6 +148,37 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestListSendsFilter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("model") != "gpt-4" || q.Get("failed") != "true" || q.Get("limit") != "50" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		if q.Get("start_time") != start.Format(time.RFC3339) {
+			t.Errorf("Expected start_time %s, got %s", start.Format(time.RFC3339), q.Get("start_time"))
+		}
+		if tags := q["tags"]; len(tags) != 2 || tags[0] != "prod" || tags[1] != "chat" {
+			t.Errorf("Expected repeated tags, got %v", tags)
+		}
+		json.NewEncoder(w).Encode(types.LogsResponse{})
+	}))
+	defer server.Close()
+
+	s := NewService(client.New("test-key", client.WithBaseURL(server.URL)))
+	_, err := s.List(context.Background(), &types.LogFilter{
+		Model:     utils.String("gpt-4"),
+		Failed:    utils.Bool(true),
+		StartTime: &start,
+		Tags:      []string{"prod", "chat"},
+		Limit:     utils.Int(50),
+	})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+}
+
 func TestGet(t *testing.T) {
 	expectedLog := types.RequestLog{
 		Model: "gpt-4",
@@ -241,4 +274,54 @@ func TestListThreads(t *testing.T) {
 	if result[0].ID != "thread-1" {
 		t.Errorf("Expected thread ID thread-1, got %s", result[0].ID)
 	}
-}
\ No newline at end of file
+}
+func TestCreateAnnotatesTrace(t *testing.T) {
+	var log types.RequestLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&log)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	s := NewService(c)
+
+	ctx, span := tracing.StartSpan(context.Background(), "agent-run")
+	defer span.End()
+
+	err := s.Create(ctx, &types.RequestLog{Model: "gpt-4"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if log.TraceUniqueID == nil || *log.TraceUniqueID != span.TraceID() {
+		t.Errorf("Expected trace ID %s, got %v", span.TraceID(), log.TraceUniqueID)
+	}
+	if log.SpanParentID == nil || *log.SpanParentID != span.SpanID() {
+		t.Errorf("Expected parent span %s, got %v", span.SpanID(), log.SpanParentID)
+	}
+}
+
+func TestListThreadsByCustomer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET method, got %s", r.Method)
+		}
+		if r.URL.Query().Get("customer_identifier") != "customer 123" {
+			t.Errorf("Expected customer_identifier query parameter, got %s", r.URL.RawQuery)
+		}
+		json.NewEncoder(w).Encode([]types.Thread{{ID: "thread-1"}})
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	s := NewService(c)
+
+	result, err := s.ListThreads(context.Background(), "customer 123")
+	if err != nil {
+		t.Fatalf("ListThreads() error = %v", err)
+	}
+	if len(result) != 1 {
+		t.Errorf("Expected 1 thread, got %d", len(result))
+	}
+}
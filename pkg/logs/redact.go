@@ -0,0 +1,359 @@
+package logs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// Detector finds sensitive substrings in a string value.
+type Detector interface {
+	// Name identifies the detector in masks and redaction counts.
+	Name() string
+	// Find returns the [start, end) byte offsets of every match in s.
+	Find(s string) [][]int
+}
+
+type regexDetector struct {
+	name string
+	re   *regexp.Regexp
+}
+
+func (d regexDetector) Name() string          { return d.name }
+func (d regexDetector) Find(s string) [][]int { return d.re.FindAllStringIndex(s, -1) }
+
+// RegexDetector creates a detector that matches re.
+func RegexDetector(name string, re *regexp.Regexp) Detector {
+	return regexDetector{name: name, re: re}
+}
+
+type funcDetector struct {
+	name string
+	fn   func(string) [][]int
+}
+
+func (d funcDetector) Name() string          { return d.name }
+func (d funcDetector) Find(s string) [][]int { return d.fn(s) }
+
+// FuncDetector creates a detector backed by fn, which returns match offsets
+// in the same form as regexp.FindAllStringIndex.
+func FuncDetector(name string, fn func(string) [][]int) Detector {
+	return funcDetector{name: name, fn: fn}
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern  = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{3}\)|\b\d{3})[\s.-]?\d{3}[\s.-]?\d{4}\b`)
+	cardPattern   = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	apiKeyPattern = regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}` +
+		`|\b(?:AKIA|ASIA)[0-9A-Z]{16}\b` +
+		`|\bgh[pousr]_[A-Za-z0-9]{36,}\b` +
+		`|\bxox[abpr]-[A-Za-z0-9-]{10,}` +
+		`|(?i:\bbearer\s+)[A-Za-z0-9._~+/-]{16,}=*`)
+)
+
+// EmailDetector matches email addresses.
+func EmailDetector() Detector { return RegexDetector("EMAIL", emailPattern) }
+
+// PhoneDetector matches North American and E.164-style phone numbers.
+func PhoneDetector() Detector { return RegexDetector("PHONE", phonePattern) }
+
+// APIKeyDetector matches common secret formats: OpenAI-style sk- keys, AWS
+// access key IDs, GitHub and Slack tokens, and bearer credentials.
+func APIKeyDetector() Detector { return RegexDetector("API_KEY", apiKeyPattern) }
+
+// CardNumberDetector matches 13-19 digit payment card numbers that pass the
+// Luhn checksum, optionally separated by spaces or dashes.
+func CardNumberDetector() Detector {
+	return FuncDetector("CARD_NUMBER", func(s string) [][]int {
+		var matches [][]int
+		for _, loc := range cardPattern.FindAllStringIndex(s, -1) {
+			if luhnValid(s[loc[0]:loc[1]]) {
+				matches = append(matches, loc)
+			}
+		}
+		return matches
+	})
+}
+
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+// DefaultDetectors returns the built-in detectors in the order they are applied.
+// Card numbers run before phone numbers so long digit runs are not partially
+// masked as phones.
+func DefaultDetectors() []Detector {
+	return []Detector{
+		APIKeyDetector(),
+		EmailDetector(),
+		CardNumberDetector(),
+		PhoneDetector(),
+	}
+}
+
+// Replacement produces the text substituted for a match found by the named detector.
+type Replacement func(detector, match string) string
+
+// MaskReplacement replaces a match with a fixed mask such as [REDACTED:EMAIL].
+func MaskReplacement(detector, _ string) string {
+	return "[REDACTED:" + detector + "]"
+}
+
+// HashReplacement replaces a match with a deterministic keyed hash so the same
+// value can still be correlated across logs without being revealed.
+func HashReplacement(salt []byte) Replacement {
+	return func(detector, match string) string {
+		mac := hmac.New(sha256.New, salt)
+		mac.Write([]byte(match))
+		return "[" + detector + ":" + hex.EncodeToString(mac.Sum(nil))[:12] + "]"
+	}
+}
+
+// RedactionReport counts the matches replaced in a single log.
+type RedactionReport struct {
+	Counts map[string]int
+	Total  int
+}
+
+func (r *RedactionReport) add(detector string, n int) {
+	if r.Counts == nil {
+		r.Counts = make(map[string]int)
+	}
+	r.Counts[detector] += n
+	r.Total += n
+}
+
+// Redactor removes sensitive values from request logs before they leave the process.
+// It is safe for concurrent use.
+type Redactor struct {
+	detectors []Detector
+	replace   Replacement
+	onReport  func(RedactionReport)
+
+	// Set by options; detectors is built from them once all are applied.
+	custom     []Detector
+	noDefaults bool
+
+	mu     sync.Mutex
+	totals map[string]int
+}
+
+// RedactorOption configures a Redactor.
+type RedactorOption func(*Redactor)
+
+// WithDetectors appends custom detectors after the built-in ones.
+func WithDetectors(detectors ...Detector) RedactorOption {
+	return func(r *Redactor) {
+		r.custom = append(r.custom, detectors...)
+	}
+}
+
+// WithoutDefaultDetectors removes the built-in detectors so only those added
+// with WithDetectors are applied, regardless of the order of the options.
+func WithoutDefaultDetectors() RedactorOption {
+	return func(r *Redactor) {
+		r.noDefaults = true
+	}
+}
+
+// WithReplacement sets how matches are rewritten. The default is MaskReplacement.
+func WithReplacement(replace Replacement) RedactorOption {
+	return func(r *Redactor) {
+		r.replace = replace
+	}
+}
+
+// WithReportFunc registers a callback invoked after each log that had at least
+// one redaction.
+func WithReportFunc(fn func(RedactionReport)) RedactorOption {
+	return func(r *Redactor) {
+		r.onReport = fn
+	}
+}
+
+// NewRedactor creates a Redactor using the built-in detectors and masking,
+// adjusted by opts.
+func NewRedactor(opts ...RedactorOption) *Redactor {
+	r := &Redactor{
+		replace: MaskReplacement,
+		totals:  make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if !r.noDefaults {
+		r.detectors = DefaultDetectors()
+	}
+	r.detectors = append(r.detectors, r.custom...)
+	return r
+}
+
+// Redact returns a copy of log with sensitive values replaced in the prompt
+// messages, completion message, metadata, request params and extra headers.
+// The original log is left untouched.
+func (r *Redactor) Redact(log *types.RequestLog) (*types.RequestLog, RedactionReport) {
+	var report RedactionReport
+	if log == nil {
+		return nil, report
+	}
+
+	out := *log
+	if log.PromptMessages != nil {
+		out.PromptMessages = make([]types.Message, len(log.PromptMessages))
+		for i, msg := range log.PromptMessages {
+			out.PromptMessages[i] = r.redactMessage(msg, &report)
+		}
+	}
+	if log.CompletionMessage != nil {
+		msg := r.redactMessage(*log.CompletionMessage, &report)
+		out.CompletionMessage = &msg
+	}
+	if log.Metadata != nil {
+		out.Metadata = r.redactMap(log.Metadata, &report)
+	}
+	if log.RequestParams != nil {
+		out.RequestParams = r.redactMap(log.RequestParams, &report)
+	}
+	if log.ExtraHeaders != nil {
+		out.ExtraHeaders = make(map[string]string, len(log.ExtraHeaders))
+		for k, v := range log.ExtraHeaders {
+			out.ExtraHeaders[k] = r.redactString(v, &report)
+		}
+	}
+
+	if report.Total > 0 {
+		r.mu.Lock()
+		for name, n := range report.Counts {
+			r.totals[name] += n
+		}
+		r.mu.Unlock()
+		if r.onReport != nil {
+			r.onReport(report)
+		}
+	}
+	return &out, report
+}
+
+// Stats returns the cumulative number of redactions per detector.
+func (r *Redactor) Stats() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make(map[string]int, len(r.totals))
+	for name, n := range r.totals {
+		stats[name] = n
+	}
+	return stats
+}
+
+func (r *Redactor) redactMessage(msg types.Message, report *RedactionReport) types.Message {
+	msg.Content = r.redactValue(msg.Content, report)
+	return msg
+}
+
+func (r *Redactor) redactMap(m map[string]interface{}, report *RedactionReport) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = r.redactValue(v, report)
+	}
+	return out
+}
+
+// redactValue walks JSON-shaped values. Multi-part message content arrives
+// either as []interface{} of maps or as typed structs; the latter are
+// normalised through a JSON round trip so every text field is reached.
+func (r *Redactor) redactValue(v interface{}, report *RedactionReport) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.redactString(val, report)
+	case []string:
+		out := make([]string, len(val))
+		for i, s := range val {
+			out[i] = r.redactString(s, report)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.redactValue(item, report)
+		}
+		return out
+	case map[string]interface{}:
+		return r.redactMap(val, report)
+	case []map[string]interface{}:
+		out := make([]map[string]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.redactMap(item, report)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(val))
+		for k, s := range val {
+			out[k] = r.redactString(s, report)
+		}
+		return out
+	case bool, int, int32, int64, float32, float64, json.Number:
+		return val
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return val
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return val
+		}
+		return r.redactValue(generic, report)
+	}
+}
+
+func (r *Redactor) redactString(s string, report *RedactionReport) string {
+	for _, d := range r.detectors {
+		matches := d.Find(s)
+		if len(matches) == 0 {
+			continue
+		}
+		sort.Slice(matches, func(i, j int) bool { return matches[i][0] < matches[j][0] })
+
+		var b strings.Builder
+		last, n := 0, 0
+		for _, m := range matches {
+			if m[0] < last {
+				continue
+			}
+			b.WriteString(s[last:m[0]])
+			b.WriteString(r.replace(d.Name(), s[m[0]:m[1]]))
+			last = m[1]
+			n++
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+		report.add(d.Name(), n)
+	}
+	return s
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var csvHeader = []string{
+	"dimension", "key", "count", "failures", "failure_rate",
+	"prompt_tokens", "completion_tokens", "total_tokens", "cost",
+	"latency_p50", "latency_p95", "latency_p99",
+}
+
+// WriteText renders the total and one table per dimension, aligned for a
+// terminal or a plain-text email.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	writeTable(tw, "Total", []Row{r.Total()})
+	for _, dim := range r.dims {
+		fmt.Fprintln(tw)
+		writeTable(tw, "By "+string(dim), r.Rows(dim))
+	}
+	return tw.Flush()
+}
+
+func writeTable(w io.Writer, title string, rows []Row) {
+	fmt.Fprintln(w, title)
+	fmt.Fprintln(w, "KEY\tREQUESTS\tFAILURES\tFAIL %\tPROMPT TOK\tCOMPL TOK\tTOTAL TOK\tCOST\tP50\tP95\tP99\t")
+	for _, row := range rows {
+		key := row.Key
+		if key == "" {
+			key = "(none)"
+		}
+		if row.Dimension == "" {
+			key = "all"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.2f\t%d\t%d\t%d\t%.4f\t%.0f\t%.0f\t%.0f\t\n",
+			key, row.Count, row.Failures, row.FailureRate*100,
+			row.PromptTokens, row.CompletionTokens, row.TotalTokens, row.Cost,
+			row.LatencyP50, row.LatencyP95, row.LatencyP99)
+	}
+}
+
+// WriteJSON writes {"total": Row, "groups": {dimension: [Row, ...]}}.
+func (r *Report) WriteJSON(w io.Writer) error {
+	out := struct {
+		Total  Row                 `json:"total"`
+		Groups map[Dimension][]Row `json:"groups"`
+	}{Total: r.Total(), Groups: make(map[Dimension][]Row, len(r.dims))}
+	for _, dim := range r.dims {
+		out.Groups[dim] = r.Rows(dim)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// WriteCSV writes one row per dimension value, preceded by the total with
+// dimension "total".
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	total := r.Total()
+	total.Dimension = "total"
+	cw.Write(csvRecord(total))
+	for _, dim := range r.dims {
+		for _, row := range r.Rows(dim) {
+			cw.Write(csvRecord(row))
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("report: failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+func csvRecord(row Row) []string {
+	return []string{
+		string(row.Dimension),
+		row.Key,
+		strconv.FormatInt(row.Count, 10),
+		strconv.FormatInt(row.Failures, 10),
+		formatFloat(row.FailureRate),
+		strconv.FormatInt(row.PromptTokens, 10),
+		strconv.FormatInt(row.CompletionTokens, 10),
+		strconv.FormatInt(row.TotalTokens, 10),
+		formatFloat(row.Cost),
+		formatFloat(row.LatencyP50),
+		formatFloat(row.LatencyP95),
+		formatFloat(row.LatencyP99),
+	}
+}
+
+func formatFloat(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 6, 64)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
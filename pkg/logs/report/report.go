@@ -0,0 +1,340 @@
+// Package report aggregates request logs locally into rollups per model,
+// customer, tag and category, and renders them as text, JSON or CSV.
+package report
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/rizome-dev/go-keywordsai/internal/paging"
+	"github.com/rizome-dev/go-keywordsai/pkg/logs"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// Dimension is a log attribute rows are grouped by.
+type Dimension string
+
+const (
+	ByModel    Dimension = "model"
+	ByCustomer Dimension = "customer"
+	ByTag      Dimension = "tag"
+	ByCategory Dimension = "category"
+)
+
+// AllDimensions lists every dimension in output order.
+var AllDimensions = []Dimension{ByModel, ByCustomer, ByTag, ByCategory}
+
+// Row is the rollup of the logs sharing one dimension value. Latency
+// quantiles are in the unit of RequestLog.Latency.
+type Row struct {
+	Dimension        Dimension `json:"dimension,omitempty"`
+	Key              string    `json:"key"`
+	Count            int64     `json:"count"`
+	Failures         int64     `json:"failures"`
+	FailureRate      float64   `json:"failure_rate"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	Cost             float64   `json:"cost"`
+	LatencyP50       float64   `json:"latency_p50"`
+	LatencyP95       float64   `json:"latency_p95"`
+	LatencyP99       float64   `json:"latency_p99"`
+}
+
+type rollup struct {
+	count            int64
+	failures         int64
+	promptTokens     int64
+	completionTokens int64
+	totalTokens      int64
+	cost             float64
+	latency          *Sketch
+}
+
+// Report accumulates rollups. It is safe for concurrent use.
+type Report struct {
+	dims     []Dimension
+	accuracy float64
+
+	mu     sync.Mutex
+	total  *rollup
+	groups map[Dimension]map[string]*rollup
+}
+
+// Option configures a Report.
+type Option func(*Report)
+
+// WithDimensions limits the dimensions rows are grouped by. All dimensions
+// are used by default.
+func WithDimensions(dims ...Dimension) Option {
+	return func(r *Report) {
+		r.dims = dims
+	}
+}
+
+// WithAccuracy sets the relative accuracy of latency quantiles. Reports can
+// only be merged when their accuracy matches.
+func WithAccuracy(relativeAccuracy float64) Option {
+	return func(r *Report) {
+		r.accuracy = relativeAccuracy
+	}
+}
+
+func New(opts ...Option) *Report {
+	r := &Report{
+		dims:     AllDimensions,
+		accuracy: DefaultRelativeAccuracy,
+		groups:   make(map[Dimension]map[string]*rollup),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.total = r.newRollup()
+	for _, dim := range r.dims {
+		r.groups[dim] = make(map[string]*rollup)
+	}
+	return r
+}
+
+// Add folds one log into the report. A log with several tags counts towards
+// each of them; logs without a value for a dimension are grouped under "".
+func (r *Report) Add(log *types.RequestLog) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.total.add(log)
+	for _, dim := range r.dims {
+		for _, key := range keys(dim, log) {
+			g, ok := r.groups[dim][key]
+			if !ok {
+				g = r.newRollup()
+				r.groups[dim][key] = g
+			}
+			g.add(log)
+		}
+	}
+}
+
+// AddAll folds every log into the report.
+func (r *Report) AddAll(logs []types.RequestLog) {
+	for i := range logs {
+		r.Add(&logs[i])
+	}
+}
+
+// Merge folds other into r, e.g. to combine reports built concurrently or
+// across weeks. Only dimensions present in r are merged.
+func (r *Report) Merge(other *Report) error {
+	if other == r {
+		return nil
+	}
+	// Copy other first so the two reports are never locked together.
+	total, groups := other.snapshot()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.total.merge(total); err != nil {
+		return err
+	}
+	for dim, byKey := range groups {
+		mine, ok := r.groups[dim]
+		if !ok {
+			continue
+		}
+		for key, g := range byKey {
+			m, ok := mine[key]
+			if !ok {
+				m = r.newRollup()
+				mine[key] = m
+			}
+			if err := m.merge(g); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Report) snapshot() (*rollup, map[Dimension]map[string]*rollup) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := r.total.clone()
+	groups := make(map[Dimension]map[string]*rollup, len(r.groups))
+	for dim, byKey := range r.groups {
+		groups[dim] = make(map[string]*rollup, len(byKey))
+		for key, g := range byKey {
+			groups[dim][key] = g.clone()
+		}
+	}
+	return total, groups
+}
+
+// Total returns the rollup over every log.
+func (r *Report) Total() Row {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.total.row("", "")
+}
+
+// Rows returns the rollups for dim, highest cost first, then by count and key.
+func (r *Report) Rows(dim Dimension) []Row {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rows := make([]Row, 0, len(r.groups[dim]))
+	for key, g := range r.groups[dim] {
+		rows = append(rows, g.row(dim, key))
+	}
+	slices.SortFunc(rows, func(a, b Row) int {
+		if c := cmp.Compare(b.Cost, a.Cost); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return rows
+}
+
+// Dimensions returns the dimensions the report groups by.
+func (r *Report) Dimensions() []Dimension {
+	return slices.Clone(r.dims)
+}
+
+// FromService pages through the logs matching filter and aggregates them.
+func FromService(ctx context.Context, service *logs.Service, filter *types.LogFilter, opts ...Option) (*Report, error) {
+	r := New(opts...)
+	f := types.LogFilter{}
+	if filter != nil {
+		f = *filter
+	}
+	if f.Limit == nil {
+		limit := paging.DefaultPageSize
+		f.Limit = &limit
+	}
+	offset := 0
+	if f.Offset != nil {
+		offset = *f.Offset
+	}
+
+	for {
+		f.Offset = &offset
+		page, err := service.List(ctx, &f)
+		if err != nil {
+			return r, err
+		}
+		r.AddAll(page.Logs)
+		next, ok := paging.Next(offset, len(page.Logs), page.NextOffset, page.TotalCount)
+		if !ok {
+			return r, nil
+		}
+		offset = next
+	}
+}
+
+func (r *Report) newRollup() *rollup {
+	return &rollup{latency: NewSketch(r.accuracy)}
+}
+
+func (g *rollup) add(log *types.RequestLog) {
+	g.count++
+	if failed(log) {
+		g.failures++
+	}
+	prompt, completion, total := tokens(log)
+	g.promptTokens += prompt
+	g.completionTokens += completion
+	g.totalTokens += total
+	if log.Cost != nil {
+		g.cost += *log.Cost
+	}
+	if log.Latency != nil {
+		g.latency.Add(float64(*log.Latency))
+	}
+}
+
+func (g *rollup) clone() *rollup {
+	c := *g
+	c.latency = NewSketch(g.latency.accuracy)
+	c.latency.Merge(g.latency)
+	return &c
+}
+
+func (g *rollup) merge(other *rollup) error {
+	if err := g.latency.Merge(other.latency); err != nil {
+		return err
+	}
+	g.count += other.count
+	g.failures += other.failures
+	g.promptTokens += other.promptTokens
+	g.completionTokens += other.completionTokens
+	g.totalTokens += other.totalTokens
+	g.cost += other.cost
+	return nil
+}
+
+func (g *rollup) row(dim Dimension, key string) Row {
+	row := Row{
+		Dimension:        dim,
+		Key:              key,
+		Count:            g.count,
+		Failures:         g.failures,
+		PromptTokens:     g.promptTokens,
+		CompletionTokens: g.completionTokens,
+		TotalTokens:      g.totalTokens,
+		Cost:             g.cost,
+		LatencyP50:       g.latency.Quantile(0.50),
+		LatencyP95:       g.latency.Quantile(0.95),
+		LatencyP99:       g.latency.Quantile(0.99),
+	}
+	if g.count > 0 {
+		row.FailureRate = float64(g.failures) / float64(g.count)
+	}
+	return row
+}
+
+func keys(dim Dimension, log *types.RequestLog) []string {
+	switch dim {
+	case ByModel:
+		return []string{log.Model}
+	case ByCustomer:
+		if log.CustomerParams != nil {
+			return []string{log.CustomerParams.CustomerIdentifier}
+		}
+	case ByCategory:
+		if log.Category != nil {
+			return []string{*log.Category}
+		}
+	case ByTag:
+		if len(log.Tags) > 0 {
+			return slices.Compact(slices.Sorted(slices.Values(log.Tags)))
+		}
+	}
+	return []string{""}
+}
+
+func failed(log *types.RequestLog) bool {
+	return (log.Failed != nil && *log.Failed) ||
+		(log.StatusCode != nil && *log.StatusCode >= 400) ||
+		(log.Error != nil && *log.Error != "")
+}
+
+// tokens prefers the top-level counts and falls back to Usage.
+func tokens(log *types.RequestLog) (prompt, completion, total int64) {
+	if log.Usage != nil {
+		prompt = int64(log.Usage.PromptTokens)
+		completion = int64(log.Usage.CompletionTokens)
+		total = int64(log.Usage.TotalTokens)
+	}
+	if log.PromptTokens != nil {
+		prompt = int64(*log.PromptTokens)
+	}
+	if log.CompletionTokens != nil {
+		completion = int64(*log.CompletionTokens)
+	}
+	return prompt, completion, max(total, prompt+completion)
+}
@@ -0,0 +1,173 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/logs"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+func testLogs() []types.RequestLog {
+	return []types.RequestLog{
+		{
+			Model:            "gpt-4o",
+			CustomerParams:   &types.CustomerParams{CustomerIdentifier: "acme"},
+			PromptTokens:     utils.Int(100),
+			CompletionTokens: utils.Int(50),
+			Cost:             utils.Float64(0.5),
+			Latency:          utils.Int(200),
+			Tags:             []string{"prod", "chat", "prod"},
+			Category:         utils.String("chat"),
+		},
+		{
+			Model:          "gpt-4o",
+			CustomerParams: &types.CustomerParams{CustomerIdentifier: "globex"},
+			Usage:          &types.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+			Cost:           utils.Float64(0.25),
+			Latency:        utils.Int(400),
+			StatusCode:     utils.Int(500),
+			Tags:           []string{"prod"},
+		},
+		{
+			Model:   "gpt-4o-mini",
+			Cost:    utils.Float64(0.01),
+			Latency: utils.Int(100),
+			Failed:  utils.Bool(true),
+		},
+	}
+}
+
+func TestReportRollups(t *testing.T) {
+	r := New()
+	r.AddAll(testLogs())
+
+	total := r.Total()
+	if total.Count != 3 || total.Failures != 2 || total.PromptTokens != 110 || total.TotalTokens != 165 {
+		t.Errorf("unexpected total: %+v", total)
+	}
+	if total.Cost != 0.76 {
+		t.Errorf("expected cost 0.76, got %v", total.Cost)
+	}
+
+	models := r.Rows(ByModel)
+	if len(models) != 2 || models[0].Key != "gpt-4o" || models[0].Count != 2 || models[0].FailureRate != 0.5 {
+		t.Fatalf("unexpected model rows: %+v", models)
+	}
+	// Quantiles use the lower rank, so with two samples every quantile below
+	// 1 is the smaller one.
+	if models[0].LatencyP50 < 198 || models[0].LatencyP50 > 202 || models[0].LatencyP99 != models[0].LatencyP50 {
+		t.Errorf("unexpected latency quantiles: %+v", models[0])
+	}
+
+	tags := r.Rows(ByTag)
+	counts := map[string]int64{}
+	for _, row := range tags {
+		counts[row.Key] = row.Count
+	}
+	if counts["prod"] != 2 || counts["chat"] != 1 || counts[""] != 1 {
+		t.Errorf("unexpected tag counts: %v", counts)
+	}
+
+	customers := r.Rows(ByCustomer)
+	if len(customers) != 3 || customers[2].Key != "" {
+		t.Errorf("expected logs without a customer grouped under empty key: %+v", customers)
+	}
+}
+
+func TestReportMerge(t *testing.T) {
+	all := testLogs()
+	whole, a, b := New(), New(), New()
+	whole.AddAll(all)
+	a.AddAll(all[:1])
+	b.AddAll(all[1:])
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if a.Total() != whole.Total() {
+		t.Errorf("merged total %+v differs from %+v", a.Total(), whole.Total())
+	}
+	if len(a.Rows(ByModel)) != 2 || a.Rows(ByModel)[0] != whole.Rows(ByModel)[0] {
+		t.Errorf("merged model rows differ: %+v", a.Rows(ByModel))
+	}
+
+	if err := a.Merge(New(WithAccuracy(0.05), WithDimensions(ByModel))); err != nil {
+		t.Errorf("expected merging an empty report to succeed, got %v", err)
+	}
+}
+
+func TestFromService(t *testing.T) {
+	all := testLogs()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("model") != "gpt-4o" {
+			t.Errorf("expected filter to be sent, got %s", r.URL.RawQuery)
+		}
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		end := min(offset+2, len(all))
+		json.NewEncoder(w).Encode(types.LogsResponse{Logs: all[offset:end], TotalCount: len(all)})
+	}))
+	defer server.Close()
+
+	service := logs.NewService(client.New("test-key", client.WithBaseURL(server.URL)))
+	r, err := FromService(context.Background(), service, &types.LogFilter{Model: utils.String("gpt-4o"), Limit: utils.Int(2)}, WithDimensions(ByModel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Total().Count != 3 {
+		t.Errorf("expected 3 logs across pages, got %d", r.Total().Count)
+	}
+	if len(r.Rows(ByCustomer)) != 0 {
+		t.Error("expected only the requested dimensions")
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	r := New(WithDimensions(ByModel, ByCustomer))
+	r.AddAll(testLogs())
+
+	var text bytes.Buffer
+	if err := r.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Total", "By model", "gpt-4o-mini", "(none)", "0.7600"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var out struct {
+		Total  Row              `json:"total"`
+		Groups map[string][]Row `json:"groups"`
+	}
+	var js bytes.Buffer
+	if err := r.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(js.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Total.Count != 3 || len(out.Groups["model"]) != 2 || len(out.Groups["customer"]) != 3 {
+		t.Errorf("unexpected JSON output: %s", js.String())
+	}
+
+	var csvOut bytes.Buffer
+	if err := r.WriteCSV(&csvOut); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if len(lines) != 1+1+2+3 {
+		t.Fatalf("unexpected CSV rows:\n%s", csvOut.String())
+	}
+	if !strings.HasPrefix(lines[1], "total,,3,2,0.666667,110,55,165,0.76,") {
+		t.Errorf("unexpected total row %q", lines[1])
+	}
+}
@@ -0,0 +1,158 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// DefaultRelativeAccuracy bounds the relative error of Sketch quantiles.
+const DefaultRelativeAccuracy = 0.01
+
+var ErrIncompatibleSketch = errors.New("report: sketches have different accuracy")
+
+// Sketch estimates quantiles of non-negative values in constant memory per
+// order of magnitude. Values are counted in logarithmic buckets, so any
+// returned quantile is within the relative accuracy of the exact value
+// (DDSketch). Sketches with the same accuracy can be merged, which makes them
+// suitable for combining per-shard or per-week results.
+type Sketch struct {
+	accuracy float64
+	gamma    float64
+	logGamma float64
+	buckets  map[int]uint64
+	zeros    uint64
+	count    uint64
+	min, max float64
+}
+
+// NewSketch creates a Sketch with the given relative accuracy, e.g. 0.01 for
+// 1%. Values outside (0, 1) use DefaultRelativeAccuracy.
+func NewSketch(relativeAccuracy float64) *Sketch {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		relativeAccuracy = DefaultRelativeAccuracy
+	}
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &Sketch{
+		accuracy: relativeAccuracy,
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		buckets:  make(map[int]uint64),
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+	}
+}
+
+// Add records v. Negative and NaN values are ignored.
+func (s *Sketch) Add(v float64) {
+	if v < 0 || math.IsNaN(v) {
+		return
+	}
+	s.count++
+	s.min = min(s.min, v)
+	s.max = max(s.max, v)
+	if v == 0 {
+		s.zeros++
+		return
+	}
+	s.buckets[int(math.Ceil(math.Log(v)/s.logGamma))]++
+}
+
+// Merge adds other's values to s.
+func (s *Sketch) Merge(other *Sketch) error {
+	if other == nil || other.count == 0 {
+		return nil
+	}
+	if other.accuracy != s.accuracy {
+		return ErrIncompatibleSketch
+	}
+	for i, n := range other.buckets {
+		s.buckets[i] += n
+	}
+	s.zeros += other.zeros
+	s.count += other.count
+	s.min = min(s.min, other.min)
+	s.max = max(s.max, other.max)
+	return nil
+}
+
+// Count returns the number of values added.
+func (s *Sketch) Count() uint64 {
+	return s.count
+}
+
+// Quantile returns the estimated q-quantile for q in [0, 1], or 0 if the
+// sketch is empty.
+func (s *Sketch) Quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+	q = min(max(q, 0), 1)
+	switch q {
+	case 0:
+		return s.min
+	case 1:
+		return s.max
+	}
+
+	rank := uint64(q * float64(s.count-1))
+	if rank < s.zeros {
+		return 0
+	}
+	seen := s.zeros
+	keys := make([]int, 0, len(s.buckets))
+	for i := range s.buckets {
+		keys = append(keys, i)
+	}
+	slices.Sort(keys)
+	for _, i := range keys {
+		seen += s.buckets[i]
+		if seen > rank {
+			// The bucket midpoint in relative terms, clamped to the observed
+			// range so small samples are not overestimated.
+			v := 2 * math.Pow(s.gamma, float64(i)) / (s.gamma + 1)
+			return min(max(v, s.min), s.max)
+		}
+	}
+	return s.max
+}
+
+type sketchJSON struct {
+	Accuracy float64        `json:"relative_accuracy"`
+	Buckets  map[int]uint64 `json:"buckets"`
+	Zeros    uint64         `json:"zeros,omitempty"`
+	Min      float64        `json:"min"`
+	Max      float64        `json:"max"`
+}
+
+// MarshalJSON encodes the sketch so it can be stored and merged later.
+func (s *Sketch) MarshalJSON() ([]byte, error) {
+	out := sketchJSON{Accuracy: s.accuracy, Buckets: s.buckets, Zeros: s.zeros}
+	if s.count > 0 {
+		out.Min, out.Max = s.min, s.max
+	}
+	return json.Marshal(out)
+}
+
+func (s *Sketch) UnmarshalJSON(data []byte) error {
+	var in sketchJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Accuracy <= 0 || in.Accuracy >= 1 {
+		return fmt.Errorf("report: invalid sketch accuracy %v", in.Accuracy)
+	}
+	*s = *NewSketch(in.Accuracy)
+	s.zeros = in.Zeros
+	s.count = in.Zeros
+	for i, n := range in.Buckets {
+		s.buckets[i] = n
+		s.count += n
+	}
+	if s.count > 0 {
+		s.min, s.max = in.Min, in.Max
+	}
+	return nil
+}
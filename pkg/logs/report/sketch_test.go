@@ -0,0 +1,105 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestSketchAccuracy(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	values := make([]float64, 20000)
+	s := NewSketch(0.01)
+	for i := range values {
+		// Log-normal, like request latencies in milliseconds.
+		values[i] = math.Exp(6 + rng.NormFloat64())
+		s.Add(values[i])
+	}
+	slices.Sort(values)
+
+	for _, q := range []float64{0.5, 0.9, 0.95, 0.99} {
+		exact := values[int(q*float64(len(values)-1))]
+		got := s.Quantile(q)
+		if math.Abs(got-exact)/exact > 0.01 {
+			t.Errorf("p%v: got %v, exact %v", q*100, got, exact)
+		}
+	}
+	if s.Quantile(0) != values[0] || s.Quantile(1) != values[len(values)-1] {
+		t.Error("expected exact min and max")
+	}
+}
+
+func TestSketchMerge(t *testing.T) {
+	whole, a, b := NewSketch(0.01), NewSketch(0.01), NewSketch(0.01)
+	for i := 0; i <= 1000; i++ {
+		v := float64(i)
+		whole.Add(v)
+		if i%2 == 0 {
+			a.Add(v)
+		} else {
+			b.Add(v)
+		}
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if a.Count() != whole.Count() {
+		t.Fatalf("expected count %d, got %d", whole.Count(), a.Count())
+	}
+	for _, q := range []float64{0.1, 0.5, 0.99} {
+		if a.Quantile(q) != whole.Quantile(q) {
+			t.Errorf("q%v: merged %v, whole %v", q, a.Quantile(q), whole.Quantile(q))
+		}
+	}
+
+	if err := a.Merge(NewSketch(0.05)); err != nil {
+		t.Errorf("expected merging an empty sketch to succeed, got %v", err)
+	}
+	other := NewSketch(0.05)
+	other.Add(1)
+	if err := a.Merge(other); !errors.Is(err, ErrIncompatibleSketch) {
+		t.Errorf("expected ErrIncompatibleSketch, got %v", err)
+	}
+}
+
+func TestSketchJSON(t *testing.T) {
+	s := NewSketch(0.02)
+	for _, v := range []float64{0, 3, 50, 120, 4000} {
+		s.Add(v)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Sketch
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Count() != 5 || decoded.Quantile(0.5) != s.Quantile(0.5) || decoded.Quantile(1) != 4000 {
+		t.Errorf("round trip changed sketch: %s", data)
+	}
+	if err := decoded.Merge(s); err != nil {
+		t.Errorf("expected decoded sketch to merge, got %v", err)
+	}
+}
+
+func TestSketchEmptyAndZeros(t *testing.T) {
+	s := NewSketch(0)
+	if s.Quantile(0.5) != 0 {
+		t.Error("expected 0 for empty sketch")
+	}
+	s.Add(-1)
+	s.Add(math.NaN())
+	if s.Count() != 0 {
+		t.Error("expected invalid values to be ignored")
+	}
+	for _, v := range []float64{0, 0, 10, 10} {
+		s.Add(v)
+	}
+	if s.Quantile(0.25) != 0 || s.Quantile(0.75) < 9.8 || s.Quantile(0.75) > 10 {
+		t.Errorf("unexpected quantiles %v %v", s.Quantile(0.25), s.Quantile(0.75))
+	}
+}
@@ -0,0 +1,191 @@
+package logs
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand/v2"
+	"sync/atomic"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// SampleRateMetadataKey is the Metadata key under which the Sampler records the
+// rate a log was kept at, so dashboards can re-weight counts by 1/rate.
+const SampleRateMetadataKey = "sample_rate"
+
+// Sampler decides which logs are submitted. Outliers (failed, expensive or slow
+// requests) are always kept; everything else is kept at the configured rate,
+// which can be overridden per customer or per model. It is safe for concurrent use.
+type Sampler struct {
+	rate             float64
+	customerRates    map[string]float64
+	modelRates       map[string]float64
+	keepFailed       bool
+	costThreshold    *float64
+	latencyThreshold *int
+	deterministic    bool
+	random           func() float64
+
+	kept    atomic.Int64
+	dropped atomic.Int64
+}
+
+// SamplerOption configures a Sampler.
+type SamplerOption func(*Sampler)
+
+// WithCustomerRate overrides the sample rate for a customer identifier.
+func WithCustomerRate(customerIdentifier string, rate float64) SamplerOption {
+	return func(s *Sampler) {
+		s.customerRates[customerIdentifier] = rate
+	}
+}
+
+// WithModelRate overrides the sample rate for a model. Customer rates take
+// precedence over model rates.
+func WithModelRate(model string, rate float64) SamplerOption {
+	return func(s *Sampler) {
+		s.modelRates[model] = rate
+	}
+}
+
+// WithKeepFailed always keeps logs marked Failed or with a StatusCode of 400 or above.
+func WithKeepFailed() SamplerOption {
+	return func(s *Sampler) {
+		s.keepFailed = true
+	}
+}
+
+// WithCostThreshold always keeps logs whose Cost is at least threshold.
+func WithCostThreshold(threshold float64) SamplerOption {
+	return func(s *Sampler) {
+		s.costThreshold = &threshold
+	}
+}
+
+// WithLatencyThreshold always keeps logs whose Latency is at least threshold,
+// expressed in the same unit as RequestLog.Latency.
+func WithLatencyThreshold(threshold int) SamplerOption {
+	return func(s *Sampler) {
+		s.latencyThreshold = &threshold
+	}
+}
+
+// WithDeterministicSampling samples by hashing the customer identifier instead
+// of drawing a random number, so a given customer is either always or never
+// kept at a given rate. Logs without a customer fall back to random sampling.
+func WithDeterministicSampling() SamplerOption {
+	return func(s *Sampler) {
+		s.deterministic = true
+	}
+}
+
+// NewSampler creates a Sampler that keeps logs at rate, a value between 0 and 1.
+// Rates outside that range, including per-customer and per-model rates, are
+// clamped to it.
+func NewSampler(rate float64, opts ...SamplerOption) *Sampler {
+	s := &Sampler{
+		rate:          clampRate(rate),
+		customerRates: make(map[string]float64),
+		modelRates:    make(map[string]float64),
+		random:        rand.Float64,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for k, r := range s.customerRates {
+		s.customerRates[k] = clampRate(r)
+	}
+	for k, r := range s.modelRates {
+		s.modelRates[k] = clampRate(r)
+	}
+	return s
+}
+
+func clampRate(rate float64) float64 {
+	return min(max(rate, 0), 1)
+}
+
+// Sample reports whether log should be submitted and the rate it was sampled at.
+func (s *Sampler) Sample(log *types.RequestLog) (bool, float64) {
+	if s.isOutlier(log) {
+		s.kept.Add(1)
+		return true, 1
+	}
+
+	rate := s.rateFor(log)
+	var keep bool
+	switch {
+	case rate >= 1:
+		keep = true
+	case rate <= 0:
+		keep = false
+	case s.deterministic && customerIdentifier(log) != "":
+		keep = hashFraction(customerIdentifier(log)) < rate
+	default:
+		keep = s.random() < rate
+	}
+
+	if keep {
+		s.kept.Add(1)
+	} else {
+		s.dropped.Add(1)
+	}
+	return keep, rate
+}
+
+// Stats returns how many logs have been kept and dropped.
+func (s *Sampler) Stats() (kept, dropped int64) {
+	return s.kept.Load(), s.dropped.Load()
+}
+
+func (s *Sampler) isOutlier(log *types.RequestLog) bool {
+	if s.keepFailed {
+		if log.Failed != nil && *log.Failed {
+			return true
+		}
+		if log.StatusCode != nil && *log.StatusCode >= 400 {
+			return true
+		}
+	}
+	if s.costThreshold != nil && log.Cost != nil && *log.Cost >= *s.costThreshold {
+		return true
+	}
+	if s.latencyThreshold != nil && log.Latency != nil && *log.Latency >= *s.latencyThreshold {
+		return true
+	}
+	return false
+}
+
+func (s *Sampler) rateFor(log *types.RequestLog) float64 {
+	if rate, ok := s.customerRates[customerIdentifier(log)]; ok {
+		return rate
+	}
+	if rate, ok := s.modelRates[log.Model]; ok {
+		return rate
+	}
+	return s.rate
+}
+
+func customerIdentifier(log *types.RequestLog) string {
+	if log.CustomerParams == nil {
+		return ""
+	}
+	return log.CustomerParams.CustomerIdentifier
+}
+
+// hashFraction maps key onto [0, 1).
+func hashFraction(key string) float64 {
+	sum := sha256.Sum256([]byte(key))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+// withSampleRate returns a copy of log with rate recorded in its Metadata.
+func withSampleRate(log *types.RequestLog, rate float64) *types.RequestLog {
+	out := *log
+	out.Metadata = make(map[string]interface{}, len(log.Metadata)+1)
+	for k, v := range log.Metadata {
+		out.Metadata[k] = v
+	}
+	out.Metadata[SampleRateMetadataKey] = rate
+	return &out
+}
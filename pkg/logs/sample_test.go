@@ -0,0 +1,195 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+func TestSamplerRates(t *testing.T) {
+	tests := []struct {
+		name     string
+		sampler  *Sampler
+		log      types.RequestLog
+		random   float64
+		wantKeep bool
+		wantRate float64
+	}{
+		{
+			name:     "default rate keeps below threshold",
+			sampler:  NewSampler(0.5),
+			log:      types.RequestLog{Model: "gpt-4"},
+			random:   0.3,
+			wantKeep: true,
+			wantRate: 0.5,
+		},
+		{
+			name:     "default rate drops above threshold",
+			sampler:  NewSampler(0.5),
+			log:      types.RequestLog{Model: "gpt-4"},
+			random:   0.7,
+			wantKeep: false,
+			wantRate: 0.5,
+		},
+		{
+			name:     "model rate overrides default",
+			sampler:  NewSampler(0, WithModelRate("gpt-4", 1)),
+			log:      types.RequestLog{Model: "gpt-4"},
+			random:   0.99,
+			wantKeep: true,
+			wantRate: 1,
+		},
+		{
+			name:    "customer rate overrides model rate",
+			sampler: NewSampler(1, WithModelRate("gpt-4", 1), WithCustomerRate("noisy", 0.1)),
+			log: types.RequestLog{
+				Model:          "gpt-4",
+				CustomerParams: &types.CustomerParams{CustomerIdentifier: "noisy"},
+			},
+			random:   0.5,
+			wantKeep: false,
+			wantRate: 0.1,
+		},
+		{
+			name:     "rates above 1 are clamped",
+			sampler:  NewSampler(2, WithModelRate("gpt-4", 3)),
+			log:      types.RequestLog{Model: "gpt-4"},
+			random:   0.99,
+			wantKeep: true,
+			wantRate: 1,
+		},
+		{
+			name:     "negative rates are clamped",
+			sampler:  NewSampler(-1),
+			log:      types.RequestLog{Model: "gpt-4"},
+			random:   0,
+			wantKeep: false,
+			wantRate: 0,
+		},
+		{
+			name:     "failed logs are always kept",
+			sampler:  NewSampler(0, WithKeepFailed()),
+			log:      types.RequestLog{Failed: utils.Bool(true)},
+			random:   0.99,
+			wantKeep: true,
+			wantRate: 1,
+		},
+		{
+			name:     "error status codes are always kept",
+			sampler:  NewSampler(0, WithKeepFailed()),
+			log:      types.RequestLog{StatusCode: utils.Int(500)},
+			random:   0.99,
+			wantKeep: true,
+			wantRate: 1,
+		},
+		{
+			name:     "expensive logs are always kept",
+			sampler:  NewSampler(0, WithCostThreshold(0.5)),
+			log:      types.RequestLog{Cost: utils.Float64(0.75)},
+			random:   0.99,
+			wantKeep: true,
+			wantRate: 1,
+		},
+		{
+			name:     "slow logs are always kept",
+			sampler:  NewSampler(0, WithLatencyThreshold(2000)),
+			log:      types.RequestLog{Latency: utils.Int(2500)},
+			random:   0.99,
+			wantKeep: true,
+			wantRate: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.sampler.random = func() float64 { return tt.random }
+			keep, rate := tt.sampler.Sample(&tt.log)
+			if keep != tt.wantKeep {
+				t.Errorf("keep = %v, want %v", keep, tt.wantKeep)
+			}
+			if rate != tt.wantRate {
+				t.Errorf("rate = %v, want %v", rate, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestSamplerDeterministic(t *testing.T) {
+	s := NewSampler(0.5, WithDeterministicSampling())
+	s.random = func() float64 {
+		t.Fatal("random should not be used for logs with a customer identifier")
+		return 0
+	}
+
+	kept := 0
+	for i := 0; i < 200; i++ {
+		customer := string(rune('a'+i%26)) + string(rune('a'+i/26))
+		log := &types.RequestLog{CustomerParams: &types.CustomerParams{CustomerIdentifier: customer}}
+		first, _ := s.Sample(log)
+		second, _ := s.Sample(log)
+		if first != second {
+			t.Fatalf("customer %s sampled inconsistently", customer)
+		}
+		if first {
+			kept++
+		}
+	}
+	if kept < 60 || kept > 140 {
+		t.Errorf("kept %d of 200 customers at rate 0.5", kept)
+	}
+}
+
+func TestServiceWithSampler(t *testing.T) {
+	var payload types.BatchRequestLogsPayload
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sampler := NewSampler(0, WithModelRate("gpt-4", 1))
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	s := NewService(c, WithSampler(sampler))
+
+	// Sampled-out single logs are not sent.
+	if err := s.Create(context.Background(), &types.RequestLog{Model: "gpt-3.5"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if requests != 0 {
+		t.Fatalf("expected dropped log not to be sent, got %d requests", requests)
+	}
+
+	logs := []types.RequestLog{
+		{Model: "gpt-4", Metadata: map[string]interface{}{"env": "prod"}},
+		{Model: "gpt-3.5"},
+	}
+	if err := s.BatchCreate(context.Background(), logs); err != nil {
+		t.Fatalf("BatchCreate() error = %v", err)
+	}
+
+	if len(payload.Logs) != 1 {
+		t.Fatalf("expected 1 log in batch, got %d", len(payload.Logs))
+	}
+	if rate := payload.Logs[0].Metadata[SampleRateMetadataKey]; rate != 1.0 {
+		t.Errorf("expected sample_rate 1 in metadata, got %v", rate)
+	}
+	if payload.Logs[0].Metadata["env"] != "prod" {
+		t.Errorf("expected existing metadata to be preserved")
+	}
+	if _, ok := logs[0].Metadata[SampleRateMetadataKey]; ok {
+		t.Errorf("caller's metadata was modified")
+	}
+
+	kept, dropped := sampler.Stats()
+	if kept != 1 || dropped != 2 {
+		t.Errorf("Stats() = %d, %d, want 1, 2", kept, dropped)
+	}
+}
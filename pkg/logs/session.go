@@ -0,0 +1,269 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// TurnIndexMetadataKey is the Metadata key holding a session turn's
+// zero-based index within its thread.
+const TurnIndexMetadataKey = "turn_index"
+
+// TokenCounter estimates how many tokens a message uses.
+type TokenCounter func(types.Message) int
+
+// EstimateTokens is the default TokenCounter. It assumes roughly four
+// characters per token plus a small per-message overhead.
+func EstimateTokens(msg types.Message) int {
+	var chars int
+	switch c := msg.Content.(type) {
+	case string:
+		chars = len(c)
+	case nil:
+	default:
+		chars = len(fmt.Sprint(c))
+	}
+	return chars/4 + 4
+}
+
+// Summarizer condenses older messages into a short summary that replaces
+// them in the history.
+type Summarizer func(ctx context.Context, messages []types.Message) (string, error)
+
+// Session accumulates a multi-turn conversation for one customer and thread
+// and logs each turn stamped with the thread identifier and turn index.
+// It is safe for concurrent use.
+type Session struct {
+	service            *Service
+	batcher            *Batcher
+	customerIdentifier string
+	threadID           string
+	tokenBudget        int
+	countTokens        TokenCounter
+	summarize          Summarizer
+
+	// fitMu serializes fitBudget, which releases mu while the summarizer
+	// runs.
+	fitMu   sync.Mutex
+	mu      sync.Mutex
+	history []types.Message
+	summary string
+	turn    int
+}
+
+// SessionOption configures a Session.
+type SessionOption func(*Session)
+
+// WithSessionHistory seeds the session with earlier messages, e.g. a system
+// prompt or a conversation restored with threads.ToMessages.
+func WithSessionHistory(messages []types.Message) SessionOption {
+	return func(s *Session) {
+		s.history = append(s.history, messages...)
+	}
+}
+
+// WithTokenBudget caps the history sent to the model at budget tokens as
+// measured by counter (EstimateTokens if nil). The oldest non-system messages
+// are dropped, or summarized if WithSummarizer is also set.
+func WithTokenBudget(budget int, counter TokenCounter) SessionOption {
+	return func(s *Session) {
+		s.tokenBudget = budget
+		if counter != nil {
+			s.countTokens = counter
+		}
+	}
+}
+
+// WithSummarizer summarizes messages that no longer fit the token budget
+// instead of dropping them. The summary counts toward the budget, so fn may be
+// called again with more messages until the history fits. Other Session
+// methods are not blocked while fn runs, but concurrent AddUserMessage calls
+// wait for it.
+func WithSummarizer(fn Summarizer) SessionOption {
+	return func(s *Session) {
+		s.summarize = fn
+	}
+}
+
+// WithSessionBatcher delivers turn logs through b instead of calling Create
+// for each turn.
+func WithSessionBatcher(b *Batcher) SessionOption {
+	return func(s *Session) {
+		s.batcher = b
+	}
+}
+
+// NewSession creates a session that logs turns through service.
+func NewSession(service *Service, customerIdentifier, threadID string, opts ...SessionOption) *Session {
+	s := &Session{
+		service:            service,
+		customerIdentifier: customerIdentifier,
+		threadID:           threadID,
+		countTokens:        EstimateTokens,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CustomerIdentifier returns the customer the session belongs to.
+func (s *Session) CustomerIdentifier() string { return s.customerIdentifier }
+
+// ThreadID returns the thread identifier stamped on every turn.
+func (s *Session) ThreadID() string { return s.threadID }
+
+// TurnIndex returns the index the next recorded turn will have.
+func (s *Session) TurnIndex() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.turn
+}
+
+// AddMessage appends a message, such as a system or tool message, without
+// starting a turn.
+func (s *Session) AddMessage(msg types.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, msg)
+}
+
+// AddUserMessage appends a user message, fits the history to the token budget
+// and returns the messages to send to the model.
+func (s *Session) AddUserMessage(ctx context.Context, content interface{}) ([]types.Message, error) {
+	s.fitMu.Lock()
+	defer s.fitMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, types.Message{Role: "user", Content: content})
+	if err := s.fitBudget(ctx); err != nil {
+		return nil, err
+	}
+	return s.messages(), nil
+}
+
+// Messages returns the history as it would be sent to the model, including
+// any summary of earlier turns.
+func (s *Session) Messages() []types.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messages()
+}
+
+// RecordCompletion appends the model's reply and logs the turn. log carries
+// the call's model, usage and other details; its prompt and completion
+// messages, thread identifier, customer and turn index are filled in by the
+// session. log may be nil.
+func (s *Session) RecordCompletion(ctx context.Context, completion types.Message, log *types.RequestLog) error {
+	s.mu.Lock()
+	entry := types.RequestLog{}
+	if log != nil {
+		entry = *log
+	}
+	if completion.Role == "" {
+		completion.Role = "assistant"
+	}
+	entry.PromptMessages = s.messages()
+	entry.CompletionMessage = &completion
+	threadID := s.threadID
+	entry.ThreadIdentifier = &threadID
+	if entry.CustomerParams == nil {
+		entry.CustomerParams = &types.CustomerParams{CustomerIdentifier: s.customerIdentifier}
+	}
+	metadata := make(map[string]interface{}, len(entry.Metadata)+1)
+	for k, v := range entry.Metadata {
+		metadata[k] = v
+	}
+	metadata[TurnIndexMetadataKey] = s.turn
+	entry.Metadata = metadata
+
+	s.history = append(s.history, completion)
+	s.turn++
+	s.mu.Unlock()
+
+	if s.batcher != nil {
+		return s.batcher.Add(ctx, &entry)
+	}
+	return s.service.Create(ctx, &entry)
+}
+
+// Flush waits for turns handed to the session's Batcher to be submitted. It
+// does nothing when turns are logged directly.
+func (s *Session) Flush(ctx context.Context) error {
+	if s.batcher == nil {
+		return nil
+	}
+	return s.batcher.Flush(ctx)
+}
+
+// messages must be called with s.mu held.
+func (s *Session) messages() []types.Message {
+	lead := leadingSystemCount(s.history)
+	out := make([]types.Message, 0, len(s.history)+1)
+	out = append(out, s.history[:lead]...)
+	if s.summary != "" {
+		out = append(out, summaryMessage(s.summary))
+	}
+	return append(out, s.history[lead:]...)
+}
+
+// fitBudget drops or summarizes the oldest non-system messages until the
+// history, including any summary, fits the token budget, always keeping the
+// latest message. It must be called with s.fitMu and s.mu held, and releases
+// s.mu while the summarizer runs. Only fitBudget removes messages, so the
+// prefix being summarized is unchanged when s.mu is reacquired.
+func (s *Session) fitBudget(ctx context.Context) error {
+	if s.tokenBudget <= 0 {
+		return nil
+	}
+	for {
+		total := 0
+		for _, msg := range s.messages() {
+			total += s.countTokens(msg)
+		}
+
+		lead := leadingSystemCount(s.history)
+		cut := lead
+		for total > s.tokenBudget && cut < len(s.history)-1 {
+			total -= s.countTokens(s.history[cut])
+			cut++
+		}
+		if cut == lead {
+			return nil
+		}
+
+		if s.summarize != nil {
+			input := make([]types.Message, 0, cut-lead+1)
+			if s.summary != "" {
+				input = append(input, summaryMessage(s.summary))
+			}
+			input = append(input, s.history[lead:cut]...)
+			s.mu.Unlock()
+			summary, err := s.summarize(ctx, input)
+			s.mu.Lock()
+			if err != nil {
+				return fmt.Errorf("failed to summarize history: %w", err)
+			}
+			s.summary = summary
+		}
+
+		history := make([]types.Message, 0, len(s.history)-(cut-lead))
+		history = append(history, s.history[:lead]...)
+		s.history = append(history, s.history[cut:]...)
+	}
+}
+
+func leadingSystemCount(messages []types.Message) int {
+	n := 0
+	for n < len(messages) && messages[n].Role == "system" {
+		n++
+	}
+	return n
+}
+
+func summaryMessage(summary string) types.Message {
+	return types.Message{Role: "system", Content: "Summary of the earlier conversation: " + summary}
+}
@@ -0,0 +1,199 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+func TestSessionRecordsTurns(t *testing.T) {
+	var logged []types.RequestLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var log types.RequestLog
+		json.NewDecoder(r.Body).Decode(&log)
+		logged = append(logged, log)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	session := NewSession(NewService(c), "user-1", "thread-1",
+		WithSessionHistory([]types.Message{{Role: "system", Content: "Be brief."}}))
+
+	ctx := context.Background()
+	prompt, err := session.AddUserMessage(ctx, "Hi")
+	if err != nil {
+		t.Fatalf("AddUserMessage() error = %v", err)
+	}
+	if len(prompt) != 2 {
+		t.Fatalf("expected system and user messages, got %+v", prompt)
+	}
+	err = session.RecordCompletion(ctx, types.Message{Content: "Hello!"}, &types.RequestLog{
+		Model:        "gpt-4",
+		PromptTokens: utils.Int(10),
+	})
+	if err != nil {
+		t.Fatalf("RecordCompletion() error = %v", err)
+	}
+
+	session.AddUserMessage(ctx, "Bye")
+	session.RecordCompletion(ctx, types.Message{Role: "assistant", Content: "Goodbye!"}, nil)
+
+	if len(logged) != 2 {
+		t.Fatalf("expected 2 logged turns, got %d", len(logged))
+	}
+
+	first, second := logged[0], logged[1]
+	if first.ThreadIdentifier == nil || *first.ThreadIdentifier != "thread-1" {
+		t.Errorf("ThreadIdentifier = %v, want thread-1", first.ThreadIdentifier)
+	}
+	if first.CustomerParams == nil || first.CustomerParams.CustomerIdentifier != "user-1" {
+		t.Errorf("unexpected customer %+v", first.CustomerParams)
+	}
+	if first.Metadata[TurnIndexMetadataKey] != 0.0 || second.Metadata[TurnIndexMetadataKey] != 1.0 {
+		t.Errorf("unexpected turn indexes %v, %v", first.Metadata[TurnIndexMetadataKey], second.Metadata[TurnIndexMetadataKey])
+	}
+	if first.Model != "gpt-4" || *first.PromptTokens != 10 {
+		t.Errorf("expected caller's log fields to be kept, got %+v", first)
+	}
+	if first.CompletionMessage.Role != "assistant" {
+		t.Errorf("expected completion role to default to assistant, got %q", first.CompletionMessage.Role)
+	}
+	if len(second.PromptMessages) != 4 || second.PromptMessages[2].Content != "Hello!" {
+		t.Errorf("expected second turn to carry the full history, got %+v", second.PromptMessages)
+	}
+	if session.TurnIndex() != 2 {
+		t.Errorf("TurnIndex() = %d, want 2", session.TurnIndex())
+	}
+}
+
+func TestSessionTokenBudgetDropsOldest(t *testing.T) {
+	counter := func(types.Message) int { return 10 }
+	session := NewSession(nil, "user-1", "thread-1",
+		WithSessionHistory([]types.Message{{Role: "system", Content: "sys"}}),
+		WithTokenBudget(30, counter))
+
+	ctx := context.Background()
+	session.AddMessage(types.Message{Role: "user", Content: "one"})
+	session.AddMessage(types.Message{Role: "assistant", Content: "two"})
+	prompt, err := session.AddUserMessage(ctx, "three")
+	if err != nil {
+		t.Fatalf("AddUserMessage() error = %v", err)
+	}
+
+	if len(prompt) != 3 {
+		t.Fatalf("expected 3 messages within budget, got %+v", prompt)
+	}
+	if prompt[0].Role != "system" || prompt[1].Content != "two" || prompt[2].Content != "three" {
+		t.Errorf("expected system prompt kept and oldest turn dropped, got %+v", prompt)
+	}
+}
+
+func TestSessionTokenBudgetSummarizes(t *testing.T) {
+	counter := func(msg types.Message) int {
+		if msg.Role == "system" {
+			return 5
+		}
+		return 10
+	}
+	var summarized []types.Message
+	session := NewSession(nil, "user-1", "thread-1",
+		WithTokenBudget(25, counter),
+		WithSummarizer(func(ctx context.Context, messages []types.Message) (string, error) {
+			summarized = messages
+			return "user said one and two", nil
+		}))
+
+	session.AddMessage(types.Message{Role: "user", Content: "one"})
+	session.AddMessage(types.Message{Role: "assistant", Content: "two"})
+	prompt, err := session.AddUserMessage(context.Background(), "three")
+	if err != nil {
+		t.Fatalf("AddUserMessage() error = %v", err)
+	}
+
+	if len(summarized) != 1 || summarized[0].Content != "one" {
+		t.Errorf("unexpected messages summarized %+v", summarized)
+	}
+	if len(prompt) != 3 || prompt[0].Role != "system" {
+		t.Fatalf("expected summary to lead the prompt, got %+v", prompt)
+	}
+	if !strings.Contains(prompt[0].Content.(string), "user said one and two") {
+		t.Errorf("unexpected summary message %v", prompt[0].Content)
+	}
+}
+
+func TestSessionTokenBudgetCountsSummary(t *testing.T) {
+	counter := func(msg types.Message) int {
+		if msg.Role == "system" {
+			return strings.Count(msg.Content.(string), "s")
+		}
+		return 10
+	}
+	var calls int
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	session := NewSession(nil, "user-1", "thread-1",
+		WithTokenBudget(35, counter),
+		WithSummarizer(func(ctx context.Context, messages []types.Message) (string, error) {
+			calls++
+			started <- struct{}{}
+			<-release
+			return strings.Repeat("s", 5*len(messages)), nil
+		}))
+	for _, content := range []string{"a", "b", "c", "d"} {
+		session.AddMessage(types.Message{Role: "user", Content: content})
+	}
+
+	done := make(chan []types.Message)
+	go func() {
+		prompt, _ := session.AddUserMessage(context.Background(), "e")
+		done <- prompt
+	}()
+	<-started
+	// Other methods are not blocked while the summarizer runs.
+	if n := len(session.Messages()); n != 5 {
+		t.Errorf("expected 5 messages while summarizing, got %d", n)
+	}
+	close(release)
+	prompt := <-done
+
+	total := 0
+	for _, msg := range prompt {
+		total += counter(msg)
+	}
+	if total > 35 || calls != 2 || len(prompt) != 3 {
+		t.Errorf("expected the summary to be counted and the history refit, got %d tokens after %d summaries: %+v", total, calls, prompt)
+	}
+}
+
+func TestSessionWithBatcher(t *testing.T) {
+	rec := &batchRecorder{}
+	server := httptest.NewServer(rec.handler(t))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	b := NewBatcher(NewService(c), WithFlushInterval(time.Hour))
+	defer b.Close(context.Background())
+
+	session := NewSession(NewService(c), "user-1", "thread-1", WithSessionBatcher(b))
+	session.AddUserMessage(context.Background(), "Hi")
+	session.RecordCompletion(context.Background(), types.Message{Content: "Hello"}, nil)
+
+	if _, logs := rec.count(); logs != 0 {
+		t.Fatalf("expected turn to be queued, got %d logs sent", logs)
+	}
+	if err := session.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if _, logs := rec.count(); logs != 1 {
+		t.Errorf("expected 1 log after flush, got %d", logs)
+	}
+}
@@ -0,0 +1,285 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// SlogKeys names the record attributes a SlogHandler reads. Attributes nested
+// in groups are matched by their dotted path, so slog.Group("llm", "model", m)
+// matches the key "llm.model".
+type SlogKeys struct {
+	Model            string
+	Messages         string
+	Completion       string
+	PromptTokens     string
+	CompletionTokens string
+	Cost             string
+	Customer         string
+	Latency          string
+	Error            string
+}
+
+// DefaultSlogKeys returns the attribute names recognised by default.
+func DefaultSlogKeys() SlogKeys {
+	return SlogKeys{
+		Model:            "llm.model",
+		Messages:         "llm.messages",
+		Completion:       "llm.completion",
+		PromptTokens:     "llm.prompt_tokens",
+		CompletionTokens: "llm.completion_tokens",
+		Cost:             "llm.cost",
+		Customer:         "llm.customer",
+		Latency:          "llm.latency",
+		Error:            "llm.error",
+	}
+}
+
+// SlogHandler is a slog.Handler that turns records carrying an LLM model
+// attribute into request logs delivered through a Batcher. All other records
+// are passed to the wrapped handler.
+type SlogHandler struct {
+	batcher     *Batcher
+	next        slog.Handler
+	keys        SlogKeys
+	passThrough bool
+
+	attrs  []slog.Attr
+	prefix string
+}
+
+// SlogOption configures a SlogHandler.
+type SlogOption func(*SlogHandler)
+
+// WithSlogKeys overrides the attribute names that identify LLM records.
+func WithSlogKeys(keys SlogKeys) SlogOption {
+	return func(h *SlogHandler) {
+		h.keys = keys
+	}
+}
+
+// WithSlogPassThrough also passes LLM records to the wrapped handler.
+func WithSlogPassThrough() SlogOption {
+	return func(h *SlogHandler) {
+		h.passThrough = true
+	}
+}
+
+// NewSlogHandler creates a handler that sends LLM records to batcher and the
+// rest to next. next may be nil to discard non-LLM records.
+func NewSlogHandler(batcher *Batcher, next slog.Handler, opts ...SlogOption) *SlogHandler {
+	h := &SlogHandler{
+		batcher: batcher,
+		next:    next,
+		keys:    DefaultSlogKeys(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Enabled reports true for every level, since LLM records must reach the
+// batcher even when the wrapped handler would filter them.
+func (h *SlogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle converts LLM records to request logs and forwards the rest.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make(map[string]slog.Value)
+	for _, a := range h.attrs {
+		flattenAttr(attrs, "", a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		flattenAttr(attrs, h.prefix, a)
+		return true
+	})
+
+	if _, ok := attrs[h.keys.Model]; !ok {
+		return h.forward(ctx, r)
+	}
+
+	log := h.toRequestLog(r, attrs)
+	err := h.batcher.Add(ctx, log)
+	if h.passThrough {
+		if fwdErr := h.forward(ctx, r); fwdErr != nil {
+			return fwdErr
+		}
+	}
+	return err
+}
+
+// WithAttrs returns a handler whose records include attrs.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = make([]slog.Attr, len(h.attrs), len(h.attrs)+len(attrs))
+	copy(clone.attrs, h.attrs)
+	for _, a := range attrs {
+		if h.prefix != "" {
+			a.Key = h.prefix + a.Key
+		}
+		clone.attrs = append(clone.attrs, a)
+	}
+	if h.next != nil {
+		clone.next = h.next.WithAttrs(attrs)
+	}
+	return &clone
+}
+
+// WithGroup returns a handler that nests subsequent attributes under name.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	if h.next != nil {
+		clone.next = h.next.WithGroup(name)
+	}
+	return &clone
+}
+
+func (h *SlogHandler) forward(ctx context.Context, r slog.Record) error {
+	if h.next == nil || !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func flattenAttr(dst map[string]slog.Value, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if a.Key != "" {
+			groupPrefix = prefix + a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			flattenAttr(dst, groupPrefix, ga)
+		}
+		return
+	}
+	dst[prefix+a.Key] = v
+}
+
+func (h *SlogHandler) toRequestLog(r slog.Record, attrs map[string]slog.Value) *types.RequestLog {
+	k := h.keys
+	log := &types.RequestLog{
+		Model:          attrs[k.Model].String(),
+		PromptMessages: []types.Message{},
+	}
+	if !r.Time.IsZero() {
+		ts := r.Time
+		log.Timestamp = &ts
+	}
+
+	if v, ok := attrs[k.Messages]; ok {
+		log.PromptMessages = toMessages(v.Any())
+	}
+	if v, ok := attrs[k.Completion]; ok {
+		if msgs := toMessages(v.Any()); len(msgs) > 0 {
+			msg := msgs[0]
+			if msg.Role == "user" {
+				msg.Role = "assistant"
+			}
+			log.CompletionMessage = &msg
+		}
+	}
+	if v, ok := attrs[k.PromptTokens]; ok {
+		n := int(valueFloat(v))
+		log.PromptTokens = &n
+	}
+	if v, ok := attrs[k.CompletionTokens]; ok {
+		n := int(valueFloat(v))
+		log.CompletionTokens = &n
+	}
+	if v, ok := attrs[k.Cost]; ok {
+		cost := valueFloat(v)
+		log.Cost = &cost
+	}
+	if v, ok := attrs[k.Latency]; ok {
+		var latency int
+		if v.Kind() == slog.KindDuration {
+			latency = int(v.Duration() / time.Millisecond)
+		} else {
+			latency = int(valueFloat(v))
+		}
+		log.Latency = &latency
+	}
+	if v, ok := attrs[k.Customer]; ok {
+		log.CustomerParams = &types.CustomerParams{CustomerIdentifier: v.String()}
+	}
+	if v, ok := attrs[k.Error]; ok {
+		failed := true
+		errText := v.String()
+		log.Failed = &failed
+		log.Error = &errText
+	}
+
+	known := map[string]bool{
+		k.Model: true, k.Messages: true, k.Completion: true, k.PromptTokens: true,
+		k.CompletionTokens: true, k.Cost: true, k.Customer: true, k.Latency: true, k.Error: true,
+	}
+	metadata := map[string]interface{}{}
+	if r.Message != "" {
+		metadata["log_message"] = r.Message
+	}
+	for key, v := range attrs {
+		if !known[key] {
+			metadata[key] = v.Any()
+		}
+	}
+	if len(metadata) > 0 {
+		log.Metadata = metadata
+	}
+	return log
+}
+
+// toMessages accepts []types.Message, a single types.Message, a plain string
+// (treated as one user message) or any JSON-compatible message list.
+func toMessages(v interface{}) []types.Message {
+	switch val := v.(type) {
+	case []types.Message:
+		return val
+	case types.Message:
+		return []types.Message{val}
+	case *types.Message:
+		if val == nil {
+			return nil
+		}
+		return []types.Message{*val}
+	case string:
+		return []types.Message{{Role: "user", Content: val}}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var msgs []types.Message
+	if err := json.Unmarshal(data, &msgs); err == nil {
+		return msgs
+	}
+	var msg types.Message
+	if err := json.Unmarshal(data, &msg); err == nil && strings.TrimSpace(msg.Role) != "" {
+		return []types.Message{msg}
+	}
+	return nil
+}
+
+func valueFloat(v slog.Value) float64 {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return float64(v.Int64())
+	case slog.KindUint64:
+		return float64(v.Uint64())
+	case slog.KindFloat64:
+		return v.Float64()
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+func newTestSlogHandler(t *testing.T, out *bytes.Buffer, opts ...SlogOption) (*slog.Logger, *Batcher, *batchRecorder) {
+	t.Helper()
+	rec := &batchRecorder{}
+	server := httptest.NewServer(rec.handler(t))
+	t.Cleanup(server.Close)
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	b := NewBatcher(NewService(c), WithFlushInterval(time.Hour))
+	t.Cleanup(func() { b.Close(context.Background()) })
+
+	next := slog.NewTextHandler(out, &slog.HandlerOptions{Level: slog.LevelInfo})
+	return slog.New(NewSlogHandler(b, next, opts...)), b, rec
+}
+
+func TestSlogHandlerConvertsLLMRecords(t *testing.T) {
+	var out bytes.Buffer
+	logger, b, rec := newTestSlogHandler(t, &out)
+
+	logger.Info("chat completion",
+		"llm.model", "gpt-4",
+		"llm.messages", []types.Message{{Role: "user", Content: "Hi"}},
+		"llm.completion", "Hello!",
+		"llm.prompt_tokens", 3,
+		"llm.completion_tokens", 2,
+		"llm.cost", 0.0004,
+		"llm.latency", 1200*time.Millisecond,
+		"llm.customer", "user-1",
+		"request_id", "abc",
+	)
+	logger.Info("unrelated", "path", "/health")
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if _, logs := rec.count(); logs != 1 {
+		t.Fatalf("expected 1 log sent, got %d", logs)
+	}
+	log := rec.batches[0][0]
+	if log.Model != "gpt-4" {
+		t.Errorf("Model = %s, want gpt-4", log.Model)
+	}
+	if len(log.PromptMessages) != 1 || log.PromptMessages[0].Content != "Hi" {
+		t.Errorf("unexpected prompt messages %+v", log.PromptMessages)
+	}
+	if log.CompletionMessage == nil || log.CompletionMessage.Role != "assistant" || log.CompletionMessage.Content != "Hello!" {
+		t.Errorf("unexpected completion %+v", log.CompletionMessage)
+	}
+	if *log.PromptTokens != 3 || *log.CompletionTokens != 2 || *log.Cost != 0.0004 || *log.Latency != 1200 {
+		t.Errorf("unexpected usage fields %d %d %v %d", *log.PromptTokens, *log.CompletionTokens, *log.Cost, *log.Latency)
+	}
+	if log.CustomerParams == nil || log.CustomerParams.CustomerIdentifier != "user-1" {
+		t.Errorf("unexpected customer %+v", log.CustomerParams)
+	}
+	if log.Metadata["request_id"] != "abc" || log.Metadata["log_message"] != "chat completion" {
+		t.Errorf("unexpected metadata %v", log.Metadata)
+	}
+
+	if strings.Contains(out.String(), "chat completion") {
+		t.Errorf("LLM record should not be passed through by default: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "unrelated") {
+		t.Errorf("expected non-LLM record to be passed through: %s", out.String())
+	}
+}
+
+func TestSlogHandlerGroupsAndAttrs(t *testing.T) {
+	var out bytes.Buffer
+	logger, b, rec := newTestSlogHandler(t, &out, WithSlogPassThrough())
+
+	logger = logger.With("service", "support")
+	logger.Debug("call", slog.Group("llm", "model", "claude-3", "error", "overloaded"))
+
+	b.Flush(context.Background())
+
+	if _, logs := rec.count(); logs != 1 {
+		t.Fatalf("expected debug LLM record to be sent, got %d logs", logs)
+	}
+	log := rec.batches[0][0]
+	if log.Model != "claude-3" {
+		t.Errorf("Model = %s, want claude-3", log.Model)
+	}
+	if log.Failed == nil || !*log.Failed || *log.Error != "overloaded" {
+		t.Errorf("expected failed log, got %+v", log)
+	}
+	if log.Metadata["service"] != "support" {
+		t.Errorf("expected handler attrs in metadata, got %v", log.Metadata)
+	}
+	if out.Len() != 0 {
+		t.Errorf("debug record should be filtered by the wrapped handler's level: %s", out.String())
+	}
+}
+
+func TestSlogHandlerWithGroup(t *testing.T) {
+	var out bytes.Buffer
+	logger, b, rec := newTestSlogHandler(t, &out)
+
+	logger.WithGroup("llm").Info("call", "model", "gpt-4o", "cost", 0.01)
+	b.Flush(context.Background())
+
+	if _, logs := rec.count(); logs != 1 {
+		t.Fatalf("expected grouped record to be recognised, got %d logs", logs)
+	}
+	if cost := rec.batches[0][0].Cost; cost == nil || *cost != 0.01 {
+		t.Errorf("Cost = %v, want 0.01", cost)
+	}
+}
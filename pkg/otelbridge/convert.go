@@ -0,0 +1,402 @@
+package otelbridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// GenAI semantic convention attribute keys.
+const (
+	attrSystem           = "gen_ai.system"
+	attrProviderName     = "gen_ai.provider.name"
+	attrOperationName    = "gen_ai.operation.name"
+	attrRequestModel     = "gen_ai.request.model"
+	attrResponseModel    = "gen_ai.response.model"
+	attrResponseID       = "gen_ai.response.id"
+	attrFinishReasons    = "gen_ai.response.finish_reasons"
+	attrInputTokens      = "gen_ai.usage.input_tokens"
+	attrOutputTokens     = "gen_ai.usage.output_tokens"
+	attrPromptTokens     = "gen_ai.usage.prompt_tokens"
+	attrCompletionTokens = "gen_ai.usage.completion_tokens"
+	attrInputMessages    = "gen_ai.input.messages"
+	attrOutputMessages   = "gen_ai.output.messages"
+	attrErrorType        = "error.type"
+	attrEndUserID        = "enduser.id"
+
+	requestParamPrefix = "gen_ai.request."
+	promptPrefix       = "gen_ai.prompt."
+	completionPrefix   = "gen_ai.completion."
+)
+
+// ToRequestLog maps a span following the GenAI semantic conventions to a
+// request log. It returns false for spans without any gen_ai.* attributes.
+//
+// Messages are read from the gen_ai.input.messages/gen_ai.output.messages
+// attributes, the indexed gen_ai.prompt.N/gen_ai.completion.N attributes, or
+// gen_ai.*.message and gen_ai.choice events, in that order of preference.
+// Latency is recorded in milliseconds.
+func ToRequestLog(span Span) (*types.RequestLog, bool) {
+	if !isGenAISpan(span) {
+		return nil, false
+	}
+	attrs := span.Attributes
+
+	log := &types.RequestLog{
+		Model: stringAttr(attrs, attrResponseModel),
+	}
+	if log.Model == "" {
+		log.Model = stringAttr(attrs, attrRequestModel)
+	}
+
+	if provider := firstString(attrs, attrProviderName, attrSystem); provider != "" {
+		log.Provider = &provider
+	}
+
+	if !span.StartTime.IsZero() {
+		start := span.StartTime
+		log.Timestamp = &start
+		if span.EndTime.After(span.StartTime) {
+			latency := int(span.EndTime.Sub(span.StartTime).Milliseconds())
+			log.Latency = &latency
+		}
+	}
+
+	promptTokens, hasPrompt := firstInt(attrs, attrInputTokens, attrPromptTokens)
+	completionTokens, hasCompletion := firstInt(attrs, attrOutputTokens, attrCompletionTokens)
+	if hasPrompt {
+		log.PromptTokens = &promptTokens
+	}
+	if hasCompletion {
+		log.CompletionTokens = &completionTokens
+	}
+	if hasPrompt || hasCompletion {
+		log.Usage = &types.Usage{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      promptTokens + completionTokens,
+		}
+	}
+
+	log.PromptMessages, log.CompletionMessage = spanMessages(span)
+	if log.PromptMessages == nil {
+		log.PromptMessages = []types.Message{}
+	}
+
+	params := make(map[string]interface{})
+	for key, value := range attrs {
+		if strings.HasPrefix(key, requestParamPrefix) && key != attrRequestModel {
+			params[strings.TrimPrefix(key, requestParamPrefix)] = value
+		}
+	}
+	if len(params) > 0 {
+		log.RequestParams = params
+	}
+
+	if customer := stringAttr(attrs, attrEndUserID); customer != "" {
+		log.CustomerParams = &types.CustomerParams{CustomerIdentifier: customer}
+	}
+
+	if span.TraceID != "" {
+		traceID := span.TraceID
+		log.TraceUniqueID = &traceID
+	}
+	if span.SpanID != "" {
+		spanID := span.SpanID
+		log.SpanUniqueID = &spanID
+	}
+	if span.ParentSpanID != "" {
+		parentID := span.ParentSpanID
+		log.SpanParentID = &parentID
+	}
+	if span.Name != "" {
+		name := span.Name
+		log.SpanName = &name
+	}
+
+	metadata := map[string]interface{}{}
+	if op := stringAttr(attrs, attrOperationName); op != "" {
+		metadata["operation"] = op
+	}
+	if id := stringAttr(attrs, attrResponseID); id != "" {
+		metadata["response_id"] = id
+	}
+	if reasons, ok := attrs[attrFinishReasons]; ok {
+		metadata["finish_reasons"] = reasons
+	}
+	if len(metadata) > 0 {
+		log.Metadata = metadata
+	}
+
+	applyError(span, log)
+	return log, true
+}
+
+func isGenAISpan(span Span) bool {
+	for key := range span.Attributes {
+		if strings.HasPrefix(key, "gen_ai.") {
+			return true
+		}
+	}
+	return false
+}
+
+func applyError(span Span, log *types.RequestLog) {
+	errText := ""
+	for _, event := range span.Events {
+		if event.Name == "exception" {
+			errText = stringAttr(event.Attributes, "exception.message")
+			if errText == "" {
+				errText = stringAttr(event.Attributes, "exception.type")
+			}
+		}
+	}
+	errType := stringAttr(span.Attributes, attrErrorType)
+
+	if span.Status != StatusError && errType == "" && errText == "" {
+		return
+	}
+
+	failed := true
+	log.Failed = &failed
+	switch {
+	case span.StatusDescription != "":
+		errText = span.StatusDescription
+	case errText == "":
+		errText = errType
+	}
+	if errText != "" {
+		log.Error = &errText
+	}
+	if code, ok := firstInt(span.Attributes, "http.response.status_code", "http.status_code"); ok {
+		log.StatusCode = &code
+	}
+}
+
+func spanMessages(span Span) ([]types.Message, *types.Message) {
+	attrs := span.Attributes
+
+	prompt := structuredMessages(attrs[attrInputMessages])
+	if prompt == nil {
+		prompt = indexedMessages(attrs, promptPrefix)
+	}
+	var completion *types.Message
+	if out := structuredMessages(attrs[attrOutputMessages]); len(out) > 0 {
+		completion = &out[0]
+	} else if out := indexedMessages(attrs, completionPrefix); len(out) > 0 {
+		completion = &out[0]
+	}
+
+	if prompt != nil && completion != nil {
+		return prompt, completion
+	}
+
+	eventPrompt, eventCompletion := eventMessages(span.Events)
+	if prompt == nil {
+		prompt = eventPrompt
+	}
+	if completion == nil {
+		completion = eventCompletion
+	}
+	return prompt, completion
+}
+
+// structuredMessages decodes the JSON form used by gen_ai.input.messages and
+// gen_ai.output.messages, where each message carries a list of typed parts.
+func structuredMessages(value interface{}) []types.Message {
+	var raw []map[string]interface{}
+	switch v := value.(type) {
+	case string:
+		if err := json.Unmarshal([]byte(v), &raw); err != nil {
+			return nil
+		}
+	case []interface{}:
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				raw = append(raw, m)
+			}
+		}
+	default:
+		return nil
+	}
+
+	messages := make([]types.Message, 0, len(raw))
+	for _, m := range raw {
+		role, _ := m["role"].(string)
+		msg := types.Message{Role: role}
+		if parts, ok := m["parts"].([]interface{}); ok {
+			msg.Content = partsContent(parts)
+		} else {
+			msg.Content = m["content"]
+		}
+		if name, ok := m["name"].(string); ok && name != "" {
+			msg.Name = &name
+		}
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// partsContent collapses text-only parts to a string and keeps anything else
+// as multi-part content.
+func partsContent(parts []interface{}) interface{} {
+	var texts []string
+	for _, p := range parts {
+		part, ok := p.(map[string]interface{})
+		if !ok || part["type"] != "text" {
+			return parts
+		}
+		text, _ := part["content"].(string)
+		texts = append(texts, text)
+	}
+	return strings.Join(texts, "")
+}
+
+// indexedMessages reads the flattened prefix.N.role / prefix.N.content attributes.
+func indexedMessages(attrs map[string]interface{}, prefix string) []types.Message {
+	byIndex := make(map[int]*types.Message)
+	for key, value := range attrs {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(key, prefix)
+		dot := strings.IndexByte(rest, '.')
+		if dot < 0 {
+			continue
+		}
+		idx, err := strconv.Atoi(rest[:dot])
+		if err != nil {
+			continue
+		}
+		msg, ok := byIndex[idx]
+		if !ok {
+			msg = &types.Message{}
+			byIndex[idx] = msg
+		}
+		switch rest[dot+1:] {
+		case "role":
+			msg.Role = toString(value)
+		case "content":
+			msg.Content = toString(value)
+		}
+	}
+	if len(byIndex) == 0 {
+		return nil
+	}
+
+	indexes := make([]int, 0, len(byIndex))
+	for idx := range byIndex {
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+	messages := make([]types.Message, 0, len(indexes))
+	for _, idx := range indexes {
+		messages = append(messages, *byIndex[idx])
+	}
+	return messages
+}
+
+// eventMessages reads the gen_ai.{system,user,assistant,tool}.message and
+// gen_ai.choice span events.
+func eventMessages(events []Event) ([]types.Message, *types.Message) {
+	var prompt []types.Message
+	var completion *types.Message
+	for _, event := range events {
+		switch event.Name {
+		case "gen_ai.system.message", "gen_ai.user.message", "gen_ai.assistant.message", "gen_ai.tool.message":
+			role := stringAttr(event.Attributes, "role")
+			if role == "" {
+				role = strings.TrimSuffix(strings.TrimPrefix(event.Name, "gen_ai."), ".message")
+			}
+			prompt = append(prompt, types.Message{Role: role, Content: event.Attributes["content"]})
+		case "gen_ai.choice":
+			if completion != nil {
+				continue
+			}
+			msg := types.Message{Role: "assistant"}
+			switch m := event.Attributes["message"].(type) {
+			case map[string]interface{}:
+				if role, ok := m["role"].(string); ok && role != "" {
+					msg.Role = role
+				}
+				msg.Content = m["content"]
+			case string:
+				var decoded map[string]interface{}
+				if err := json.Unmarshal([]byte(m), &decoded); err == nil {
+					if role, ok := decoded["role"].(string); ok && role != "" {
+						msg.Role = role
+					}
+					msg.Content = decoded["content"]
+				} else {
+					msg.Content = m
+				}
+			default:
+				msg.Content = event.Attributes["content"]
+			}
+			completion = &msg
+		}
+	}
+	return prompt, completion
+}
+
+func stringAttr(attrs map[string]interface{}, key string) string {
+	if v, ok := attrs[key]; ok {
+		return toString(v)
+	}
+	return ""
+}
+
+func firstString(attrs map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if s := stringAttr(attrs, key); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func firstInt(attrs map[string]interface{}, keys ...string) (int, bool) {
+	for _, key := range keys {
+		if v, ok := attrs[key]; ok {
+			if n, ok := toInt(v); ok {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func toString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func toInt(v interface{}) (int, bool) {
+	switch val := v.(type) {
+	case int:
+		return val, true
+	case int32:
+		return int(val), true
+	case int64:
+		return int(val), true
+	case float64:
+		return int(val), true
+	case json.Number:
+		n, err := val.Int64()
+		return int(n), err == nil
+	case string:
+		n, err := strconv.Atoi(val)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,158 @@
+package otelbridge
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToRequestLogAttributes(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	span := Span{
+		Name:      "chat gpt-4",
+		TraceID:   "trace-1",
+		SpanID:    "span-1",
+		StartTime: start,
+		EndTime:   start.Add(1500 * time.Millisecond),
+		Attributes: map[string]interface{}{
+			"gen_ai.system":              "openai",
+			"gen_ai.operation.name":      "chat",
+			"gen_ai.request.model":       "gpt-4",
+			"gen_ai.response.model":      "gpt-4-0613",
+			"gen_ai.request.temperature": 0.2,
+			"gen_ai.usage.input_tokens":  int64(12),
+			"gen_ai.usage.output_tokens": int64(30),
+			"gen_ai.input.messages":      `[{"role":"system","parts":[{"type":"text","content":"Be brief."}]},{"role":"user","parts":[{"type":"text","content":"Hi"}]}]`,
+			"gen_ai.output.messages":     `[{"role":"assistant","parts":[{"type":"text","content":"Hello!"}]}]`,
+			"enduser.id":                 "user-42",
+		},
+	}
+
+	log, ok := ToRequestLog(span)
+	if !ok {
+		t.Fatal("expected GenAI span to be converted")
+	}
+
+	if log.Model != "gpt-4-0613" {
+		t.Errorf("Model = %s, want response model gpt-4-0613", log.Model)
+	}
+	if log.Provider == nil || *log.Provider != "openai" {
+		t.Errorf("Provider = %v, want openai", log.Provider)
+	}
+	if log.PromptTokens == nil || *log.PromptTokens != 12 {
+		t.Errorf("PromptTokens = %v, want 12", log.PromptTokens)
+	}
+	if log.CompletionTokens == nil || *log.CompletionTokens != 30 {
+		t.Errorf("CompletionTokens = %v, want 30", log.CompletionTokens)
+	}
+	if log.Usage == nil || log.Usage.TotalTokens != 42 {
+		t.Errorf("Usage = %+v, want total 42", log.Usage)
+	}
+	if log.Latency == nil || *log.Latency != 1500 {
+		t.Errorf("Latency = %v, want 1500", log.Latency)
+	}
+	if log.Timestamp == nil || !log.Timestamp.Equal(start) {
+		t.Errorf("Timestamp = %v, want %v", log.Timestamp, start)
+	}
+	if len(log.PromptMessages) != 2 || log.PromptMessages[1].Content != "Hi" {
+		t.Errorf("unexpected prompt messages %+v", log.PromptMessages)
+	}
+	if log.CompletionMessage == nil || log.CompletionMessage.Content != "Hello!" {
+		t.Errorf("unexpected completion %+v", log.CompletionMessage)
+	}
+	if log.RequestParams["temperature"] != 0.2 {
+		t.Errorf("RequestParams = %v, want temperature", log.RequestParams)
+	}
+	if log.CustomerParams == nil || log.CustomerParams.CustomerIdentifier != "user-42" {
+		t.Errorf("CustomerParams = %+v", log.CustomerParams)
+	}
+	if log.TraceUniqueID == nil || *log.TraceUniqueID != "trace-1" || log.SpanUniqueID == nil || *log.SpanUniqueID != "span-1" {
+		t.Errorf("TraceUniqueID = %v, SpanUniqueID = %v", log.TraceUniqueID, log.SpanUniqueID)
+	}
+	if log.Metadata["operation"] != "chat" {
+		t.Errorf("Metadata = %v", log.Metadata)
+	}
+	if log.Failed != nil {
+		t.Errorf("expected successful span, got Failed = %v", *log.Failed)
+	}
+}
+
+func TestToRequestLogIndexedMessages(t *testing.T) {
+	span := Span{
+		Attributes: map[string]interface{}{
+			"gen_ai.request.model":           "claude-3",
+			"gen_ai.usage.prompt_tokens":     5,
+			"gen_ai.prompt.1.role":           "user",
+			"gen_ai.prompt.1.content":        "second",
+			"gen_ai.prompt.0.role":           "system",
+			"gen_ai.prompt.0.content":        "first",
+			"gen_ai.completion.0.role":       "assistant",
+			"gen_ai.completion.0.content":    "reply",
+			"gen_ai.usage.completion_tokens": "7",
+		},
+	}
+
+	log, ok := ToRequestLog(span)
+	if !ok {
+		t.Fatal("expected conversion")
+	}
+	if len(log.PromptMessages) != 2 || log.PromptMessages[0].Content != "first" || log.PromptMessages[1].Role != "user" {
+		t.Errorf("unexpected prompt messages %+v", log.PromptMessages)
+	}
+	if log.CompletionMessage == nil || log.CompletionMessage.Content != "reply" {
+		t.Errorf("unexpected completion %+v", log.CompletionMessage)
+	}
+	if log.CompletionTokens == nil || *log.CompletionTokens != 7 {
+		t.Errorf("CompletionTokens = %v, want 7", log.CompletionTokens)
+	}
+}
+
+func TestToRequestLogEvents(t *testing.T) {
+	span := Span{
+		Attributes: map[string]interface{}{"gen_ai.request.model": "gpt-4o"},
+		Events: []Event{
+			{Name: "gen_ai.system.message", Attributes: map[string]interface{}{"content": "sys"}},
+			{Name: "gen_ai.user.message", Attributes: map[string]interface{}{"content": "question"}},
+			{Name: "gen_ai.choice", Attributes: map[string]interface{}{"message": `{"role":"assistant","content":"answer"}`}},
+		},
+	}
+
+	log, _ := ToRequestLog(span)
+	if len(log.PromptMessages) != 2 || log.PromptMessages[0].Role != "system" || log.PromptMessages[1].Role != "user" {
+		t.Errorf("unexpected prompt messages %+v", log.PromptMessages)
+	}
+	if log.CompletionMessage == nil || log.CompletionMessage.Content != "answer" {
+		t.Errorf("unexpected completion %+v", log.CompletionMessage)
+	}
+}
+
+func TestToRequestLogErrors(t *testing.T) {
+	span := Span{
+		Attributes: map[string]interface{}{
+			"gen_ai.request.model":      "gpt-4",
+			"error.type":                "rate_limit",
+			"http.response.status_code": 429,
+		},
+		Events: []Event{
+			{Name: "exception", Attributes: map[string]interface{}{"exception.message": "too many requests"}},
+		},
+		Status: StatusError,
+	}
+
+	log, _ := ToRequestLog(span)
+	if log.Failed == nil || !*log.Failed {
+		t.Fatal("expected Failed to be set")
+	}
+	if log.Error == nil || *log.Error != "too many requests" {
+		t.Errorf("Error = %v, want exception message", log.Error)
+	}
+	if log.StatusCode == nil || *log.StatusCode != 429 {
+		t.Errorf("StatusCode = %v, want 429", log.StatusCode)
+	}
+}
+
+func TestToRequestLogSkipsNonGenAISpans(t *testing.T) {
+	span := Span{Name: "GET /health", Attributes: map[string]interface{}{"http.method": "GET"}}
+	if _, ok := ToRequestLog(span); ok {
+		t.Error("expected non-GenAI span to be skipped")
+	}
+}
@@ -0,0 +1,182 @@
+// Package otelbridge exports OpenTelemetry spans that follow the GenAI semantic
+// conventions to KeywordsAI as request logs.
+//
+// The package does not depend on the OpenTelemetry SDK. Span mirrors the parts
+// of sdktrace.ReadOnlySpan the exporter needs, so wiring it into an OTel
+// pipeline only takes a small adapter:
+//
+//	func (a adapter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+//		converted := make([]otelbridge.Span, len(spans))
+//		for i, s := range spans {
+//			attrs := make(map[string]interface{})
+//			for _, kv := range s.Attributes() {
+//				attrs[string(kv.Key)] = kv.Value.AsInterface()
+//			}
+//			converted[i] = otelbridge.Span{
+//				Name:       s.Name(),
+//				TraceID:    s.SpanContext().TraceID().String(),
+//				SpanID:     s.SpanContext().SpanID().String(),
+//				StartTime:  s.StartTime(),
+//				EndTime:    s.EndTime(),
+//				Attributes: attrs,
+//				Status:     otelbridge.StatusCode(s.Status().Code),
+//				// ...
+//			}
+//		}
+//		return a.exporter.ExportSpans(ctx, converted)
+//	}
+package otelbridge
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/logs"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// maxBatchSize is the largest batch logs.Service.BatchCreate accepts.
+const maxBatchSize = 5000
+
+// ErrShutdown is returned by ExportSpans after Shutdown has been called.
+var ErrShutdown = errors.New("otelbridge: exporter is shut down")
+
+// StatusCode mirrors the OpenTelemetry span status codes, with the same values.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota
+	StatusError
+	StatusOK
+)
+
+// Event is a timestamped span event.
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes map[string]interface{}
+}
+
+// Span is the subset of an OpenTelemetry span needed to build a request log.
+type Span struct {
+	Name              string
+	TraceID           string
+	SpanID            string
+	ParentSpanID      string
+	StartTime         time.Time
+	EndTime           time.Time
+	Attributes        map[string]interface{}
+	Events            []Event
+	Status            StatusCode
+	StatusDescription string
+}
+
+// SpanExporter has the same shape as the OpenTelemetry SDK's SpanExporter,
+// with spans expressed using the local Span type.
+type SpanExporter interface {
+	ExportSpans(ctx context.Context, spans []Span) error
+	Shutdown(ctx context.Context) error
+}
+
+// Exporter converts GenAI spans to request logs and submits them with
+// logs.Service.BatchCreate. Spans without gen_ai.* attributes are ignored.
+type Exporter struct {
+	service   *logs.Service
+	batchSize int
+	transform func(Span, *types.RequestLog)
+
+	mu       sync.RWMutex
+	shutdown bool
+}
+
+var _ SpanExporter = (*Exporter)(nil)
+
+// Option configures an Exporter.
+type Option func(*Exporter)
+
+// WithBatchSize limits how many logs are sent per BatchCreate call.
+func WithBatchSize(size int) Option {
+	return func(e *Exporter) {
+		if size > 0 && size <= maxBatchSize {
+			e.batchSize = size
+		}
+	}
+}
+
+// WithTransform registers fn to adjust each log after the default mapping,
+// e.g. to copy custom span attributes into Metadata or CustomerParams.
+func WithTransform(fn func(Span, *types.RequestLog)) Option {
+	return func(e *Exporter) {
+		e.transform = fn
+	}
+}
+
+// NewExporter creates an Exporter that submits logs through service.
+func NewExporter(service *logs.Service, opts ...Option) *Exporter {
+	e := &Exporter{
+		service:   service,
+		batchSize: maxBatchSize,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// ExportSpans maps GenAI spans to request logs and submits them in batches.
+func (e *Exporter) ExportSpans(ctx context.Context, spans []Span) error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.shutdown {
+		return ErrShutdown
+	}
+
+	batch := make([]types.RequestLog, 0, min(len(spans), e.batchSize))
+	for _, span := range spans {
+		log, ok := ToRequestLog(span)
+		if !ok {
+			continue
+		}
+		if e.transform != nil {
+			e.transform(span, log)
+		}
+		batch = append(batch, *log)
+		if len(batch) == e.batchSize {
+			if err := e.service.BatchCreate(ctx, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	return e.service.BatchCreate(ctx, batch)
+}
+
+// Shutdown stops the exporter once in-flight exports have finished. Spans
+// exported afterwards are rejected. It returns ctx's error only if ctx ended
+// while exports were still in flight; the exporter still stops when they
+// finish.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	if e.mu.TryLock() {
+		e.shutdown = true
+		e.mu.Unlock()
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		e.mu.Lock()
+		e.shutdown = true
+		e.mu.Unlock()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,87 @@
+package otelbridge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/logs"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+func TestExportSpans(t *testing.T) {
+	var batches [][]types.RequestLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/request-logs/batch/create" {
+			t.Errorf("Expected path /api/request-logs/batch/create, got %s", r.URL.Path)
+		}
+		var payload types.BatchRequestLogsPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("Failed to decode request body: %v", err)
+		}
+		batches = append(batches, payload.Logs)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	exporter := NewExporter(logs.NewService(c),
+		WithBatchSize(2),
+		WithTransform(func(span Span, log *types.RequestLog) {
+			log.Tags = append(log.Tags, span.Name)
+		}),
+	)
+
+	spans := []Span{
+		{Name: "a", Attributes: map[string]interface{}{"gen_ai.request.model": "gpt-4"}},
+		{Name: "db", Attributes: map[string]interface{}{"db.system": "postgres"}},
+		{Name: "b", Attributes: map[string]interface{}{"gen_ai.request.model": "gpt-4"}},
+		{Name: "c", Attributes: map[string]interface{}{"gen_ai.request.model": "gpt-4"}},
+	}
+
+	if err := exporter.ExportSpans(context.Background(), spans); err != nil {
+		t.Fatalf("ExportSpans() error = %v", err)
+	}
+
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(batches))
+	}
+	if len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Errorf("unexpected batch sizes %d, %d", len(batches[0]), len(batches[1]))
+	}
+	if batches[1][0].Tags[0] != "c" {
+		t.Errorf("expected transform to tag log with span name, got %v", batches[1][0].Tags)
+	}
+}
+
+func TestExportSpansAfterShutdown(t *testing.T) {
+	c := client.New("test-key", client.WithBaseURL("http://127.0.0.1:0"))
+	exporter := NewExporter(logs.NewService(c))
+
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	err := exporter.ExportSpans(context.Background(), []Span{{Attributes: map[string]interface{}{"gen_ai.system": "openai"}}})
+	if !errors.Is(err, ErrShutdown) {
+		t.Errorf("expected ErrShutdown, got %v", err)
+	}
+}
+
+func TestShutdownWithExpiredContext(t *testing.T) {
+	c := client.New("test-key", client.WithBaseURL("http://127.0.0.1:0"))
+	exporter := NewExporter(logs.NewService(c))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := exporter.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() with nothing in flight error = %v", err)
+	}
+	err := exporter.ExportSpans(context.Background(), []Span{{Attributes: map[string]interface{}{"gen_ai.system": "openai"}}})
+	if !errors.Is(err, ErrShutdown) {
+		t.Errorf("expected ErrShutdown, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package threads
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/rizome-dev/go-keywordsai/internal/paging"
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+type Service struct {
+	client *client.Client
+}
+
+func NewService(client *client.Client) *Service {
+	return &Service{client: client}
+}
+
+// List returns one page of threads matching filter.
+func (s *Service) List(ctx context.Context, filter *types.ThreadFilter) (*types.ThreadsResponse, error) {
+	var result types.ThreadsResponse
+	return &result, s.client.GetWithQuery(ctx, "/api/threads", filter, &result)
+}
+
+// ListAll pages through every thread matching filter.
+func (s *Service) ListAll(ctx context.Context, filter *types.ThreadFilter) ([]types.Thread, error) {
+	f := types.ThreadFilter{}
+	if filter != nil {
+		f = *filter
+	}
+	if f.Limit == nil {
+		limit := paging.DefaultPageSize
+		f.Limit = &limit
+	}
+	offset := 0
+	if f.Offset != nil {
+		offset = *f.Offset
+	}
+
+	var all []types.Thread
+	for {
+		f.Offset = &offset
+		page, err := s.List(ctx, &f)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page.Threads...)
+		next, ok := paging.Next(offset, len(page.Threads), page.NextOffset, page.TotalCount)
+		if !ok {
+			return all, nil
+		}
+		offset = next
+	}
+}
+
+func (s *Service) Get(ctx context.Context, threadID string) (*types.Thread, error) {
+	var result types.Thread
+	path := fmt.Sprintf("/api/threads/%s", url.PathEscape(threadID))
+	return &result, s.client.Get(ctx, path, &result)
+}
+
+// ListMessages returns one page of a thread's messages, oldest first.
+func (s *Service) ListMessages(ctx context.Context, threadID string, params *types.PageParams) (*types.ThreadMessagesResponse, error) {
+	var result types.ThreadMessagesResponse
+	path := fmt.Sprintf("/api/threads/%s/messages", url.PathEscape(threadID))
+	return &result, s.client.GetWithQuery(ctx, path, params, &result)
+}
+
+// History pages through a thread's messages and returns the full conversation.
+func (s *Service) History(ctx context.Context, threadID string) ([]types.Message, error) {
+	limit := paging.DefaultPageSize
+	offset := 0
+
+	var all []types.Message
+	for {
+		page, err := s.ListMessages(ctx, threadID, &types.PageParams{Limit: &limit, Offset: &offset})
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page.Messages...)
+		next, ok := paging.Next(offset, len(page.Messages), page.NextOffset, page.TotalCount)
+		if !ok {
+			return all, nil
+		}
+		offset = next
+	}
+}
+
+// ToMessages converts a thread back into a message history that can be sent
+// to a chat model. Entries without a role, which cannot be replayed, are dropped.
+func ToMessages(thread *types.Thread) []types.Message {
+	if thread == nil {
+		return nil
+	}
+	history := make([]types.Message, 0, len(thread.Messages))
+	for _, msg := range thread.Messages {
+		if msg.Role == "" {
+			continue
+		}
+		history = append(history, msg)
+	}
+	return history
+}
@@ -0,0 +1,181 @@
+package threads
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+	"github.com/rizome-dev/go-keywordsai/pkg/utils"
+)
+
+func TestList(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/threads" {
+			t.Errorf("Expected path /api/threads, got %s", r.URL.Path)
+		}
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET method, got %s", r.Method)
+		}
+
+		q := r.URL.Query()
+		if q.Get("customer_identifier") != "customer-123" {
+			t.Errorf("Expected customer_identifier=customer-123, got %s", q.Get("customer_identifier"))
+		}
+		if q.Get("start_time") != start.Format(time.RFC3339) {
+			t.Errorf("Expected start_time %s, got %s", start.Format(time.RFC3339), q.Get("start_time"))
+		}
+		if q.Get("metadata") != `{"plan":"pro"}` {
+			t.Errorf("Expected JSON metadata filter, got %s", q.Get("metadata"))
+		}
+		if q.Get("limit") != "10" {
+			t.Errorf("Expected limit=10, got %s", q.Get("limit"))
+		}
+
+		json.NewEncoder(w).Encode(types.ThreadsResponse{
+			Threads:    []types.Thread{{ID: "thread-1", CustomerIdentifier: "customer-123"}},
+			TotalCount: 1,
+		})
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	s := NewService(c)
+
+	result, err := s.List(context.Background(), &types.ThreadFilter{
+		CustomerIdentifier: utils.String("customer-123"),
+		StartTime:          &start,
+		Metadata:           map[string]string{"plan": "pro"},
+		Limit:              utils.Int(10),
+	})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(result.Threads) != 1 || result.Threads[0].ID != "thread-1" {
+		t.Errorf("unexpected threads %+v", result.Threads)
+	}
+}
+
+func TestListAll(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if r.URL.Query().Get("limit") != "2" {
+			t.Errorf("Expected limit=2, got %s", r.URL.Query().Get("limit"))
+		}
+
+		all := []types.Thread{{ID: "t1"}, {ID: "t2"}, {ID: "t3"}}
+		end := min(offset+2, len(all))
+		json.NewEncoder(w).Encode(types.ThreadsResponse{Threads: all[offset:end], TotalCount: len(all)})
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	s := NewService(c)
+
+	threads, err := s.ListAll(context.Background(), &types.ThreadFilter{Limit: utils.Int(2)})
+	if err != nil {
+		t.Fatalf("ListAll() error = %v", err)
+	}
+	if len(threads) != 3 || threads[2].ID != "t3" {
+		t.Errorf("unexpected threads %+v", threads)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func TestGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/threads/thread-1" {
+			t.Errorf("Expected path /api/threads/thread-1, got %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(types.Thread{ID: "thread-1", CustomerIdentifier: "customer-123"})
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	s := NewService(c)
+
+	thread, err := s.Get(context.Background(), "thread-1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if thread.CustomerIdentifier != "customer-123" {
+		t.Errorf("Expected customer-123, got %s", thread.CustomerIdentifier)
+	}
+}
+
+func TestGetEscapesID(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	s := NewService(client.New("test-key", client.WithBaseURL(server.URL)))
+	s.Get(context.Background(), "a/b?c#d")
+	s.ListMessages(context.Background(), "a/b", nil)
+	want := []string{"/api/threads/a%2Fb%3Fc%23d", "/api/threads/a%2Fb/messages"}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("expected escaped paths %v, got %v", want, paths)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/threads/thread-1/messages" {
+			t.Errorf("Expected path /api/threads/thread-1/messages, got %s", r.URL.Path)
+		}
+		resp := types.ThreadMessagesResponse{TotalCount: 3}
+		if r.URL.Query().Get("offset") == "0" {
+			resp.Messages = []types.Message{{Role: "user", Content: "Hi"}, {Role: "assistant", Content: "Hello"}}
+			resp.NextOffset = utils.Int(2)
+		} else {
+			resp.Messages = []types.Message{{Role: "user", Content: "Bye"}}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	c := client.New("test-key", client.WithBaseURL(server.URL))
+	s := NewService(c)
+
+	history, err := s.History(context.Background(), "thread-1")
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(history) != 3 || history[2].Content != "Bye" {
+		t.Errorf("unexpected history %+v", history)
+	}
+}
+
+func TestToMessages(t *testing.T) {
+	thread := &types.Thread{
+		Messages: []types.Message{
+			{Role: "system", Content: "Be helpful"},
+			{Role: "", Content: "orphan"},
+			{Role: "user", Content: "Hi"},
+		},
+	}
+
+	history := ToMessages(thread)
+	if len(history) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(history))
+	}
+	if history[0].Role != "system" || history[1].Content != "Hi" {
+		t.Errorf("unexpected history %+v", history)
+	}
+	if ToMessages(nil) != nil {
+		t.Error("expected nil history for nil thread")
+	}
+}
@@ -0,0 +1,271 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// Kind classifies a span. The values match KeywordsAI's log_type.
+type Kind string
+
+const (
+	KindWorkflow Kind = "workflow"
+	KindAgent    Kind = "agent"
+	KindTask     Kind = "task"
+	KindTool     Kind = "tool"
+	KindLLM      Kind = "generation"
+)
+
+// Span is a timed unit of work within a trace. Spans are created with
+// StartSpan and must be finished with End.
+type Span struct {
+	tracer   *Tracer
+	traceID  string
+	spanID   string
+	parentID string
+	name     string
+	workflow string
+	kind     Kind
+	start    time.Time
+
+	mu       sync.Mutex
+	end      time.Time
+	ended    bool
+	input    interface{}
+	output   interface{}
+	model    string
+	metadata map[string]interface{}
+	err      error
+}
+
+// SpanOption configures a span at start.
+type SpanOption func(*Span)
+
+// WithKind sets the span kind. The default is KindWorkflow for root spans and
+// KindTask for child spans.
+func WithKind(kind Kind) SpanOption {
+	return func(s *Span) {
+		s.kind = kind
+	}
+}
+
+// WithWorkflowName names the workflow the trace belongs to. Child spans
+// inherit it; a root span without one uses its own name.
+func WithWorkflowName(name string) SpanOption {
+	return func(s *Span) {
+		s.workflow = name
+	}
+}
+
+// WithInput records the span's input.
+func WithInput(input interface{}) SpanOption {
+	return func(s *Span) {
+		s.input = input
+	}
+}
+
+// WithTracer submits the span's trace through t instead of the tracer found in
+// the parent span or the default tracer.
+func WithTracer(t *Tracer) SpanOption {
+	return func(s *Span) {
+		s.tracer = t
+	}
+}
+
+type spanContextKey struct{}
+
+// StartSpan starts a span named name as a child of the span in ctx, or as the
+// root of a new trace if there is none. The returned context carries the new
+// span, so logs created with it are annotated with its trace and span IDs.
+func StartSpan(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	parent := SpanFromContext(ctx)
+	s := &Span{
+		name:  name,
+		start: time.Now(),
+	}
+	if parent != nil {
+		s.tracer = parent.tracer
+		s.traceID = parent.traceID
+		s.parentID = parent.spanID
+		s.workflow = parent.workflow
+		s.kind = KindTask
+	} else {
+		s.tracer = DefaultTracer()
+		s.traceID = newID(16)
+		s.kind = KindWorkflow
+	}
+	s.spanID = newID(8)
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.workflow == "" && parent == nil {
+		s.workflow = name
+	}
+	if s.tracer != nil {
+		s.tracer.started(s)
+	}
+	return context.WithValue(ctx, spanContextKey{}, s), s
+}
+
+// SpanFromContext returns the span carried by ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanContextKey{}).(*Span)
+	return s
+}
+
+// TraceID returns the ID shared by every span in the trace.
+func (s *Span) TraceID() string { return s.traceID }
+
+// SpanID returns the span's own ID.
+func (s *Span) SpanID() string { return s.spanID }
+
+// ParentID returns the parent span's ID, or "" for a root span.
+func (s *Span) ParentID() string { return s.parentID }
+
+// Name returns the span name.
+func (s *Span) Name() string { return s.name }
+
+// WorkflowName returns the workflow the span belongs to.
+func (s *Span) WorkflowName() string { return s.workflow }
+
+// SetInput records the span's input.
+func (s *Span) SetInput(input interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.input = input
+}
+
+// SetOutput records the span's output.
+func (s *Span) SetOutput(output interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.output = output
+}
+
+// SetModel records the model used by an LLM span.
+func (s *Span) SetModel(model string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.model = model
+}
+
+// SetAttribute adds a metadata key to the span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.metadata == nil {
+		s.metadata = make(map[string]interface{})
+	}
+	s.metadata[key] = value
+}
+
+// RecordError marks the span as failed.
+func (s *Span) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End finishes the span. Calls after the first are ignored.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if s.tracer != nil {
+		s.tracer.ended(s)
+	}
+}
+
+// Duration returns how long the span ran, or how long it has been running if
+// it has not ended.
+func (s *Span) Duration() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return s.end.Sub(s.start)
+	}
+	return time.Since(s.start)
+}
+
+// toTraceSpan converts an ended span to its ingestion form. Metadata is
+// copied so later SetAttribute calls do not change buffered spans.
+func (s *Span) toTraceSpan() types.TraceSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts := types.TraceSpan{
+		TraceUniqueID:    s.traceID,
+		SpanUniqueID:     s.spanID,
+		SpanName:         s.name,
+		SpanWorkflowName: s.workflow,
+		LogType:          string(s.kind),
+		StartTime:        s.start,
+		Timestamp:        s.end,
+		Input:            s.input,
+		Output:           s.output,
+		Model:            s.model,
+		Metadata:         maps.Clone(s.metadata),
+	}
+	if s.parentID != "" {
+		parent := s.parentID
+		ts.SpanParentID = &parent
+	}
+	if s.err != nil {
+		msg := s.err.Error()
+		ts.Error = &msg
+		ts.StatusCode = 500
+	}
+	return ts
+}
+
+// Annotate stamps log with the trace of the span in ctx. The log becomes a
+// child of that span with its own span ID. Fields already set on the log are
+// kept. It does nothing if ctx carries no span.
+func Annotate(ctx context.Context, log *types.RequestLog) {
+	s := SpanFromContext(ctx)
+	if s == nil || log == nil {
+		return
+	}
+	if log.TraceUniqueID == nil {
+		traceID := s.traceID
+		log.TraceUniqueID = &traceID
+	}
+	if log.SpanParentID == nil {
+		parentID := s.spanID
+		log.SpanParentID = &parentID
+	}
+	if log.SpanUniqueID == nil {
+		spanID := newID(8)
+		log.SpanUniqueID = &spanID
+	}
+	if log.SpanName == nil {
+		name := s.name
+		if log.Model != "" {
+			name = log.Model
+		}
+		log.SpanName = &name
+	}
+	if log.SpanWorkflowName == nil && s.workflow != "" {
+		workflow := s.workflow
+		log.SpanWorkflowName = &workflow
+	}
+}
+
+func newID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
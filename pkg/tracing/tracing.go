@@ -0,0 +1,209 @@
+// Package tracing groups request logs from multi-step agent runs into traces.
+//
+// StartSpan propagates trace and span IDs through a context.Context. Logs
+// created with logs.Service under that context are annotated with the trace,
+// and when a Tracer is configured the completed spans themselves are submitted
+// to KeywordsAI's tracing ingestion endpoint.
+//
+//	ctx, run := tracing.StartSpan(ctx, "support-agent")
+//	defer run.End()
+//
+//	ctx, tool := tracing.StartSpan(ctx, "search-kb", tracing.WithKind(tracing.KindTool))
+//	tool.SetOutput(results)
+//	tool.End()
+package tracing
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+const (
+	ingestPath      = "/api/v1/traces/ingest"
+	defaultTraceTTL = time.Hour
+)
+
+// Tracer collects ended spans and submits each trace once its root span ends.
+// Spans that end after their root are submitted on their own. A trace whose
+// root span has not ended within the trace TTL is submitted with the spans
+// buffered so far and no longer held; expiry is checked whenever a span
+// starts or ends.
+type Tracer struct {
+	client  *client.Client
+	onError func(error)
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	traces    map[string]*traceBuffer
+	lastSweep time.Time
+	wg        sync.WaitGroup
+}
+
+type traceBuffer struct {
+	started time.Time
+	spans   []types.TraceSpan
+}
+
+// Option configures a Tracer.
+type Option func(*Tracer)
+
+// WithErrorHandler sets a callback for errors from background trace submission.
+func WithErrorHandler(fn func(error)) Option {
+	return func(t *Tracer) {
+		t.onError = fn
+	}
+}
+
+// WithTraceTTL sets how long a trace is buffered waiting for its root span to
+// end. Default 1h.
+func WithTraceTTL(ttl time.Duration) Option {
+	return func(t *Tracer) {
+		if ttl > 0 {
+			t.ttl = ttl
+		}
+	}
+}
+
+// NewTracer creates a Tracer that submits traces through c.
+func NewTracer(c *client.Client, opts ...Option) *Tracer {
+	t := &Tracer{
+		client: c,
+		ttl:    defaultTraceTTL,
+		now:    time.Now,
+		traces: make(map[string]*traceBuffer),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+var defaultTracer atomic.Pointer[Tracer]
+
+// SetDefaultTracer sets the tracer used by root spans started without WithTracer.
+func SetDefaultTracer(t *Tracer) {
+	defaultTracer.Store(t)
+}
+
+// DefaultTracer returns the tracer set with SetDefaultTracer, or nil. Without a
+// tracer, spans still annotate logs but are not submitted themselves.
+func DefaultTracer() *Tracer {
+	return defaultTracer.Load()
+}
+
+// StartSpan starts a span that is submitted through t.
+func (t *Tracer) StartSpan(ctx context.Context, name string, opts ...SpanOption) (context.Context, *Span) {
+	return StartSpan(ctx, name, append([]SpanOption{WithTracer(t)}, opts...)...)
+}
+
+// Submit sends spans to the tracing ingestion endpoint.
+func (t *Tracer) Submit(ctx context.Context, spans []types.TraceSpan) error {
+	return t.client.Post(ctx, ingestPath, spans, nil)
+}
+
+// Shutdown submits the spans of traces whose root span has not ended yet,
+// then waits for in-flight trace submissions to finish or ctx to expire.
+// Spans of those traces that end later are submitted on their own.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	var pending [][]types.TraceSpan
+	for id, buf := range t.traces {
+		delete(t.traces, id)
+		if len(buf.spans) > 0 {
+			pending = append(pending, buf.spans)
+		}
+	}
+	t.mu.Unlock()
+	for _, spans := range pending {
+		t.submitAsync(spans)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Tracer) started(s *Span) {
+	if s.parentID != "" {
+		return
+	}
+	now := t.now()
+	t.mu.Lock()
+	expired := t.sweep(now)
+	t.traces[s.traceID] = &traceBuffer{started: now}
+	t.mu.Unlock()
+
+	for _, spans := range expired {
+		t.submitAsync(spans)
+	}
+}
+
+// sweep removes traces older than the TTL and returns their buffered spans.
+// It scans at most once per tenth of the TTL. t.mu must be held.
+func (t *Tracer) sweep(now time.Time) [][]types.TraceSpan {
+	if now.Sub(t.lastSweep) < t.ttl/10 {
+		return nil
+	}
+	t.lastSweep = now
+	var expired [][]types.TraceSpan
+	for id, buf := range t.traces {
+		if now.Sub(buf.started) < t.ttl {
+			continue
+		}
+		delete(t.traces, id)
+		if len(buf.spans) > 0 {
+			expired = append(expired, buf.spans)
+		}
+	}
+	return expired
+}
+
+func (t *Tracer) ended(s *Span) {
+	ts := s.toTraceSpan()
+
+	t.mu.Lock()
+	expired := t.sweep(t.now())
+	var submit []types.TraceSpan
+	buf, ok := t.traces[s.traceID]
+	switch {
+	case !ok:
+		submit = []types.TraceSpan{ts}
+	case s.parentID == "":
+		delete(t.traces, s.traceID)
+		submit = append(buf.spans, ts)
+	default:
+		buf.spans = append(buf.spans, ts)
+	}
+	t.mu.Unlock()
+
+	for _, spans := range expired {
+		t.submitAsync(spans)
+	}
+	if submit != nil {
+		t.submitAsync(submit)
+	}
+}
+
+func (t *Tracer) submitAsync(spans []types.TraceSpan) {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		if err := t.Submit(context.Background(), spans); err != nil && t.onError != nil {
+			t.onError(err)
+		}
+	}()
+}
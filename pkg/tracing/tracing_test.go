@@ -0,0 +1,251 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+func TestStartSpanPropagatesIDs(t *testing.T) {
+	ctx, root := StartSpan(context.Background(), "agent-run")
+	childCtx, child := StartSpan(ctx, "lookup", WithKind(KindTool))
+	_, grandchild := StartSpan(childCtx, "llm", WithKind(KindLLM))
+
+	if root.ParentID() != "" {
+		t.Errorf("root ParentID = %q, want empty", root.ParentID())
+	}
+	if child.TraceID() != root.TraceID() || grandchild.TraceID() != root.TraceID() {
+		t.Error("expected all spans to share the root trace ID")
+	}
+	if child.ParentID() != root.SpanID() {
+		t.Errorf("child ParentID = %q, want %q", child.ParentID(), root.SpanID())
+	}
+	if grandchild.ParentID() != child.SpanID() {
+		t.Errorf("grandchild ParentID = %q, want %q", grandchild.ParentID(), child.SpanID())
+	}
+	if grandchild.WorkflowName() != "agent-run" {
+		t.Errorf("WorkflowName = %q, want inherited agent-run", grandchild.WorkflowName())
+	}
+	if SpanFromContext(childCtx) != child {
+		t.Error("SpanFromContext did not return the child span")
+	}
+	if len(root.TraceID()) != 32 || len(root.SpanID()) != 16 {
+		t.Errorf("unexpected ID lengths %q %q", root.TraceID(), root.SpanID())
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	log := &types.RequestLog{Model: "gpt-4"}
+	Annotate(context.Background(), log)
+	if log.TraceUniqueID != nil {
+		t.Fatal("expected log outside a span to be left alone")
+	}
+
+	ctx, span := StartSpan(context.Background(), "workflow", WithWorkflowName("checkout"))
+	Annotate(ctx, log)
+
+	if log.TraceUniqueID == nil || *log.TraceUniqueID != span.TraceID() {
+		t.Errorf("TraceUniqueID = %v, want %s", log.TraceUniqueID, span.TraceID())
+	}
+	if log.SpanParentID == nil || *log.SpanParentID != span.SpanID() {
+		t.Errorf("SpanParentID = %v, want %s", log.SpanParentID, span.SpanID())
+	}
+	if log.SpanUniqueID == nil || *log.SpanUniqueID == span.SpanID() {
+		t.Errorf("expected log to get its own span ID, got %v", log.SpanUniqueID)
+	}
+	if log.SpanName == nil || *log.SpanName != "gpt-4" {
+		t.Errorf("SpanName = %v, want gpt-4", log.SpanName)
+	}
+	if log.SpanWorkflowName == nil || *log.SpanWorkflowName != "checkout" {
+		t.Errorf("SpanWorkflowName = %v, want checkout", log.SpanWorkflowName)
+	}
+}
+
+func TestTracerSubmitsTraceWhenRootEnds(t *testing.T) {
+	var mu sync.Mutex
+	var submissions [][]types.TraceSpan
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/traces/ingest" {
+			t.Errorf("Expected path /api/v1/traces/ingest, got %s", r.URL.Path)
+		}
+		var spans []types.TraceSpan
+		if err := json.NewDecoder(r.Body).Decode(&spans); err != nil {
+			t.Errorf("Failed to decode request body: %v", err)
+		}
+		mu.Lock()
+		submissions = append(submissions, spans)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(client.New("test-key", client.WithBaseURL(server.URL)))
+
+	ctx, root := tracer.StartSpan(context.Background(), "agent-run", WithInput("question"))
+	_, tool := StartSpan(ctx, "search", WithKind(KindTool))
+	time.Sleep(5 * time.Millisecond)
+	tool.RecordError(errors.New("timeout"))
+	tool.End()
+
+	mu.Lock()
+	if len(submissions) != 0 {
+		t.Fatal("trace submitted before the root span ended")
+	}
+	mu.Unlock()
+
+	root.SetOutput("answer")
+	root.End()
+	root.End()
+
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if len(submissions) != 1 {
+		t.Fatalf("expected 1 submission, got %d", len(submissions))
+	}
+	spans := submissions[0]
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	toolSpan, rootSpan := spans[0], spans[1]
+	if toolSpan.LogType != "tool" || toolSpan.SpanParentID == nil || *toolSpan.SpanParentID != root.SpanID() {
+		t.Errorf("unexpected tool span %+v", toolSpan)
+	}
+	if toolSpan.Error == nil || *toolSpan.Error != "timeout" {
+		t.Errorf("expected tool span error, got %v", toolSpan.Error)
+	}
+	if !toolSpan.Timestamp.After(toolSpan.StartTime) {
+		t.Errorf("expected tool span to have a duration")
+	}
+	if rootSpan.LogType != "workflow" || rootSpan.SpanParentID != nil {
+		t.Errorf("unexpected root span %+v", rootSpan)
+	}
+	if rootSpan.Input != "question" || rootSpan.Output != "answer" {
+		t.Errorf("unexpected root input/output %v/%v", rootSpan.Input, rootSpan.Output)
+	}
+}
+
+func TestTracerErrorHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var got error
+	tracer := NewTracer(client.New("test-key", client.WithBaseURL(server.URL)),
+		WithErrorHandler(func(err error) { got = err }))
+
+	_, span := tracer.StartSpan(context.Background(), "run")
+	span.End()
+	tracer.Shutdown(context.Background())
+
+	var apiErr *client.APIError
+	if !errors.As(got, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected APIError 500, got %v", got)
+	}
+}
+
+func TestTracerExpiresUnendedTraces(t *testing.T) {
+	var mu sync.Mutex
+	var submissions [][]types.TraceSpan
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var spans []types.TraceSpan
+		json.NewDecoder(r.Body).Decode(&spans)
+		mu.Lock()
+		submissions = append(submissions, spans)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(client.New("test-key", client.WithBaseURL(server.URL)), WithTraceTTL(time.Minute))
+	now := time.Now()
+	tracer.now = func() time.Time { return now }
+
+	ctx, abandoned := tracer.StartSpan(context.Background(), "abandoned")
+	_, child := StartSpan(ctx, "step")
+	child.SetAttribute("attempt", 1)
+	child.End()
+	child.SetAttribute("attempt", 2)
+
+	now = now.Add(time.Minute)
+	_, next := tracer.StartSpan(context.Background(), "next")
+	tracer.Shutdown(context.Background())
+
+	tracer.mu.Lock()
+	_, held := tracer.traces[abandoned.TraceID()]
+	tracer.mu.Unlock()
+	if held || len(submissions) != 1 || len(submissions[0]) != 1 {
+		t.Fatalf("expected the expired trace's ended spans to be submitted, got %v", submissions)
+	}
+	if got := submissions[0][0].Metadata["attempt"]; got != float64(1) {
+		t.Errorf("expected metadata as of End, got %v", got)
+	}
+
+	// The root ending late is submitted on its own.
+	abandoned.End()
+	next.End()
+	tracer.Shutdown(context.Background())
+	if len(submissions) != 3 {
+		t.Errorf("expected 3 submissions, got %d", len(submissions))
+	}
+}
+
+func TestTracerExpiresOnEndAndFlushesOnShutdown(t *testing.T) {
+	var mu sync.Mutex
+	var submissions [][]types.TraceSpan
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var spans []types.TraceSpan
+		json.NewDecoder(r.Body).Decode(&spans)
+		mu.Lock()
+		submissions = append(submissions, spans)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(client.New("test-key", client.WithBaseURL(server.URL)), WithTraceTTL(time.Minute))
+	now := time.Now()
+	tracer.now = func() time.Time { return now }
+
+	// No new root starts, but a span ending past the TTL expires the trace.
+	ctx, abandoned := tracer.StartSpan(context.Background(), "abandoned")
+	_, first := StartSpan(ctx, "first")
+	first.End()
+	now = now.Add(time.Minute)
+	_, second := StartSpan(ctx, "second")
+	second.End()
+	tracer.mu.Lock()
+	_, held := tracer.traces[abandoned.TraceID()]
+	tracer.mu.Unlock()
+	if held {
+		t.Error("expected the expired trace to be released when a span ends")
+	}
+
+	// Shutdown submits traces whose root is still running.
+	ctx, running := tracer.StartSpan(context.Background(), "running")
+	_, step := StartSpan(ctx, "step")
+	step.End()
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var flushed bool
+	for _, spans := range submissions {
+		if len(spans) == 1 && spans[0].SpanName == "step" {
+			flushed = true
+		}
+	}
+	if !flushed || tracer.traces[running.TraceID()] != nil {
+		t.Errorf("expected the running trace to be flushed on Shutdown, got %v", submissions)
+	}
+}
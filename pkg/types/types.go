@@ -54,22 +54,79 @@ type RequestLog struct {
 	Stream                *bool                  `json:"stream,omitempty"`
 	Category              *string                `json:"category,omitempty"`
 	Tags                  []string               `json:"tags,omitempty"`
+	ThreadIdentifier      *string                `json:"thread_identifier,omitempty"`
+	TraceUniqueID         *string                `json:"trace_unique_id,omitempty"`
+	SpanUniqueID          *string                `json:"span_unique_id,omitempty"`
+	SpanParentID          *string                `json:"span_parent_id,omitempty"`
+	SpanName              *string                `json:"span_name,omitempty"`
+	SpanWorkflowName      *string                `json:"span_workflow_name,omitempty"`
+	IdempotencyKey        *string                `json:"idempotency_key,omitempty"`
 }
 
 type BatchRequestLogsPayload struct {
 	Logs []RequestLog `json:"logs"`
 }
 
+type TraceSpan struct {
+	TraceUniqueID    string                 `json:"trace_unique_id"`
+	SpanUniqueID     string                 `json:"span_unique_id"`
+	SpanParentID     *string                `json:"span_parent_id,omitempty"`
+	SpanName         string                 `json:"span_name"`
+	SpanWorkflowName string                 `json:"span_workflow_name,omitempty"`
+	LogType          string                 `json:"log_type"`
+	StartTime        time.Time              `json:"start_time"`
+	Timestamp        time.Time              `json:"timestamp"`
+	Input            interface{}            `json:"input,omitempty"`
+	Output           interface{}            `json:"output,omitempty"`
+	Model            string                 `json:"model,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+	StatusCode       int                    `json:"status_code,omitempty"`
+	Error            *string                `json:"error_message,omitempty"`
+}
+
 type LogFilter struct {
-	Model              *string    `json:"model,omitempty"`
-	Failed             *bool      `json:"failed,omitempty"`
-	Category           *string    `json:"category,omitempty"`
-	CustomerIdentifier *string    `json:"customer_identifier,omitempty"`
-	StartTime          *time.Time `json:"start_time,omitempty"`
-	EndTime            *time.Time `json:"end_time,omitempty"`
-	Tags               []string   `json:"tags,omitempty"`
-	Limit              *int       `json:"limit,omitempty"`
-	Offset             *int       `json:"offset,omitempty"`
+	Model              *string    `json:"model,omitempty" url:"model,omitempty"`
+	Failed             *bool      `json:"failed,omitempty" url:"failed,omitempty"`
+	Category           *string    `json:"category,omitempty" url:"category,omitempty"`
+	CustomerIdentifier *string    `json:"customer_identifier,omitempty" url:"customer_identifier,omitempty"`
+	StartTime          *time.Time `json:"start_time,omitempty" url:"start_time,omitempty"`
+	EndTime            *time.Time `json:"end_time,omitempty" url:"end_time,omitempty"`
+	Tags               []string   `json:"tags,omitempty" url:"tags,omitempty"`
+	Limit              *int       `json:"limit,omitempty" url:"limit,omitempty"`
+	Offset             *int       `json:"offset,omitempty" url:"offset,omitempty"`
+}
+
+type AnalyticsQuery struct {
+	Filter   *LogFilter `json:"filters,omitempty"`
+	Metrics  []string   `json:"metrics"`
+	GroupBy  []string   `json:"group_by,omitempty"`
+	Interval *string    `json:"interval,omitempty"`
+}
+
+type AnalyticsResponse struct {
+	Interval string            `json:"interval,omitempty"`
+	Series   []AnalyticsSeries `json:"series"`
+}
+
+type AnalyticsSeries struct {
+	Group  map[string]string `json:"group,omitempty"`
+	Points []AnalyticsPoint  `json:"points"`
+}
+
+type AnalyticsPoint struct {
+	Timestamp        time.Time `json:"timestamp"`
+	Requests         int64     `json:"requests"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	Cost             float64   `json:"cost"`
+	LatencyAvg       float64   `json:"latency_avg"`
+	LatencyP50       float64   `json:"latency_p50"`
+	LatencyP90       float64   `json:"latency_p90"`
+	LatencyP95       float64   `json:"latency_p95"`
+	LatencyP99       float64   `json:"latency_p99"`
+	Errors           int64     `json:"errors"`
+	ErrorRate        float64   `json:"error_rate"`
 }
 
 type LogsResponse struct {
@@ -87,6 +144,32 @@ type Thread struct {
 	UpdatedAt         time.Time              `json:"updated_at"`
 }
 
+type PageParams struct {
+	Limit  *int `json:"limit,omitempty" url:"limit,omitempty"`
+	Offset *int `json:"offset,omitempty" url:"offset,omitempty"`
+}
+
+type ThreadFilter struct {
+	CustomerIdentifier *string           `json:"customer_identifier,omitempty" url:"customer_identifier,omitempty"`
+	StartTime          *time.Time        `json:"start_time,omitempty" url:"start_time,omitempty"`
+	EndTime            *time.Time        `json:"end_time,omitempty" url:"end_time,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty" url:"metadata,omitempty"`
+	Limit              *int              `json:"limit,omitempty" url:"limit,omitempty"`
+	Offset             *int              `json:"offset,omitempty" url:"offset,omitempty"`
+}
+
+type ThreadsResponse struct {
+	Threads    []Thread `json:"threads"`
+	TotalCount int      `json:"total_count"`
+	NextOffset *int     `json:"next_offset,omitempty"`
+}
+
+type ThreadMessagesResponse struct {
+	Messages   []Message `json:"messages"`
+	TotalCount int       `json:"total_count"`
+	NextOffset *int      `json:"next_offset,omitempty"`
+}
+
 type Prompt struct {
 	ID          string    `json:"id"`
 	Name        string    `json:"name"`
@@ -134,6 +217,67 @@ type TemporaryKey struct {
 	Metadata         map[string]interface{} `json:"metadata,omitempty"`
 }
 
+type Customer struct {
+	ID                 string                 `json:"id,omitempty"`
+	CustomerIdentifier string                 `json:"customer_identifier"`
+	Name               *string                `json:"name,omitempty"`
+	Email              *string                `json:"email,omitempty"`
+	PeriodBudget       *float64               `json:"period_budget,omitempty"`
+	BudgetDuration     *string                `json:"budget_duration,omitempty"`
+	TotalBudget        *float64               `json:"total_budget,omitempty"`
+	RateLimit          *int                   `json:"rate_limit,omitempty"`
+	PeriodSpend        float64                `json:"period_spend"`
+	TotalSpend         float64                `json:"total_spend"`
+	Metadata           map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt          time.Time              `json:"created_at"`
+	UpdatedAt          time.Time              `json:"updated_at"`
+}
+
+type CustomerFilter struct {
+	Search     *string           `json:"search,omitempty" url:"search,omitempty"`
+	Email      *string           `json:"email,omitempty" url:"email,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty" url:"metadata,omitempty"`
+	OverBudget *bool             `json:"over_budget,omitempty" url:"over_budget,omitempty"`
+	Limit      *int              `json:"limit,omitempty" url:"limit,omitempty"`
+	Offset     *int              `json:"offset,omitempty" url:"offset,omitempty"`
+}
+
+type CustomersResponse struct {
+	Customers  []Customer `json:"customers"`
+	TotalCount int        `json:"total_count"`
+	NextOffset *int       `json:"next_offset,omitempty"`
+}
+
+type CustomerSummaryParams struct {
+	StartTime   *time.Time `json:"start_time,omitempty" url:"start_time,omitempty"`
+	EndTime     *time.Time `json:"end_time,omitempty" url:"end_time,omitempty"`
+	Granularity *string    `json:"granularity,omitempty" url:"granularity,omitempty"`
+}
+
+type CustomerUsage struct {
+	Cost             float64 `json:"cost"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+}
+
+type CustomerPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	CustomerUsage
+}
+
+type CustomerSummary struct {
+	CustomerIdentifier string                   `json:"customer_identifier"`
+	StartTime          time.Time                `json:"start_time"`
+	EndTime            time.Time                `json:"end_time"`
+	Total              CustomerUsage            `json:"total"`
+	Periods            []CustomerPeriod         `json:"periods,omitempty"`
+	ByModel            map[string]CustomerUsage `json:"by_model,omitempty"`
+	BudgetRemaining    *float64                 `json:"budget_remaining,omitempty"`
+}
+
 type TTSRequest struct {
 	Model          string  `json:"model"`
 	Input          string  `json:"input"`
@@ -148,15 +292,38 @@ type STTRequest struct {
 	Language        *string `json:"language,omitempty"`
 	Temperature     *float64 `json:"temperature,omitempty"`
 	Prompt          *string `json:"prompt,omitempty"`
+	TimestampGranularities []string `json:"timestamp_granularities,omitempty"`
 }
 
 type STTResponse struct {
 	Text     string                 `json:"text"`
+	Task     string                 `json:"task,omitempty"`
 	Language string                 `json:"language,omitempty"`
 	Duration float64                `json:"duration,omitempty"`
+	Segments []STTSegment           `json:"segments,omitempty"`
+	Words    []STTWord              `json:"words,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+type STTSegment struct {
+	ID               int     `json:"id"`
+	Seek             int     `json:"seek,omitempty"`
+	Start            float64 `json:"start"`
+	End              float64 `json:"end"`
+	Text             string  `json:"text"`
+	Tokens           []int   `json:"tokens,omitempty"`
+	Temperature      float64 `json:"temperature,omitempty"`
+	AvgLogprob       float64 `json:"avg_logprob,omitempty"`
+	CompressionRatio float64 `json:"compression_ratio,omitempty"`
+	NoSpeechProb     float64 `json:"no_speech_prob,omitempty"`
+}
+
+type STTWord struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
 type EmbeddingRequest struct {
 	Model          string      `json:"model"`
 	Input          interface{} `json:"input"`
@@ -0,0 +1,55 @@
+package vector
+
+import "fmt"
+
+// Filter restricts search results to entries it returns true for.
+type Filter func(Entry) bool
+
+// Where matches entries whose metadata value for key equals value. Values are
+// compared by their formatted form, so 3 matches both int 3 and float64 3
+// from decoded JSON.
+func Where(key string, value interface{}) Filter {
+	want := fmt.Sprint(value)
+	return func(e Entry) bool {
+		v, ok := e.Metadata[key]
+		return ok && fmt.Sprint(v) == want
+	}
+}
+
+// WhereIn matches entries whose metadata value for key equals any of values.
+func WhereIn(key string, values ...interface{}) Filter {
+	want := make(map[string]bool, len(values))
+	for _, v := range values {
+		want[fmt.Sprint(v)] = true
+	}
+	return func(e Entry) bool {
+		v, ok := e.Metadata[key]
+		return ok && want[fmt.Sprint(v)]
+	}
+}
+
+// Not inverts a filter.
+func Not(f Filter) Filter {
+	return func(e Entry) bool { return !f(e) }
+}
+
+// Any matches entries that pass at least one of filters.
+func Any(filters ...Filter) Filter {
+	return func(e Entry) bool {
+		for _, f := range filters {
+			if f(e) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func matches(e Entry, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(e) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,312 @@
+// Package vector provides a small in-memory vector index for semantic search
+// over embeddings, without running a vector database.
+package vector
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+var (
+	// ErrDimensionMismatch is returned when a vector's length differs from
+	// the vectors already in the index.
+	ErrDimensionMismatch = errors.New("vector dimension mismatch")
+	// ErrNoEmbedder is returned by SearchText when the index has no
+	// QueryEmbedder.
+	ErrNoEmbedder = errors.New("index has no query embedder")
+)
+
+// Metric is how vectors are compared.
+type Metric uint8
+
+const (
+	Cosine Metric = iota
+	Dot
+	Euclidean
+)
+
+func (m Metric) String() string {
+	switch m {
+	case Cosine:
+		return "cosine"
+	case Dot:
+		return "dot"
+	case Euclidean:
+		return "euclidean"
+	}
+	return fmt.Sprintf("Metric(%d)", m)
+}
+
+// Entry is a vector with the document it was created from.
+type Entry struct {
+	ID       string
+	Document string
+	Metadata map[string]interface{}
+	Vector   []float32
+}
+
+// Document is the text and metadata behind an embedding, used with
+// AddEmbeddings.
+type Document struct {
+	ID       string
+	Text     string
+	Metadata map[string]interface{}
+}
+
+// Result is a search hit. Higher scores are more similar: Score is the cosine
+// similarity, the dot product, or the negated Euclidean distance.
+type Result struct {
+	Entry
+	Score float64
+}
+
+// QueryEmbedder embeds search text. *embedder.Embedder implements it.
+type QueryEmbedder interface {
+	EmbedOne(ctx context.Context, text string) ([]float32, error)
+}
+
+// Index is an in-memory vector index searched by brute force, suited to tens
+// of thousands of vectors. It is safe for concurrent use.
+type Index struct {
+	metric   Metric
+	embedder QueryEmbedder
+
+	mu      sync.RWMutex
+	dims    int
+	entries []Entry
+	norms   []float64
+	byID    map[string]int
+}
+
+// Option configures an Index.
+type Option func(*Index)
+
+// WithMetric sets the similarity metric. The default is Cosine.
+func WithMetric(m Metric) Option {
+	return func(x *Index) {
+		x.metric = m
+	}
+}
+
+// WithEmbedder lets SearchText embed queries, typically with an
+// embedder.Embedder for the model the index was built with.
+func WithEmbedder(e QueryEmbedder) Option {
+	return func(x *Index) {
+		x.embedder = e
+	}
+}
+
+// New creates an empty index.
+func New(opts ...Option) *Index {
+	x := &Index{byID: make(map[string]int)}
+	for _, opt := range opts {
+		opt(x)
+	}
+	return x
+}
+
+// Metric returns the index's similarity metric.
+func (x *Index) Metric() Metric { return x.metric }
+
+// Len returns the number of entries.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.entries)
+}
+
+// Dimensions returns the vector length, or 0 for an empty index.
+func (x *Index) Dimensions() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.dims
+}
+
+// Add inserts entries, replacing any with the same ID. All vectors must have
+// the same length.
+func (x *Index) Add(entries ...Entry) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	dims := x.dims
+	for _, e := range entries {
+		if len(e.Vector) == 0 {
+			return fmt.Errorf("entry %q has an empty vector", e.ID)
+		}
+		if dims == 0 {
+			dims = len(e.Vector)
+		}
+		if len(e.Vector) != dims {
+			return fmt.Errorf("%w: entry %q has %d dimensions, index has %d", ErrDimensionMismatch, e.ID, len(e.Vector), dims)
+		}
+	}
+	x.dims = dims
+
+	for _, e := range entries {
+		norm := vectorNorm(e.Vector)
+		if i, ok := x.byID[e.ID]; ok {
+			x.entries[i], x.norms[i] = e, norm
+			continue
+		}
+		x.byID[e.ID] = len(x.entries)
+		x.entries = append(x.entries, e)
+		x.norms = append(x.norms, norm)
+	}
+	return nil
+}
+
+// AddEmbeddings inserts the embeddings from an EmbeddingResponse. Each
+// embedding's Index selects its document in docs.
+func (x *Index) AddEmbeddings(data []types.EmbeddingData, docs []Document) error {
+	entries := make([]Entry, 0, len(data))
+	for _, d := range data {
+		if d.Index < 0 || d.Index >= len(docs) {
+			return fmt.Errorf("embedding index %d has no document", d.Index)
+		}
+		doc := docs[d.Index]
+		vec := make([]float32, len(d.Embedding))
+		for i, v := range d.Embedding {
+			vec[i] = float32(v)
+		}
+		entries = append(entries, Entry{ID: doc.ID, Document: doc.Text, Metadata: doc.Metadata, Vector: vec})
+	}
+	return x.Add(entries...)
+}
+
+// Get returns the entry with id.
+func (x *Index) Get(id string) (Entry, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	i, ok := x.byID[id]
+	if !ok {
+		return Entry{}, false
+	}
+	return x.entries[i], true
+}
+
+// Delete removes the entry with id and reports whether it existed.
+func (x *Index) Delete(id string) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	i, ok := x.byID[id]
+	if !ok {
+		return false
+	}
+	last := len(x.entries) - 1
+	if i != last {
+		x.entries[i], x.norms[i] = x.entries[last], x.norms[last]
+		x.byID[x.entries[i].ID] = i
+	}
+	x.entries, x.norms = x.entries[:last], x.norms[:last]
+	delete(x.byID, id)
+	if len(x.entries) == 0 {
+		x.dims = 0
+	}
+	return true
+}
+
+// Search returns up to k entries most similar to query that pass every
+// filter, best first.
+func (x *Index) Search(query []float32, k int, filters ...Filter) ([]Result, error) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	if k <= 0 || len(x.entries) == 0 {
+		return nil, nil
+	}
+	if len(query) != x.dims {
+		return nil, fmt.Errorf("%w: query has %d dimensions, index has %d", ErrDimensionMismatch, len(query), x.dims)
+	}
+
+	queryNorm := vectorNorm(query)
+	top := make(resultHeap, 0, k)
+	for i, e := range x.entries {
+		if !matches(e, filters) {
+			continue
+		}
+		score := x.score(query, queryNorm, i)
+		if len(top) < k {
+			heap.Push(&top, scored{i, score})
+		} else if score > top[0].score {
+			top[0] = scored{i, score}
+			heap.Fix(&top, 0)
+		}
+	}
+
+	sort.Slice(top, func(a, b int) bool { return top[a].score > top[b].score })
+	results := make([]Result, len(top))
+	for i, s := range top {
+		results[i] = Result{Entry: x.entries[s.index], Score: s.score}
+	}
+	return results, nil
+}
+
+// SearchText embeds text with the index's QueryEmbedder and searches for it.
+func (x *Index) SearchText(ctx context.Context, text string, k int, filters ...Filter) ([]Result, error) {
+	if x.embedder == nil {
+		return nil, ErrNoEmbedder
+	}
+	query, err := x.embedder.EmbedOne(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	return x.Search(query, k, filters...)
+}
+
+func (x *Index) score(query []float32, queryNorm float64, i int) float64 {
+	v := x.entries[i].Vector
+	switch x.metric {
+	case Dot:
+		return dot(query, v)
+	case Euclidean:
+		var sum float64
+		for j := range v {
+			d := float64(query[j]) - float64(v[j])
+			sum += d * d
+		}
+		return -math.Sqrt(sum)
+	default:
+		if queryNorm == 0 || x.norms[i] == 0 {
+			return 0
+		}
+		return dot(query, v) / (queryNorm * x.norms[i])
+	}
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+func vectorNorm(v []float32) float64 {
+	return math.Sqrt(dot(v, v))
+}
+
+type scored struct {
+	index int
+	score float64
+}
+
+// resultHeap is a min-heap of the best scores seen so far.
+type resultHeap []scored
+
+func (h resultHeap) Len() int           { return len(h) }
+func (h resultHeap) Less(i, j int) bool { return h[i].score < h[j].score }
+func (h resultHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *resultHeap) Push(v any)        { *h = append(*h, v.(scored)) }
+func (h *resultHeap) Pop() any {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
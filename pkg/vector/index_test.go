@@ -0,0 +1,152 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+func testIndex(t *testing.T, opts ...Option) *Index {
+	t.Helper()
+	x := New(opts...)
+	err := x.Add(
+		Entry{ID: "east", Document: "east", Vector: []float32{1, 0}, Metadata: map[string]interface{}{"lang": "en", "year": 2023}},
+		Entry{ID: "north", Document: "north", Vector: []float32{0, 1}, Metadata: map[string]interface{}{"lang": "de", "year": 2024}},
+		Entry{ID: "northeast", Document: "northeast", Vector: []float32{2, 2}, Metadata: map[string]interface{}{"lang": "en", "year": 2024}},
+	)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	return x
+}
+
+func ids(results []Result) []string {
+	out := make([]string, len(results))
+	for i, r := range results {
+		out[i] = r.ID
+	}
+	return out
+}
+
+func TestSearchMetrics(t *testing.T) {
+	tests := []struct {
+		metric Metric
+		query  []float32
+		want   []string
+		score  float64
+	}{
+		{Cosine, []float32{1, 0.1}, []string{"east", "northeast"}, 0.995},
+		{Dot, []float32{1, 0.1}, []string{"northeast", "east"}, 2.2},
+		{Euclidean, []float32{0.9, 0.8}, []string{"east", "north"}, -math.Sqrt(0.65)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric.String(), func(t *testing.T) {
+			x := testIndex(t, WithMetric(tt.metric))
+			results, err := x.Search(tt.query, 2)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			got := ids(results)
+			if len(got) != 2 || got[0] != tt.want[0] || got[1] != tt.want[1] {
+				t.Errorf("Search() = %v, want %v", got, tt.want)
+			}
+			if math.Abs(results[0].Score-tt.score) > 1e-3 {
+				t.Errorf("top score = %v, want %v", results[0].Score, tt.score)
+			}
+		})
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	x := testIndex(t)
+
+	results, _ := x.Search([]float32{1, 0}, 10, Where("lang", "en"), Not(Where("year", 2023.0)))
+	if got := ids(results); len(got) != 1 || got[0] != "northeast" {
+		t.Errorf("unexpected filtered results %v", got)
+	}
+
+	results, _ = x.Search([]float32{1, 0}, 10, Any(Where("lang", "de"), WhereIn("year", 2023)))
+	if got := ids(results); len(got) != 2 || got[0] != "east" || got[1] != "north" {
+		t.Errorf("unexpected filtered results %v", got)
+	}
+}
+
+func TestAddReplaceAndDelete(t *testing.T) {
+	x := testIndex(t)
+
+	if err := x.Add(Entry{ID: "east", Vector: []float32{-1, 0}}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if x.Len() != 3 {
+		t.Errorf("expected replace to keep 3 entries, got %d", x.Len())
+	}
+	if e, _ := x.Get("east"); e.Vector[0] != -1 {
+		t.Errorf("expected entry to be replaced, got %+v", e)
+	}
+
+	if !x.Delete("north") || x.Delete("north") {
+		t.Error("expected Delete to remove the entry once")
+	}
+	results, _ := x.Search([]float32{0, 1}, 5)
+	if got := ids(results); len(got) != 2 || got[0] != "northeast" {
+		t.Errorf("unexpected results after delete %v", got)
+	}
+
+	err := x.Add(Entry{ID: "bad", Vector: []float32{1, 2, 3}})
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch, got %v", err)
+	}
+	if _, err := x.Search([]float32{1}, 1); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch for query, got %v", err)
+	}
+}
+
+func TestAddEmbeddings(t *testing.T) {
+	resp := types.EmbeddingResponse{Data: []types.EmbeddingData{
+		{Index: 1, Embedding: []float64{0, 1}},
+		{Index: 0, Embedding: []float64{1, 0}},
+	}}
+	docs := []Document{
+		{ID: "a", Text: "apples", Metadata: map[string]interface{}{"topic": "fruit"}},
+		{ID: "b", Text: "bicycles"},
+	}
+
+	x := New()
+	if err := x.AddEmbeddings(resp.Data, docs); err != nil {
+		t.Fatalf("AddEmbeddings() error = %v", err)
+	}
+	results, _ := x.Search([]float32{0.1, 1}, 1)
+	if len(results) != 1 || results[0].ID != "b" || results[0].Document != "bicycles" {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	if err := x.AddEmbeddings([]types.EmbeddingData{{Index: 5, Embedding: []float64{1, 1}}}, docs); err == nil {
+		t.Error("expected error for embedding without a document")
+	}
+}
+
+type embedFunc func(ctx context.Context, text string) ([]float32, error)
+
+func (f embedFunc) EmbedOne(ctx context.Context, text string) ([]float32, error) { return f(ctx, text) }
+
+func TestSearchText(t *testing.T) {
+	if _, err := New().SearchText(context.Background(), "q", 1); !errors.Is(err, ErrNoEmbedder) {
+		t.Errorf("expected ErrNoEmbedder, got %v", err)
+	}
+
+	var asked string
+	x := testIndex(t, WithEmbedder(embedFunc(func(ctx context.Context, text string) ([]float32, error) {
+		asked = text
+		return []float32{0, 1}, nil
+	})))
+	results, err := x.SearchText(context.Background(), "which way is up?", 1)
+	if err != nil {
+		t.Fatalf("SearchText() error = %v", err)
+	}
+	if asked != "which way is up?" || len(results) != 1 || results[0].ID != "north" {
+		t.Errorf("unexpected results %v for query %q", ids(results), asked)
+	}
+}
@@ -0,0 +1,210 @@
+package vector
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// File layout, all integers little-endian:
+//
+//	magic "KWVX", version byte, metric byte, dims uint32, count uint32
+//	per entry: id, document and metadata JSON as uvarint-length-prefixed
+//	bytes, followed by dims float32 values
+const (
+	fileMagic   = "KWVX"
+	fileVersion = 1
+)
+
+// maxDims bounds the vector dimension read from a file header.
+const maxDims = 1 << 16
+
+// ErrInvalidFile is returned when loading data that is not a saved index.
+var ErrInvalidFile = errors.New("not a vector index file")
+
+// Save writes the index in a compact binary format.
+func (x *Index) Save(w io.Writer) error {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 0, 14)
+	header = append(header, fileMagic...)
+	header = append(header, fileVersion, byte(x.metric))
+	header = binary.LittleEndian.AppendUint32(header, uint32(x.dims))
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(x.entries)))
+	bw.Write(header)
+
+	buf := make([]byte, 0, 4*x.dims)
+	for _, e := range x.entries {
+		var metadata []byte
+		if len(e.Metadata) > 0 {
+			var err error
+			if metadata, err = json.Marshal(e.Metadata); err != nil {
+				return fmt.Errorf("failed to encode metadata for %q: %w", e.ID, err)
+			}
+		}
+		writeBytes(bw, []byte(e.ID))
+		writeBytes(bw, []byte(e.Document))
+		writeBytes(bw, metadata)
+
+		buf = buf[:0]
+		for _, v := range e.Vector {
+			buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+		}
+		bw.Write(buf)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// SaveFile writes the index to path, replacing it atomically.
+func (x *Index) SaveFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create index file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := x.Save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads an index written by Save. The saved metric is used; opts can
+// attach a QueryEmbedder.
+func Load(r io.Reader, opts ...Option) (*Index, error) {
+	size, sized := remaining(r)
+	br := bufio.NewReader(r)
+	header := make([]byte, 14)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if string(header[:4]) != fileMagic {
+		return nil, ErrInvalidFile
+	}
+	if header[4] != fileVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFile, header[4])
+	}
+
+	metric := Metric(header[5])
+	if metric > Euclidean {
+		return nil, fmt.Errorf("%w: unknown metric %d", ErrInvalidFile, header[5])
+	}
+	dims := int(binary.LittleEndian.Uint32(header[6:]))
+	count := int(binary.LittleEndian.Uint32(header[10:]))
+	if dims > maxDims || (dims == 0 && count > 0) {
+		return nil, fmt.Errorf("%w: invalid dimension %d", ErrInvalidFile, dims)
+	}
+	// Each entry has three length prefixes and its vector.
+	if sized && int64(count)*int64(3+4*dims) > size-int64(len(header)) {
+		return nil, fmt.Errorf("%w: %d entries of %d dimensions exceed the file size", ErrInvalidFile, count, dims)
+	}
+
+	x := New(opts...)
+	x.metric = metric
+
+	entries := make([]Entry, 0, min(count, 1<<16))
+	vec := make([]byte, 4*dims)
+	for i := 0; i < count; i++ {
+		var e Entry
+		id, err := readBytes(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entry %d: %w", i, err)
+		}
+		doc, err := readBytes(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entry %d: %w", i, err)
+		}
+		metadata, err := readBytes(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entry %d: %w", i, err)
+		}
+		e.ID, e.Document = string(id), string(doc)
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &e.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to decode metadata for %q: %w", e.ID, err)
+			}
+		}
+
+		if _, err := io.ReadFull(br, vec); err != nil {
+			return nil, fmt.Errorf("failed to read vector for %q: %w", e.ID, err)
+		}
+		e.Vector = make([]float32, dims)
+		for j := range e.Vector {
+			e.Vector[j] = math.Float32frombits(binary.LittleEndian.Uint32(vec[j*4:]))
+		}
+		entries = append(entries, e)
+	}
+
+	if err := x.Add(entries...); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// remaining returns how many bytes r has left, if it can tell.
+func remaining(r io.Reader) (int64, bool) {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len()), true
+	case io.Seeker:
+		cur, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, false
+		}
+		if _, err := r.Seek(cur, io.SeekStart); err != nil {
+			return 0, false
+		}
+		return end - cur, true
+	}
+	return 0, false
+}
+
+// LoadFile reads an index saved with SaveFile.
+func LoadFile(path string, opts ...Option) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f, opts...)
+}
+
+func writeBytes(w *bufio.Writer, b []byte) {
+	w.Write(binary.AppendUvarint(nil, uint64(len(b))))
+	w.Write(b)
+}
+
+// maxFieldLen bounds string fields so a corrupt length cannot exhaust memory.
+const maxFieldLen = 64 << 20
+
+func readBytes(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxFieldLen {
+		return nil, fmt.Errorf("%w: field length %d", ErrInvalidFile, n)
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	return b, err
+}
@@ -0,0 +1,79 @@
+package vector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	x := testIndex(t, WithMetric(Dot))
+	path := filepath.Join(t.TempDir(), "index.kwv")
+	if err := x.SaveFile(path); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if loaded.Metric() != Dot || loaded.Len() != 3 || loaded.Dimensions() != 2 {
+		t.Fatalf("unexpected loaded index: metric %v, %d entries, %d dims", loaded.Metric(), loaded.Len(), loaded.Dimensions())
+	}
+	e, ok := loaded.Get("northeast")
+	if !ok || e.Document != "northeast" || e.Vector[1] != 2 || e.Metadata["lang"] != "en" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	want, _ := x.Search([]float32{1, 0.5}, 3)
+	got, _ := loaded.Search([]float32{1, 0.5}, 3)
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].Score != want[i].Score {
+			t.Errorf("result %d = %s %v, want %s %v", i, got[i].ID, got[i].Score, want[i].ID, want[i].Score)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	if _, err := Load(bytes.NewReader([]byte("not an index"))); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("expected ErrInvalidFile, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	testIndex(t).Save(&buf)
+	truncated := buf.Bytes()[:buf.Len()-3]
+	if _, err := Load(bytes.NewReader(truncated)); err == nil {
+		t.Error("expected error for truncated file")
+	}
+}
+
+func TestLoadRejectsCorruptHeader(t *testing.T) {
+	var buf bytes.Buffer
+	testIndex(t).Save(&buf)
+	valid := buf.Bytes()
+
+	corrupt := func(edit func(b []byte)) []byte {
+		b := bytes.Clone(valid)
+		edit(b)
+		return b
+	}
+	tests := map[string][]byte{
+		"unknown metric": corrupt(func(b []byte) { b[5] = 9 }),
+		"huge dims":      corrupt(func(b []byte) { binary.LittleEndian.PutUint32(b[6:], 1<<30) }),
+		"zero dims":      corrupt(func(b []byte) { binary.LittleEndian.PutUint32(b[6:], 0) }),
+		"huge count":     corrupt(func(b []byte) { binary.LittleEndian.PutUint32(b[10:], 1<<31) }),
+	}
+	for name, data := range tests {
+		if _, err := Load(bytes.NewReader(data)); !errors.Is(err, ErrInvalidFile) {
+			t.Errorf("%s: expected ErrInvalidFile, got %v", name, err)
+		}
+	}
+
+	// Without a known size, a huge count fails when the data runs out.
+	if _, err := Load(io.MultiReader(bytes.NewReader(tests["huge count"]))); err == nil {
+		t.Error("expected an error for a count beyond the data")
+	}
+}
@@ -0,0 +1,59 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// Event types sent by KeywordsAI.
+const (
+	EventAlertTriggered      = "alert.triggered"
+	EventAlertResolved       = "alert.resolved"
+	EventEvaluationCompleted = "evaluation.completed"
+	EventLogCreated          = "log.created"
+)
+
+// Event is the envelope of every webhook delivery. Data holds the
+// type-specific payload; see AlertEvent, EvaluationEvent and LogEvent.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Decode unmarshals the event data into v.
+func (e *Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+type AlertEvent struct {
+	AlertID     string                 `json:"alert_id"`
+	Name        string                 `json:"name"`
+	Status      string                 `json:"status"`
+	Severity    string                 `json:"severity,omitempty"`
+	Metric      string                 `json:"metric"`
+	Value       float64                `json:"value"`
+	Threshold   float64                `json:"threshold"`
+	Message     string                 `json:"message,omitempty"`
+	TriggeredAt time.Time              `json:"triggered_at"`
+	ResolvedAt  *time.Time             `json:"resolved_at,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type EvaluationEvent struct {
+	EvaluationID  string                 `json:"evaluation_id"`
+	EvaluatorSlug string                 `json:"evaluator_slug"`
+	LogID         string                 `json:"log_id,omitempty"`
+	Score         *float64               `json:"score,omitempty"`
+	Passed        *bool                  `json:"passed,omitempty"`
+	Explanation   string                 `json:"explanation,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type LogEvent struct {
+	LogID string `json:"log_id"`
+	types.RequestLog
+}
@@ -0,0 +1,90 @@
+package webhooks
+
+import (
+	"sync"
+	"time"
+)
+
+const defaultReplayCapacity = 10000
+
+// ReplayCache remembers deliveries, keyed by a hash of their signed timestamp
+// and body, until they expire.
+type ReplayCache interface {
+	// Add records key until expiresAt. It returns false if key is already
+	// recorded and has not expired.
+	Add(key string, expiresAt time.Time) bool
+	// Remove forgets key.
+	Remove(key string)
+}
+
+// MemoryReplayCache is an in-process ReplayCache holding at most a fixed
+// number of keys. When full, the oldest key is evicted.
+type MemoryReplayCache struct {
+	capacity int
+	now      func() time.Time
+
+	mu      sync.Mutex
+	expires map[string]time.Time
+	order   []string
+}
+
+// NewMemoryReplayCache creates a MemoryReplayCache holding up to capacity
+// keys.
+func NewMemoryReplayCache(capacity int) *MemoryReplayCache {
+	if capacity <= 0 {
+		capacity = defaultReplayCapacity
+	}
+	return &MemoryReplayCache{
+		capacity: capacity,
+		now:      time.Now,
+		expires:  make(map[string]time.Time),
+	}
+}
+
+func (c *MemoryReplayCache) Add(key string, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if exp, ok := c.expires[key]; ok && now.Before(exp) {
+		return false
+	}
+	c.evict(now)
+	if _, ok := c.expires[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.expires[key] = expiresAt
+	return true
+}
+
+func (c *MemoryReplayCache) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Expire the key rather than deleting it so it keeps a single position in
+	// the insertion order.
+	if _, ok := c.expires[key]; ok {
+		c.expires[key] = time.Time{}
+	}
+}
+
+// Len returns the number of keys held.
+func (c *MemoryReplayCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.expires)
+}
+
+// evict drops removed and expired keys from the front of the insertion order,
+// then the oldest keys until there is room for one more.
+func (c *MemoryReplayCache) evict(now time.Time) {
+	for len(c.order) > 0 {
+		key := c.order[0]
+		exp, ok := c.expires[key]
+		if ok && now.Before(exp) && len(c.expires) < c.capacity {
+			break
+		}
+		delete(c.expires, key)
+		c.order[0] = ""
+		c.order = c.order[1:]
+	}
+}
@@ -0,0 +1,44 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryReplayCache(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := NewMemoryReplayCache(2)
+	c.now = func() time.Time { return now }
+
+	if !c.Add("a", now.Add(time.Minute)) {
+		t.Fatal("expected first add to succeed")
+	}
+	if c.Add("a", now.Add(time.Minute)) {
+		t.Fatal("expected duplicate add to fail")
+	}
+
+	// Removed keys can be added again.
+	c.Remove("a")
+	if !c.Add("a", now.Add(time.Minute)) {
+		t.Fatal("expected add after remove to succeed")
+	}
+
+	// Expired keys can be added again.
+	c.Add("b", now.Add(time.Second))
+	now = now.Add(2 * time.Second)
+	if !c.Add("b", now.Add(time.Minute)) {
+		t.Fatal("expected add after expiry to succeed")
+	}
+
+	// At capacity the oldest key is evicted.
+	c.Add("c", now.Add(time.Minute))
+	if c.Len() != 2 {
+		t.Errorf("expected 2 keys, got %d", c.Len())
+	}
+	if !c.Add("a", now.Add(time.Minute)) {
+		t.Error("expected evicted key to be accepted")
+	}
+	if c.Add("c", now.Add(time.Minute)) {
+		t.Error("expected recent key to be retained")
+	}
+}
@@ -0,0 +1,48 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/internal/signing"
+)
+
+// Sign returns a SignatureHeader value for body signed with secret at t. It
+// lets tests and local tools produce deliveries a Handler accepts.
+func Sign(secret string, body []byte, t time.Time) string {
+	unix := t.Unix()
+	sig := signing.SignHMAC([]byte(secret), signedPayload(unix, body))
+	return fmt.Sprintf("t=%d,v1=%s", unix, sig)
+}
+
+// NewEvent builds an event of the given type with data encoded as its
+// payload. Empty IDs and creation times are filled in.
+func NewEvent(eventType, id string, data interface{}) (*Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event data: %w", err)
+	}
+	if id == "" {
+		id = fmt.Sprintf("evt_%d", time.Now().UnixNano())
+	}
+	return &Event{ID: id, Type: eventType, CreatedAt: time.Now().UTC(), Data: raw}, nil
+}
+
+// NewSignedRequest builds a POST request to url delivering event, signed with
+// secret at the current time.
+func NewSignedRequest(url, secret string, event *Event) (*http.Request, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(secret, body, time.Now()))
+	return req, nil
+}
@@ -0,0 +1,317 @@
+// Package webhooks receives KeywordsAI webhook deliveries. A Handler verifies
+// each request's signature and timestamp, rejects replays and dispatches the
+// decoded event to handlers registered per event type.
+//
+// Deliveries carry a SignatureHeader of the form "t=<unix seconds>,v1=<hex>",
+// where the signature is the HMAC-SHA256 of "<t>.<body>" under the endpoint's
+// shared secret. Several v1 values may be present while a secret is rotated.
+package webhooks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/internal/signing"
+)
+
+// SignatureHeader is the request header carrying the delivery signature.
+const SignatureHeader = "X-KeywordsAI-Signature"
+
+const (
+	defaultTolerance   = 5 * time.Minute
+	defaultMaxBodySize = 1 << 20
+)
+
+var (
+	ErrMissingSignature  = errors.New("webhooks: missing signature header")
+	ErrInvalidSignature  = errors.New("webhooks: malformed signature header")
+	ErrSignatureMismatch = errors.New("webhooks: no signature matches the payload")
+	ErrTimestampExpired  = errors.New("webhooks: timestamp outside tolerance")
+	ErrReplayed          = errors.New("webhooks: delivery already received")
+)
+
+// HandlerFunc processes a verified event. Returning an error makes the
+// Handler respond with 500 so the delivery is retried.
+type HandlerFunc func(ctx context.Context, event *Event) error
+
+// Handler is an http.Handler for webhook deliveries. It is safe for
+// concurrent use.
+type Handler struct {
+	secrets     [][]byte
+	tolerance   time.Duration
+	maxBodySize int64
+	replays     ReplayCache
+	onError     func(*http.Request, error)
+	now         func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string][]HandlerFunc
+	fallback HandlerFunc
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithTolerance sets how far a delivery's timestamp may be from the current
+// time. The default is five minutes.
+func WithTolerance(d time.Duration) Option {
+	return func(h *Handler) {
+		if d > 0 {
+			h.tolerance = d
+		}
+	}
+}
+
+// WithSecret accepts signatures from an additional secret, e.g. the previous
+// one while rotating.
+func WithSecret(secret string) Option {
+	return func(h *Handler) {
+		h.secrets = append(h.secrets, []byte(secret))
+	}
+}
+
+// WithMaxBodySize limits the request body size. The default is 1 MiB.
+func WithMaxBodySize(n int64) Option {
+	return func(h *Handler) {
+		if n > 0 {
+			h.maxBodySize = n
+		}
+	}
+}
+
+// WithReplayCache replaces the in-memory replay cache, e.g. with one shared
+// between instances.
+func WithReplayCache(c ReplayCache) Option {
+	return func(h *Handler) {
+		h.replays = c
+	}
+}
+
+// WithErrorHandler is called for every rejected delivery and failed event
+// handler, for logging.
+func WithErrorHandler(fn func(*http.Request, error)) Option {
+	return func(h *Handler) {
+		h.onError = fn
+	}
+}
+
+// NewHandler creates a Handler that verifies deliveries with secret.
+func NewHandler(secret string, opts ...Option) *Handler {
+	h := &Handler{
+		secrets:     [][]byte{[]byte(secret)},
+		tolerance:   defaultTolerance,
+		maxBodySize: defaultMaxBodySize,
+		now:         time.Now,
+		handlers:    make(map[string][]HandlerFunc),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.replays == nil {
+		h.replays = NewMemoryReplayCache(defaultReplayCapacity)
+	}
+	return h
+}
+
+// On registers fn for events of the given type. Handlers for the same type
+// run in registration order until one fails.
+func (h *Handler) On(eventType string, fn HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers[eventType] = append(h.handlers[eventType], fn)
+}
+
+// OnUnhandled registers fn for events with no type-specific handler. Without
+// it such events are acknowledged and ignored.
+func (h *Handler) OnUnhandled(fn HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fallback = fn
+}
+
+// OnAlert registers fn for alert.triggered and alert.resolved events.
+func (h *Handler) OnAlert(fn func(ctx context.Context, event *Event, alert *AlertEvent) error) {
+	typed := func(ctx context.Context, event *Event) error {
+		var alert AlertEvent
+		if err := event.Decode(&alert); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		return fn(ctx, event, &alert)
+	}
+	h.On(EventAlertTriggered, typed)
+	h.On(EventAlertResolved, typed)
+}
+
+// OnEvaluation registers fn for evaluation.completed events.
+func (h *Handler) OnEvaluation(fn func(ctx context.Context, event *Event, evaluation *EvaluationEvent) error) {
+	h.On(EventEvaluationCompleted, func(ctx context.Context, event *Event) error {
+		var evaluation EvaluationEvent
+		if err := event.Decode(&evaluation); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		return fn(ctx, event, &evaluation)
+	})
+}
+
+// OnLog registers fn for log.created events.
+func (h *Handler) OnLog(fn func(ctx context.Context, event *Event, log *LogEvent) error) {
+	h.On(EventLogCreated, func(ctx context.Context, event *Event) error {
+		var log LogEvent
+		if err := event.Decode(&log); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		return fn(ctx, event, &log)
+	})
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		h.reject(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("webhooks: failed to read body: %w", err))
+		return
+	}
+
+	key, timestamp, err := verifySignature(h.secrets, r.Header.Get(SignatureHeader), body, h.tolerance, h.now())
+	if err != nil {
+		status := http.StatusUnauthorized
+		if errors.Is(err, ErrMissingSignature) || errors.Is(err, ErrInvalidSignature) {
+			status = http.StatusBadRequest
+		}
+		h.reject(w, r, status, err)
+		return
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil || event.Type == "" {
+		h.reject(w, r, http.StatusBadRequest, errors.New("webhooks: invalid event payload"))
+		return
+	}
+
+	// A delivery is identified by its signed timestamp and body rather than
+	// by the signature that matched, so resending it with only one of several
+	// rotation signatures is still a replay.
+	if !h.replays.Add(key, timestamp.Add(h.tolerance)) {
+		h.reject(w, r, http.StatusConflict, ErrReplayed)
+		return
+	}
+
+	if err := h.dispatch(r.Context(), &event); err != nil {
+		// Let the sender retry the same delivery.
+		h.replays.Remove(key)
+		h.reject(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) dispatch(ctx context.Context, event *Event) error {
+	h.mu.RLock()
+	handlers := h.handlers[event.Type]
+	fallback := h.fallback
+	h.mu.RUnlock()
+
+	if len(handlers) == 0 && fallback != nil {
+		handlers = []HandlerFunc{fallback}
+	}
+	for _, fn := range handlers {
+		if err := fn(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Handler) reject(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if h.onError != nil {
+		h.onError(r, err)
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+// Verify checks a signature header against body without replay detection.
+// It is useful for receivers that do not use Handler.
+func Verify(secret, header string, body []byte, tolerance time.Duration) error {
+	_, _, err := verifySignature([][]byte{[]byte(secret)}, header, body, tolerance, time.Now())
+	return err
+}
+
+// verifySignature returns the delivery's replay key, a hash of the signed
+// payload, and the signed timestamp.
+func verifySignature(secrets [][]byte, header string, body []byte, tolerance time.Duration, now time.Time) (string, time.Time, error) {
+	if header == "" {
+		return "", time.Time{}, ErrMissingSignature
+	}
+	unix, signatures, err := parseHeader(header)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	timestamp := time.Unix(unix, 0)
+	if age := now.Sub(timestamp); age > tolerance || age < -tolerance {
+		return "", time.Time{}, ErrTimestampExpired
+	}
+
+	payload := signedPayload(unix, body)
+	for _, sig := range signatures {
+		for _, secret := range secrets {
+			if signing.VerifyHMAC(secret, payload, sig) == nil {
+				sum := sha256.Sum256(payload)
+				return hex.EncodeToString(sum[:]), timestamp, nil
+			}
+		}
+	}
+	return "", time.Time{}, ErrSignatureMismatch
+}
+
+func parseHeader(header string) (int64, []string, error) {
+	var (
+		timestamp  int64
+		signatures []string
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return 0, nil, ErrInvalidSignature
+		}
+		switch key {
+		case "t":
+			t, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return 0, nil, ErrInvalidSignature
+			}
+			timestamp = t
+		case "v1":
+			if _, err := hex.DecodeString(value); err != nil {
+				return 0, nil, ErrInvalidSignature
+			}
+			signatures = append(signatures, strings.ToLower(value))
+		}
+		// Unknown schemes are ignored so new ones can be added.
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return 0, nil, ErrInvalidSignature
+	}
+	return timestamp, signatures, nil
+}
+
+func signedPayload(timestamp int64, body []byte) []byte {
+	payload := strconv.AppendInt(nil, timestamp, 10)
+	payload = append(payload, '.')
+	return append(payload, body...)
+}
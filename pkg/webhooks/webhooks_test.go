@@ -0,0 +1,242 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_test_secret"
+
+func deliver(t *testing.T, h http.Handler, req *http.Request) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func signedRequest(t *testing.T, secret, eventType string, data interface{}) *http.Request {
+	t.Helper()
+	event, err := NewEvent(eventType, "evt_1", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := NewSignedRequest("/webhooks", secret, event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestHandlerDispatchesTypedEvents(t *testing.T) {
+	h := NewHandler(testSecret)
+
+	var gotAlert *AlertEvent
+	h.OnAlert(func(ctx context.Context, event *Event, alert *AlertEvent) error {
+		if event.Type != EventAlertTriggered {
+			t.Errorf("expected %s, got %s", EventAlertTriggered, event.Type)
+		}
+		gotAlert = alert
+		return nil
+	})
+	var gotLog *LogEvent
+	h.OnLog(func(ctx context.Context, event *Event, log *LogEvent) error {
+		gotLog = log
+		return nil
+	})
+
+	req := signedRequest(t, testSecret, EventAlertTriggered, AlertEvent{AlertID: "a1", Metric: "error_rate", Value: 0.2, Threshold: 0.1})
+	if code := deliver(t, h, req); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", code)
+	}
+	if gotAlert == nil || gotAlert.AlertID != "a1" || gotAlert.Value != 0.2 {
+		t.Errorf("unexpected alert: %+v", gotAlert)
+	}
+
+	req = signedRequest(t, testSecret, EventLogCreated, map[string]interface{}{"log_id": "log_1", "model": "gpt-4o"})
+	if code := deliver(t, h, req); code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", code)
+	}
+	if gotLog == nil || gotLog.LogID != "log_1" || gotLog.Model != "gpt-4o" {
+		t.Errorf("unexpected log: %+v", gotLog)
+	}
+}
+
+func TestHandlerUnhandledEvents(t *testing.T) {
+	h := NewHandler(testSecret)
+	if code := deliver(t, h, signedRequest(t, testSecret, "custom.event", nil)); code != http.StatusNoContent {
+		t.Fatalf("expected unhandled events to be acknowledged, got %d", code)
+	}
+
+	var got string
+	h.OnUnhandled(func(ctx context.Context, event *Event) error {
+		got = event.Type
+		return nil
+	})
+	deliver(t, h, signedRequest(t, testSecret, "custom.event", nil))
+	if got != "custom.event" {
+		t.Errorf("expected fallback to receive custom.event, got %q", got)
+	}
+}
+
+func TestHandlerRejectsInvalidDeliveries(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"id":"evt_1","type":"alert.triggered","data":{}}`)
+
+	tests := []struct {
+		name   string
+		method string
+		header string
+		want   int
+		err    error
+	}{
+		{"wrong method", http.MethodGet, Sign(testSecret, body, now), http.StatusMethodNotAllowed, nil},
+		{"missing signature", http.MethodPost, "", http.StatusBadRequest, ErrMissingSignature},
+		{"malformed signature", http.MethodPost, "v1=abc", http.StatusBadRequest, ErrInvalidSignature},
+		{"wrong secret", http.MethodPost, Sign("another secret", body, now), http.StatusUnauthorized, ErrSignatureMismatch},
+		{"expired", http.MethodPost, Sign(testSecret, body, now.Add(-10*time.Minute)), http.StatusUnauthorized, ErrTimestampExpired},
+		{"from the future", http.MethodPost, Sign(testSecret, body, now.Add(10*time.Minute)), http.StatusUnauthorized, ErrTimestampExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotErr error
+			h := NewHandler(testSecret, WithErrorHandler(func(r *http.Request, err error) { gotErr = err }))
+			h.On(EventAlertTriggered, func(ctx context.Context, event *Event) error {
+				t.Error("handler should not be called")
+				return nil
+			})
+
+			req := httptest.NewRequest(tt.method, "/webhooks", bytes.NewReader(body))
+			if tt.header != "" {
+				req.Header.Set(SignatureHeader, tt.header)
+			}
+			if code := deliver(t, h, req); code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, code)
+			}
+			if tt.err != nil && !errors.Is(gotErr, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, gotErr)
+			}
+		})
+	}
+}
+
+func TestHandlerRejectsTamperedBody(t *testing.T) {
+	h := NewHandler(testSecret)
+	body := []byte(`{"id":"evt_1","type":"alert.triggered","data":{"value":1}}`)
+	header := Sign(testSecret, body, time.Now())
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(bytes.Replace(body, []byte("1"), []byte("9"), 1)))
+	req.Header.Set(SignatureHeader, header)
+	if code := deliver(t, h, req); code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", code)
+	}
+}
+
+func TestHandlerRejectsReplays(t *testing.T) {
+	h := NewHandler(testSecret)
+	calls := 0
+	h.On(EventEvaluationCompleted, func(ctx context.Context, event *Event) error {
+		calls++
+		return nil
+	})
+
+	body := []byte(`{"id":"evt_1","type":"evaluation.completed","data":{}}`)
+	header := Sign(testSecret, body, time.Now())
+	for i, want := range []int{http.StatusNoContent, http.StatusConflict} {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+		req.Header.Set(SignatureHeader, header)
+		if code := deliver(t, h, req); code != want {
+			t.Errorf("delivery %d: expected %d, got %d", i, want, code)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestHandlerFailureAllowsRetry(t *testing.T) {
+	h := NewHandler(testSecret)
+	fail := true
+	h.On(EventEvaluationCompleted, func(ctx context.Context, event *Event) error {
+		if fail {
+			fail = false
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+
+	body := []byte(`{"id":"evt_1","type":"evaluation.completed","data":{}}`)
+	header := Sign(testSecret, body, time.Now())
+	for i, want := range []int{http.StatusInternalServerError, http.StatusNoContent} {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+		req.Header.Set(SignatureHeader, header)
+		if code := deliver(t, h, req); code != want {
+			t.Errorf("delivery %d: expected %d, got %d", i, want, code)
+		}
+	}
+}
+
+func TestHandlerSecretRotation(t *testing.T) {
+	h := NewHandler("new secret", WithSecret(testSecret))
+	if code := deliver(t, h, signedRequest(t, testSecret, EventLogCreated, nil)); code != http.StatusNoContent {
+		t.Errorf("expected old secret to be accepted, got %d", code)
+	}
+
+	// A header may carry signatures from both secrets.
+	body := []byte(`{"id":"evt_2","type":"log.created","data":{}}`)
+	now := time.Now()
+	_, current, _ := strings.Cut(Sign("new secret", body, now), ",")
+	header := Sign("unknown", body, now) + "," + current
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+	req.Header.Set(SignatureHeader, header)
+	if code := deliver(t, h, req); code != http.StatusNoContent {
+		t.Errorf("expected any matching signature to be accepted, got %d", code)
+	}
+}
+
+func TestHandlerRejectsReplaysAcrossSignatures(t *testing.T) {
+	h := NewHandler("new secret", WithSecret(testSecret))
+	body := []byte(`{"id":"evt_3","type":"log.created","data":{}}`)
+	now := time.Now()
+	old := Sign(testSecret, body, now)
+	_, current, _ := strings.Cut(Sign("new secret", body, now), ",")
+	send := func(header string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+		req.Header.Set(SignatureHeader, header)
+		return deliver(t, h, req)
+	}
+
+	if code := send(old + "," + current); code != http.StatusNoContent {
+		t.Fatalf("expected first delivery to succeed, got %d", code)
+	}
+	// Each signature on its own is the same delivery.
+	if code := send(old); code != http.StatusConflict {
+		t.Errorf("expected the old signature alone to be a replay, got %d", code)
+	}
+	t0, _, _ := strings.Cut(old, ",")
+	if code := send(t0 + "," + current); code != http.StatusConflict {
+		t.Errorf("expected the new signature alone to be a replay, got %d", code)
+	}
+}
+
+func TestHandlerBodyLimit(t *testing.T) {
+	h := NewHandler(testSecret, WithMaxBodySize(16))
+	if code := deliver(t, h, signedRequest(t, testSecret, EventLogCreated, nil)); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", code)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte("payload")
+	if err := Verify(testSecret, Sign(testSecret, body, time.Now()), body, time.Minute); err != nil {
+		t.Errorf("expected valid signature, got %v", err)
+	}
+	if err := Verify(testSecret, Sign(testSecret, body, time.Now().Add(-2*time.Minute)), body, time.Minute); !errors.Is(err, ErrTimestampExpired) {
+		t.Errorf("expected ErrTimestampExpired, got %v", err)
+	}
+}
@@ -41,17 +41,40 @@ cat > "$TEST_DIR/test_sharedlib.c" << 'EOF'
 #include <string.h>
 #include <dlfcn.h>
 
+// Error codes, mirrored from the generated header
+enum {
+    SIGNER_OK = 0,
+    SIGNER_ERR_INVALID_ARGUMENT = 1,
+    SIGNER_ERR_INVALID_KEY = 2,
+    SIGNER_ERR_INVALID_SIGNATURE = 3,
+    SIGNER_ERR_VERIFICATION_FAILED = 4,
+};
+
 // Function pointers for shared library functions
 typedef char* (*GetVersion_func)();
 typedef char* (*GetPlatform_func)();
-typedef char* (*Sign_func)(const char*);
-typedef int (*Verify_func)(const char*, const char*);
+typedef char* (*GetErrorMessage_func)(int);
+typedef int (*SignHMAC_func)(const char*, const void*, int, char**);
+typedef int (*VerifyHMAC_func)(const char*, const void*, int, const char*);
+typedef int (*SignEd25519_func)(const char*, const void*, int, char**);
+typedef int (*VerifyEd25519_func)(const char*, const void*, int, const char*);
+typedef int (*GenerateEd25519Key_func)(char**, char**);
 typedef void (*FreeString_func)(char*);
 
+static int test_passed = 1;
+
+static void expect(const char *name, int got, int want) {
+    if (got != want) {
+        fprintf(stderr, "ERROR: %s: expected code %d, got %d\n", name, want, got);
+        test_passed = 0;
+    } else {
+        printf("%s: PASSED\n", name);
+    }
+}
+
 int main() {
     void *handle;
     char *error;
-    int test_passed = 1;
 
     // Load the shared library
     #ifdef __APPLE__
@@ -71,8 +94,12 @@ int main() {
     // Load functions
     GetVersion_func GetVersion = (GetVersion_func) dlsym(handle, "GetVersion");
     GetPlatform_func GetPlatform = (GetPlatform_func) dlsym(handle, "GetPlatform");
-    Sign_func Sign = (Sign_func) dlsym(handle, "Sign");
-    Verify_func Verify = (Verify_func) dlsym(handle, "Verify");
+    GetErrorMessage_func GetErrorMessage = (GetErrorMessage_func) dlsym(handle, "GetErrorMessage");
+    SignHMAC_func SignHMAC = (SignHMAC_func) dlsym(handle, "SignHMAC");
+    VerifyHMAC_func VerifyHMAC = (VerifyHMAC_func) dlsym(handle, "VerifyHMAC");
+    SignEd25519_func SignEd25519 = (SignEd25519_func) dlsym(handle, "SignEd25519");
+    VerifyEd25519_func VerifyEd25519 = (VerifyEd25519_func) dlsym(handle, "VerifyEd25519");
+    GenerateEd25519Key_func GenerateEd25519Key = (GenerateEd25519Key_func) dlsym(handle, "GenerateEd25519Key");
     FreeString_func FreeString = (FreeString_func) dlsym(handle, "FreeString");
 
     if ((error = dlerror()) != NULL) {
@@ -85,8 +112,8 @@ int main() {
     printf("Testing GetVersion...\n");
     char *version = GetVersion();
     printf("Version: %s\n", version);
-    if (strcmp(version, "1.0.0") != 0) {
-        fprintf(stderr, "ERROR: Expected version '1.0.0', got '%s'\n", version);
+    if (strcmp(version, "2.0.0") != 0) {
+        fprintf(stderr, "ERROR: Expected version '2.0.0', got '%s'\n", version);
         test_passed = 0;
     }
     FreeString(version);
@@ -97,47 +124,53 @@ int main() {
     printf("Platform: %s\n", platform);
     FreeString(platform);
 
-    // Test Sign and Verify
-    printf("\nTesting Sign and Verify...\n");
-    const char *test_data = "Hello, World!";
-    char *signature = Sign(test_data);
-    printf("Data: %s\n", test_data);
+    // Test HMAC (RFC 4231 test case 1)
+    printf("\nTesting SignHMAC and VerifyHMAC...\n");
+    const char *hmac_key = "hex:0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b";
+    const char *test_data = "Hi There";
+    char *signature = NULL;
+    expect("SignHMAC", SignHMAC(hmac_key, test_data, strlen(test_data), &signature), SIGNER_OK);
     printf("Signature: %s\n", signature);
-
-    // Verify with correct signature
-    int verify_result = Verify(test_data, signature);
-    if (verify_result != 1) {
-        fprintf(stderr, "ERROR: Verification failed for correct signature\n");
+    if (strcmp(signature, "b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7") != 0) {
+        fprintf(stderr, "ERROR: Unexpected HMAC signature\n");
         test_passed = 0;
-    } else {
-        printf("Verification: PASSED\n");
     }
+    expect("VerifyHMAC valid", VerifyHMAC(hmac_key, test_data, strlen(test_data), signature), SIGNER_OK);
+    expect("VerifyHMAC tampered", VerifyHMAC(hmac_key, "Hi there", 8, signature), SIGNER_ERR_VERIFICATION_FAILED);
+    expect("VerifyHMAC wrong key", VerifyHMAC("another secret key!", test_data, strlen(test_data), signature), SIGNER_ERR_VERIFICATION_FAILED);
+    expect("VerifyHMAC malformed", VerifyHMAC(hmac_key, test_data, strlen(test_data), "invalid_signature"), SIGNER_ERR_INVALID_SIGNATURE);
+    FreeString(signature);
 
-    // Verify with incorrect signature
-    int verify_fail = Verify(test_data, "invalid_signature");
-    if (verify_fail != 0) {
-        fprintf(stderr, "ERROR: Verification passed for incorrect signature\n");
-        test_passed = 0;
-    } else {
-        printf("Invalid signature test: PASSED\n");
-    }
+    char *unused = NULL;
+    expect("SignHMAC short key", SignHMAC("short", test_data, strlen(test_data), &unused), SIGNER_ERR_INVALID_KEY);
 
-    FreeString(signature);
+    // Payloads may contain NUL bytes
+    const char binary[] = {'a', '\0', 'b'};
+    char *binary_sig = NULL;
+    expect("SignHMAC binary", SignHMAC(hmac_key, binary, sizeof(binary), &binary_sig), SIGNER_OK);
+    expect("VerifyHMAC binary truncated", VerifyHMAC(hmac_key, binary, 1, binary_sig), SIGNER_ERR_VERIFICATION_FAILED);
+    FreeString(binary_sig);
+
+    // Test Ed25519
+    printf("\nTesting Ed25519...\n");
+    char *private_key = NULL, *public_key = NULL, *ed_sig = NULL;
+    expect("GenerateEd25519Key", GenerateEd25519Key(&private_key, &public_key), SIGNER_OK);
+    expect("SignEd25519", SignEd25519(private_key, test_data, strlen(test_data), &ed_sig), SIGNER_OK);
+    expect("VerifyEd25519 valid", VerifyEd25519(public_key, test_data, strlen(test_data), ed_sig), SIGNER_OK);
+    expect("VerifyEd25519 tampered", VerifyEd25519(public_key, "Hi there", 8, ed_sig), SIGNER_ERR_VERIFICATION_FAILED);
+    expect("VerifyEd25519 private key as public", VerifyEd25519(private_key, test_data, strlen(test_data), ed_sig), SIGNER_ERR_INVALID_KEY);
+    FreeString(ed_sig);
+    FreeString(private_key);
+    FreeString(public_key);
 
     // Test NULL handling
     printf("\nTesting NULL handling...\n");
-    char *null_sign = Sign(NULL);
-    if (strlen(null_sign) != 0) {
-        fprintf(stderr, "ERROR: Sign(NULL) should return empty string\n");
-        test_passed = 0;
-    }
-    FreeString(null_sign);
+    expect("SignHMAC(NULL)", SignHMAC(NULL, test_data, 8, &unused), SIGNER_ERR_INVALID_ARGUMENT);
+    expect("VerifyHMAC(NULL)", VerifyHMAC(hmac_key, NULL, 8, NULL), SIGNER_ERR_INVALID_ARGUMENT);
 
-    int null_verify = Verify(NULL, NULL);
-    if (null_verify != 0) {
-        fprintf(stderr, "ERROR: Verify(NULL, NULL) should return 0\n");
-        test_passed = 0;
-    }
+    char *message = GetErrorMessage(SIGNER_ERR_VERIFICATION_FAILED);
+    printf("Error message: %s\n", message);
+    FreeString(message);
 
     // Close the library
     dlclose(handle);
@@ -156,74 +189,84 @@ EOF
 cat > "$TEST_DIR/test_sharedlib.py" << 'EOF'
 #!/usr/bin/env python3
 import ctypes
+import hashlib
+import hmac
 import platform
 import sys
 import os
 
+SIGNER_OK = 0
+SIGNER_ERR_VERIFICATION_FAILED = 4
+
 def main():
     # Load the shared library
     if platform.system() == "Darwin":
         lib_path = "./build/signer-arm64.dylib"
     else:
         lib_path = "./build/signer-amd64.so"
-    
+
     if not os.path.exists(lib_path):
         print(f"Error: Shared library not found at {lib_path}")
         return 1
-    
+
     try:
         lib = ctypes.CDLL(lib_path)
     except Exception as e:
         print(f"Error loading shared library: {e}")
         return 1
-    
-    # Define function signatures
-    lib.GetVersion.restype = ctypes.c_char_p
-    lib.GetPlatform.restype = ctypes.c_char_p
-    lib.Sign.argtypes = [ctypes.c_char_p]
-    lib.Sign.restype = ctypes.c_char_p
-    lib.Verify.argtypes = [ctypes.c_char_p, ctypes.c_char_p]
-    lib.Verify.restype = ctypes.c_int
-    lib.FreeString.argtypes = [ctypes.c_char_p]
-    
+
+    # Define function signatures. Returned strings are kept as c_void_p so
+    # they can be passed back to FreeString.
+    lib.GetVersion.restype = ctypes.c_void_p
+    lib.SignHMAC.argtypes = [ctypes.c_char_p, ctypes.c_char_p, ctypes.c_int, ctypes.POINTER(ctypes.c_void_p)]
+    lib.SignHMAC.restype = ctypes.c_int
+    lib.VerifyHMAC.argtypes = [ctypes.c_char_p, ctypes.c_char_p, ctypes.c_int, ctypes.c_char_p]
+    lib.VerifyHMAC.restype = ctypes.c_int
+    lib.FreeString.argtypes = [ctypes.c_void_p]
+
     test_passed = True
-    
+
     # Test GetVersion
     print("Testing GetVersion...")
-    version = lib.GetVersion().decode('utf-8')
+    ptr = lib.GetVersion()
+    version = ctypes.string_at(ptr).decode('utf-8')
+    lib.FreeString(ptr)
     print(f"Version: {version}")
-    if version != "1.0.0":
-        print(f"ERROR: Expected version '1.0.0', got '{version}'")
+    if version != "2.0.0":
+        print(f"ERROR: Expected version '2.0.0', got '{version}'")
         test_passed = False
-    
-    # Test GetPlatform
-    print("\nTesting GetPlatform...")
-    platform_str = lib.GetPlatform().decode('utf-8')
-    print(f"Platform: {platform_str}")
-    
-    # Test Sign and Verify
-    print("\nTesting Sign and Verify...")
+
+    # Test SignHMAC against Python's hmac module
+    print("\nTesting SignHMAC and VerifyHMAC...")
+    key = b"a shared secret for tests"
     test_data = b"Hello, World!"
-    signature = lib.Sign(test_data).decode('utf-8')
-    print(f"Data: {test_data.decode('utf-8')}")
+    out = ctypes.c_void_p()
+    code = lib.SignHMAC(key, test_data, len(test_data), ctypes.byref(out))
+    signature = ctypes.string_at(out).decode('utf-8')
+    lib.FreeString(out)
+    expected = hmac.new(key, test_data, hashlib.sha256).hexdigest()
     print(f"Signature: {signature}")
-    
+    if code != SIGNER_OK or signature != expected:
+        print(f"ERROR: Expected {expected}, got {signature} (code {code})")
+        test_passed = False
+    else:
+        print("Signature matches Python hmac: PASSED")
+
     # Verify with correct signature
-    verify_result = lib.Verify(test_data, signature.encode('utf-8'))
-    if verify_result != 1:
+    if lib.VerifyHMAC(key, test_data, len(test_data), signature.encode('utf-8')) != SIGNER_OK:
         print("ERROR: Verification failed for correct signature")
         test_passed = False
     else:
         print("Verification: PASSED")
-    
-    # Verify with incorrect signature
-    verify_fail = lib.Verify(test_data, b"invalid_signature")
-    if verify_fail != 0:
-        print("ERROR: Verification passed for incorrect signature")
+
+    # An unkeyed SHA-256 digest must not verify
+    forged = hashlib.sha256(test_data).hexdigest().encode('utf-8')
+    if lib.VerifyHMAC(key, test_data, len(test_data), forged) != SIGNER_ERR_VERIFICATION_FAILED:
+        print("ERROR: Verification passed for unkeyed SHA-256 signature")
         test_passed = False
     else:
-        print("Invalid signature test: PASSED")
-    
+        print("Forged signature test: PASSED")
+
     if test_passed:
         print("\n✅ All Python tests passed!")
         return 0
@@ -259,6 +302,232 @@ else
     echo -e "${YELLOW}Python not found, skipping Python tests${NC}"
 fi
 
+# SDK tests: a C program built against the generated header exercises the
+# client bindings against a local mock API server.
+if command -v python3 &> /dev/null; then
+    echo -e "\n${YELLOW}Running SDK tests...${NC}"
+
+    cat > "$TEST_DIR/mock_server.py" << 'EOF'
+#!/usr/bin/env python3
+import json
+import sys
+import threading
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+
+lock = threading.Lock()
+stats = {"logs": 0}
+
+class Handler(BaseHTTPRequestHandler):
+    def log_message(self, *args):
+        pass
+
+    def reply(self, status, body):
+        data = json.dumps(body).encode()
+        self.send_response(status)
+        self.send_header("Content-Type", "application/json")
+        self.send_header("Content-Length", str(len(data)))
+        self.end_headers()
+        self.wfile.write(data)
+
+    def authorized(self):
+        if self.headers.get("Authorization") != "Bearer test-key":
+            self.reply(401, {"detail": "invalid api key"})
+            return False
+        return True
+
+    def do_GET(self):
+        if self.path == "/stats":
+            with lock:
+                return self.reply(200, stats)
+        if not self.authorized():
+            return
+        if self.path == "/api/prompts/prompt-1":
+            return self.reply(200, {"id": "prompt-1", "name": "Greeting",
+                                    "created_at": "2025-01-01T00:00:00Z",
+                                    "updated_at": "2025-01-01T00:00:00Z"})
+        self.reply(404, {"detail": "prompt not found"})
+
+    def do_POST(self):
+        if not self.authorized():
+            return
+        body = json.loads(self.rfile.read(int(self.headers.get("Content-Length", 0))) or b"null")
+        if self.path == "/api/request-logs/create/":
+            with lock:
+                stats["logs"] += 1
+            return self.reply(200, {})
+        if self.path == "/api/request-logs/batch/create":
+            with lock:
+                stats["logs"] += len(body["logs"])
+            return self.reply(200, {})
+        if self.path == "/api/temporary-keys":
+            return self.reply(200, {"id": "key-1", "key": "kw-temp-123", "name": body.get("name"),
+                                    "expires_at": body["expires_at"],
+                                    "created_at": "2025-01-01T00:00:00Z", "is_active": True,
+                                    "usage_count": 0})
+        self.reply(404, {"detail": "not found"})
+
+server = ThreadingHTTPServer(("127.0.0.1", 0), Handler)
+with open(sys.argv[1], "w") as f:
+    f.write(str(server.server_address[1]))
+server.serve_forever()
+EOF
+
+    cat > "$TEST_DIR/test_sdk.c" << 'EOF'
+#include <pthread.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include "libsigner.h"
+
+#define THREADS 4
+#define LOGS_PER_THREAD 25
+
+static int test_passed = 1;
+
+static void expect(const char *name, int got, int want, char *error) {
+    if (got != want) {
+        fprintf(stderr, "ERROR: %s: expected code %d, got %d (%s)\n", name, want, got, error ? error : "no error");
+        test_passed = 0;
+    } else {
+        printf("%s: PASSED\n", name);
+    }
+}
+
+static void expect_contains(const char *name, const char *s, const char *want) {
+    if (s == NULL || strstr(s, want) == NULL) {
+        fprintf(stderr, "ERROR: %s: expected %s in %s\n", name, want, s ? s : "NULL");
+        test_passed = 0;
+    } else {
+        printf("%s: PASSED\n", name);
+    }
+}
+
+static void *enqueue_logs(void *arg) {
+    uintptr_t client = *(uintptr_t *)arg;
+    for (int i = 0; i < LOGS_PER_THREAD; i++) {
+        char *error = NULL;
+        int code = EnqueueLog(client, "{\"model\":\"gpt-4o\",\"completion_message\":{\"role\":\"assistant\",\"content\":\"hi\"}}", &error);
+        if (code != KWAI_OK) {
+            fprintf(stderr, "ERROR: EnqueueLog: code %d (%s)\n", code, error ? error : "no error");
+            test_passed = 0;
+        }
+        if (error) FreeString(error);
+    }
+    return NULL;
+}
+
+int main(int argc, char **argv) {
+    if (argc < 2) {
+        fprintf(stderr, "usage: %s BASE_URL\n", argv[0]);
+        return 1;
+    }
+
+    char config[512];
+    snprintf(config, sizeof(config),
+        "{\"api_key\":\"test-key\",\"base_url\":\"%s\",\"timeout_seconds\":5,"
+        "\"batch\":{\"size\":10,\"flush_interval_ms\":60000,\"queue_size\":1000}}", argv[1]);
+
+    uintptr_t client = 0;
+    char *error = NULL;
+    char *result = NULL;
+
+    printf("Testing client lifecycle...\n");
+    expect("NewClient", NewClient(config, &client, &error), KWAI_OK, error);
+    expect("NewClient invalid JSON", NewClient("{", &client, &error), KWAI_ERR_INVALID_JSON, NULL);
+    expect_contains("NewClient error JSON", error, "\"code\":3");
+    FreeString(error);
+    error = NULL;
+
+    printf("\nTesting logs...\n");
+    expect("CreateLog", CreateLog(client, "{\"model\":\"gpt-4o\"}", &error), KWAI_OK, error);
+    expect("BatchCreateLogs", BatchCreateLogs(client, "[{\"model\":\"gpt-4o\"},{\"model\":\"gpt-4o-mini\"}]", &error), KWAI_OK, error);
+    expect("CreateLog invalid JSON", CreateLog(client, "not json", &error), KWAI_ERR_INVALID_JSON, NULL);
+    FreeString(error);
+    error = NULL;
+
+    pthread_t threads[THREADS];
+    for (int i = 0; i < THREADS; i++) {
+        pthread_create(&threads[i], NULL, enqueue_logs, &client);
+    }
+    for (int i = 0; i < THREADS; i++) {
+        pthread_join(threads[i], NULL);
+    }
+    printf("EnqueueLog from %d threads: done\n", THREADS);
+    expect("FlushLogs", FlushLogs(client, 5000, &error), KWAI_OK, error);
+
+    printf("\nTesting prompts...\n");
+    expect("GetPrompt", GetPrompt(client, "prompt-1", &result, &error), KWAI_OK, error);
+    expect_contains("GetPrompt result", result, "\"name\":\"Greeting\"");
+    FreeString(result);
+    result = NULL;
+    expect("GetPrompt missing", GetPrompt(client, "missing", &result, &error), KWAI_ERR_API, NULL);
+    expect_contains("GetPrompt error status", error, "\"status_code\":404");
+    FreeString(error);
+    error = NULL;
+
+    printf("\nTesting keys...\n");
+    expect("CreateTemporaryKey", CreateTemporaryKey(client,
+        "{\"name\":\"e2e\",\"expires_at\":\"2030-01-01T00:00:00Z\",\"usage_limit\":10}", &result, &error), KWAI_OK, error);
+    expect_contains("CreateTemporaryKey result", result, "\"key\":\"kw-temp-123\"");
+    FreeString(result);
+    result = NULL;
+
+    printf("\nTesting authentication errors...\n");
+    uintptr_t bad_client = 0;
+    snprintf(config, sizeof(config), "{\"api_key\":\"wrong-key\",\"base_url\":\"%s\"}", argv[1]);
+    expect("NewClient wrong key", NewClient(config, &bad_client, &error), KWAI_OK, error);
+    expect("CreateLog unauthorized", CreateLog(bad_client, "{\"model\":\"gpt-4o\"}", &error), KWAI_ERR_API, NULL);
+    expect_contains("CreateLog error status", error, "\"status_code\":401");
+    FreeString(error);
+    error = NULL;
+    expect("FreeClient wrong key", FreeClient(bad_client, 1000, &error), KWAI_OK, error);
+
+    printf("\nTesting handles...\n");
+    expect("FreeClient", FreeClient(client, 5000, &error), KWAI_OK, error);
+    expect("CreateLog after FreeClient", CreateLog(client, "{}", &error), KWAI_ERR_INVALID_HANDLE, NULL);
+    FreeString(error);
+    error = NULL;
+    expect("FreeClient twice", FreeClient(client, 0, NULL), KWAI_ERR_INVALID_HANDLE, NULL);
+    expect("FlushLogs zero handle", FlushLogs(0, 0, NULL), KWAI_ERR_INVALID_HANDLE, NULL);
+
+    if (test_passed) {
+        printf("\n✅ All SDK tests passed!\n");
+        return 0;
+    }
+    printf("\n❌ Some SDK tests failed!\n");
+    return 1;
+}
+EOF
+
+    cp "${SHARED_LIB%.*}.h" "$TEST_DIR/libsigner.h"
+    gcc -o "$TEST_DIR/test_sdk" "$TEST_DIR/test_sdk.c" -I"$TEST_DIR" "$SHARED_LIB" -lpthread
+
+    python3 "$TEST_DIR/mock_server.py" "$TEST_DIR/port" &
+    SERVER_PID=$!
+    trap 'kill $SERVER_PID 2>/dev/null || true' EXIT
+    for _ in $(seq 50); do
+        [ -s "$TEST_DIR/port" ] && break
+        sleep 0.1
+    done
+    BASE_URL="http://127.0.0.1:$(cat "$TEST_DIR/port")"
+
+    if ! "$TEST_DIR/test_sdk" "$BASE_URL"; then
+        echo -e "${RED}SDK tests failed!${NC}"
+        exit 1
+    fi
+
+    # 1 created, 2 batched and 100 enqueued from C threads
+    LOGS_RECEIVED=$(python3 -c "import json, urllib.request; print(json.load(urllib.request.urlopen('$BASE_URL/stats'))['logs'])")
+    if [ "$LOGS_RECEIVED" != "103" ]; then
+        echo -e "${RED}SDK tests failed: server received $LOGS_RECEIVED logs, expected 103${NC}"
+        exit 1
+    fi
+    kill $SERVER_PID 2>/dev/null || true
+    echo -e "${GREEN}SDK tests passed!${NC}"
+else
+    echo -e "${YELLOW}Python not found, skipping SDK tests${NC}"
+fi
+
 # Clean up
 rm -rf "$TEST_DIR"
 
@@ -0,0 +1,341 @@
+package main
+
+/*
+#include <stdint.h>
+
+enum {
+	KWAI_OK = 0,
+	KWAI_ERR_INVALID_ARGUMENT = 1,
+	KWAI_ERR_INVALID_HANDLE = 2,
+	KWAI_ERR_INVALID_JSON = 3,
+	KWAI_ERR_API = 4,
+	KWAI_ERR_REQUEST = 5,
+	KWAI_ERR_QUEUE_FULL = 6,
+	KWAI_ERR_CLOSED = 7,
+};
+*/
+import "C"
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime/cgo"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rizome-dev/go-keywordsai/pkg/client"
+	"github.com/rizome-dev/go-keywordsai/pkg/keys"
+	"github.com/rizome-dev/go-keywordsai/pkg/logs"
+	"github.com/rizome-dev/go-keywordsai/pkg/prompts"
+	"github.com/rizome-dev/go-keywordsai/pkg/types"
+)
+
+// The SDK functions below operate on an opaque client handle created by
+// NewClient and released by FreeClient. Handles may be shared between
+// threads. Each function returns a KWAI_* code; on failure *error, when
+// non-NULL, receives a JSON object {"code", "message", "status_code"} that
+// the caller frees with FreeString, as with every returned string.
+
+// clientConfig is the JSON accepted by NewClient. Empty fields fall back to
+// the environment and the Go client's defaults.
+type clientConfig struct {
+	APIKey         string  `json:"api_key"`
+	BaseURL        string  `json:"base_url"`
+	TimeoutSeconds float64 `json:"timeout_seconds"`
+	Batch          struct {
+		Size            int `json:"size"`
+		FlushIntervalMS int `json:"flush_interval_ms"`
+		QueueSize       int `json:"queue_size"`
+	} `json:"batch"`
+}
+
+type sdkClient struct {
+	logs    *logs.Service
+	batcher *logs.Batcher
+	prompts *prompts.Service
+	keys    *keys.Service
+	freed   atomic.Bool
+
+	mu          sync.Mutex
+	batchErrors []error
+}
+
+func (c *sdkClient) recordBatchError(err error, _ []types.RequestLog) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batchErrors = append(c.batchErrors, err)
+}
+
+func (c *sdkClient) takeBatchErrors() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := errors.Join(c.batchErrors...)
+	c.batchErrors = nil
+	return err
+}
+
+// sdkError carries a KWAI_* code for errors raised by the bindings.
+type sdkError struct {
+	code    C.int
+	message string
+}
+
+func (e *sdkError) Error() string { return e.message }
+
+// NewClient creates a client from a JSON config and writes its handle.
+//
+//export NewClient
+func NewClient(configJSON *C.char, handle *C.uintptr_t, errOut **C.char) C.int {
+	if handle == nil {
+		return fail(errOut, &sdkError{C.KWAI_ERR_INVALID_ARGUMENT, "handle must not be NULL"})
+	}
+	var cfg clientConfig
+	if configJSON != nil {
+		if err := json.Unmarshal([]byte(C.GoString(configJSON)), &cfg); err != nil {
+			return fail(errOut, &sdkError{C.KWAI_ERR_INVALID_JSON, "invalid config: " + err.Error()})
+		}
+	}
+
+	var opts []interface{}
+	if cfg.APIKey != "" {
+		opts = append(opts, cfg.APIKey)
+	}
+	if cfg.BaseURL != "" {
+		opts = append(opts, client.WithBaseURL(cfg.BaseURL))
+	}
+	if cfg.TimeoutSeconds > 0 {
+		opts = append(opts, client.WithTimeout(time.Duration(cfg.TimeoutSeconds*float64(time.Second))))
+	}
+	c := client.New(opts...)
+
+	sdk := &sdkClient{
+		logs:    logs.NewService(c),
+		prompts: prompts.NewService(c),
+		keys:    keys.NewService(c),
+	}
+	batchOpts := []logs.BatcherOption{logs.WithBatchErrorHandler(sdk.recordBatchError)}
+	if cfg.Batch.Size > 0 {
+		batchOpts = append(batchOpts, logs.WithBatchSize(cfg.Batch.Size))
+	}
+	if cfg.Batch.FlushIntervalMS > 0 {
+		batchOpts = append(batchOpts, logs.WithFlushInterval(time.Duration(cfg.Batch.FlushIntervalMS)*time.Millisecond))
+	}
+	if cfg.Batch.QueueSize > 0 {
+		batchOpts = append(batchOpts, logs.WithQueueSize(cfg.Batch.QueueSize))
+	}
+	sdk.batcher = logs.NewBatcher(sdk.logs, batchOpts...)
+
+	*handle = C.uintptr_t(cgo.NewHandle(sdk))
+	return C.KWAI_OK
+}
+
+// FreeClient submits queued logs, waiting up to timeoutMS milliseconds (0
+// waits indefinitely), and releases the handle. The handle must not be used
+// afterwards. Logs that could not be submitted are reported in *error.
+//
+//export FreeClient
+func FreeClient(handle C.uintptr_t, timeoutMS C.int, errOut **C.char) C.int {
+	sdk, code := lookup(handle, errOut)
+	if sdk == nil {
+		return code
+	}
+	if !sdk.freed.CompareAndSwap(false, true) {
+		return fail(errOut, &sdkError{C.KWAI_ERR_INVALID_HANDLE, "invalid client handle"})
+	}
+	cgo.Handle(handle).Delete()
+
+	ctx, cancel := callContext(timeoutMS)
+	defer cancel()
+	err := errors.Join(sdk.batcher.Close(ctx), sdk.takeBatchErrors())
+	return result(errOut, err)
+}
+
+// CreateLog submits one RequestLog JSON object and waits for the response.
+//
+//export CreateLog
+func CreateLog(handle C.uintptr_t, logJSON *C.char, errOut **C.char) C.int {
+	sdk, code := lookup(handle, errOut)
+	if sdk == nil {
+		return code
+	}
+	var log types.RequestLog
+	if code := decode(logJSON, &log, errOut); code != C.KWAI_OK {
+		return code
+	}
+	return result(errOut, sdk.logs.Create(context.Background(), &log))
+}
+
+// BatchCreateLogs submits a JSON array of RequestLogs in one request.
+//
+//export BatchCreateLogs
+func BatchCreateLogs(handle C.uintptr_t, logsJSON *C.char, errOut **C.char) C.int {
+	sdk, code := lookup(handle, errOut)
+	if sdk == nil {
+		return code
+	}
+	var batch []types.RequestLog
+	if code := decode(logsJSON, &batch, errOut); code != C.KWAI_OK {
+		return code
+	}
+	return result(errOut, sdk.logs.BatchCreate(context.Background(), batch))
+}
+
+// EnqueueLog queues a RequestLog JSON object for background batch submission
+// and returns without waiting for the network.
+//
+//export EnqueueLog
+func EnqueueLog(handle C.uintptr_t, logJSON *C.char, errOut **C.char) C.int {
+	sdk, code := lookup(handle, errOut)
+	if sdk == nil {
+		return code
+	}
+	var log types.RequestLog
+	if code := decode(logJSON, &log, errOut); code != C.KWAI_OK {
+		return code
+	}
+	return result(errOut, sdk.batcher.Add(context.Background(), &log))
+}
+
+// FlushLogs submits queued logs, waiting up to timeoutMS milliseconds (0
+// waits indefinitely). It also reports background submissions that failed
+// since the last call.
+//
+//export FlushLogs
+func FlushLogs(handle C.uintptr_t, timeoutMS C.int, errOut **C.char) C.int {
+	sdk, code := lookup(handle, errOut)
+	if sdk == nil {
+		return code
+	}
+	ctx, cancel := callContext(timeoutMS)
+	defer cancel()
+	err := errors.Join(sdk.batcher.Flush(ctx), sdk.takeBatchErrors())
+	return result(errOut, err)
+}
+
+// GetPrompt writes the prompt with promptID as JSON to *resultJSON.
+//
+//export GetPrompt
+func GetPrompt(handle C.uintptr_t, promptID *C.char, resultJSON **C.char, errOut **C.char) C.int {
+	sdk, code := lookup(handle, errOut)
+	if sdk == nil {
+		return code
+	}
+	if promptID == nil || resultJSON == nil {
+		return fail(errOut, &sdkError{C.KWAI_ERR_INVALID_ARGUMENT, "prompt ID and result must not be NULL"})
+	}
+	prompt, err := sdk.prompts.Get(context.Background(), C.GoString(promptID))
+	if err != nil {
+		return fail(errOut, err)
+	}
+	return encode(prompt, resultJSON, errOut)
+}
+
+// CreateTemporaryKey mints a temporary API key from a JSON request with the
+// fields of keys.CreateKeyRequest and writes the key as JSON to *resultJSON.
+//
+//export CreateTemporaryKey
+func CreateTemporaryKey(handle C.uintptr_t, requestJSON *C.char, resultJSON **C.char, errOut **C.char) C.int {
+	sdk, code := lookup(handle, errOut)
+	if sdk == nil {
+		return code
+	}
+	if resultJSON == nil {
+		return fail(errOut, &sdkError{C.KWAI_ERR_INVALID_ARGUMENT, "result must not be NULL"})
+	}
+	var req keys.CreateKeyRequest
+	if code := decode(requestJSON, &req, errOut); code != C.KWAI_OK {
+		return code
+	}
+	key, err := sdk.keys.Create(context.Background(), &req)
+	if err != nil {
+		return fail(errOut, err)
+	}
+	return encode(key, resultJSON, errOut)
+}
+
+func lookup(handle C.uintptr_t, errOut **C.char) (sdk *sdkClient, code C.int) {
+	defer func() {
+		// cgo.Handle.Value panics for handles that were never issued or
+		// have been freed.
+		if recover() != nil {
+			sdk, code = nil, fail(errOut, &sdkError{C.KWAI_ERR_INVALID_HANDLE, "invalid client handle"})
+		}
+	}()
+	if handle == 0 {
+		return nil, fail(errOut, &sdkError{C.KWAI_ERR_INVALID_HANDLE, "invalid client handle"})
+	}
+	sdk, ok := cgo.Handle(handle).Value().(*sdkClient)
+	if !ok {
+		return nil, fail(errOut, &sdkError{C.KWAI_ERR_INVALID_HANDLE, "invalid client handle"})
+	}
+	return sdk, C.KWAI_OK
+}
+
+func decode(in *C.char, v interface{}, errOut **C.char) C.int {
+	if in == nil {
+		return fail(errOut, &sdkError{C.KWAI_ERR_INVALID_ARGUMENT, "JSON input must not be NULL"})
+	}
+	if err := json.Unmarshal([]byte(C.GoString(in)), v); err != nil {
+		return fail(errOut, &sdkError{C.KWAI_ERR_INVALID_JSON, "invalid JSON: " + err.Error()})
+	}
+	return C.KWAI_OK
+}
+
+func encode(v interface{}, out **C.char, errOut **C.char) C.int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fail(errOut, &sdkError{C.KWAI_ERR_INVALID_JSON, "failed to encode result: " + err.Error()})
+	}
+	*out = C.CString(string(data))
+	return C.KWAI_OK
+}
+
+func callContext(timeoutMS C.int) (context.Context, context.CancelFunc) {
+	if timeoutMS > 0 {
+		return context.WithTimeout(context.Background(), time.Duration(timeoutMS)*time.Millisecond)
+	}
+	return context.WithCancel(context.Background())
+}
+
+func result(errOut **C.char, err error) C.int {
+	if err == nil {
+		return C.KWAI_OK
+	}
+	return fail(errOut, err)
+}
+
+// fail maps err to a KWAI_* code and, if errOut is non-NULL, writes the
+// error JSON to it.
+func fail(errOut **C.char, err error) C.int {
+	payload := struct {
+		Code       int    `json:"code"`
+		Message    string `json:"message"`
+		StatusCode int    `json:"status_code,omitempty"`
+	}{Message: err.Error()}
+
+	var (
+		code   C.int = C.KWAI_ERR_REQUEST
+		sdkErr *sdkError
+		apiErr *client.APIError
+	)
+	switch {
+	case errors.As(err, &sdkErr):
+		code = sdkErr.code
+	case errors.As(err, &apiErr):
+		code = C.KWAI_ERR_API
+		payload.StatusCode = apiErr.StatusCode
+	case errors.Is(err, logs.ErrQueueFull):
+		code = C.KWAI_ERR_QUEUE_FULL
+	case errors.Is(err, logs.ErrBatcherClosed):
+		code = C.KWAI_ERR_CLOSED
+	}
+	payload.Code = int(code)
+
+	if errOut != nil {
+		data, _ := json.Marshal(payload)
+		*errOut = C.CString(string(data))
+	}
+	return code
+}
@@ -1,22 +1,41 @@
-// Package main provides a shared library for signing and verification.
+// Package main provides a shared library for signing and verification and a
+// C binding of the SDK client (see sdk.go).
+//
+// Payloads are passed as a pointer and length so they may contain NUL bytes.
+// Functions return one of the SIGNER_* codes below; strings written to output
+// parameters are allocated by the library and must be released with
+// FreeString. Keys are strings: PEM blocks, or raw bytes encoded as
+// "hex:<...>" or "base64:<...>". HMAC keys may also be given as plain text.
 package main
 
-// #include <stdlib.h>
+/*
+#include <stdlib.h>
+
+enum {
+	SIGNER_OK = 0,
+	SIGNER_ERR_INVALID_ARGUMENT = 1,
+	SIGNER_ERR_INVALID_KEY = 2,
+	SIGNER_ERR_INVALID_SIGNATURE = 3,
+	SIGNER_ERR_VERIFICATION_FAILED = 4,
+	SIGNER_ERR_INTERNAL = 5,
+};
+*/
 import "C"
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"runtime"
 	"unsafe"
+
+	"github.com/rizome-dev/go-keywordsai/internal/signing"
 )
 
 // GetVersion returns the version string.
 //
 //export GetVersion
 func GetVersion() *C.char {
-	return C.CString("1.0.0")
+	return C.CString("2.0.0")
 }
 
 // GetPlatform returns the platform string.
@@ -26,39 +45,108 @@ func GetPlatform() *C.char {
 	return C.CString(fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH))
 }
 
-// Sign creates a signature for the given data.
+// GetErrorMessage describes a SIGNER_* code.
 //
-//export Sign
-func Sign(data *C.char) *C.char {
-	if data == nil {
-		return C.CString("")
+//export GetErrorMessage
+func GetErrorMessage(code C.int) *C.char {
+	var msg string
+	switch code {
+	case C.SIGNER_OK:
+		msg = "ok"
+	case C.SIGNER_ERR_INVALID_ARGUMENT:
+		msg = "invalid argument"
+	case C.SIGNER_ERR_INVALID_KEY:
+		msg = signing.ErrInvalidKey.Error()
+	case C.SIGNER_ERR_INVALID_SIGNATURE:
+		msg = signing.ErrInvalidSignature.Error()
+	case C.SIGNER_ERR_VERIFICATION_FAILED:
+		msg = signing.ErrVerificationFailed.Error()
+	case C.SIGNER_ERR_INTERNAL:
+		msg = "internal error"
+	default:
+		msg = fmt.Sprintf("unknown error code %d", int(code))
 	}
+	return C.CString(msg)
+}
 
-	goData := C.GoString(data)
-	hash := sha256.Sum256([]byte(goData))
-	signature := hex.EncodeToString(hash[:])
-
-	return C.CString(signature)
+// SignHMAC writes the hex HMAC-SHA256 of data under key to *signature.
+//
+//export SignHMAC
+func SignHMAC(key *C.char, data unsafe.Pointer, dataLen C.int, signature **C.char) C.int {
+	payload, ok := goBytes(data, dataLen)
+	if key == nil || !ok || signature == nil {
+		return C.SIGNER_ERR_INVALID_ARGUMENT
+	}
+	k, err := signing.ParseHMACKey(C.GoString(key))
+	if err != nil {
+		return errorCode(err)
+	}
+	*signature = C.CString(signing.SignHMAC(k, payload))
+	return C.SIGNER_OK
 }
 
-// Verify checks if the signature matches the data.
+// VerifyHMAC checks a hex HMAC-SHA256 signature in constant time. It returns
+// SIGNER_OK when the signature is valid.
 //
-//export Verify
-func Verify(data, signature *C.char) C.int {
-	if data == nil || signature == nil {
-		return 0
+//export VerifyHMAC
+func VerifyHMAC(key *C.char, data unsafe.Pointer, dataLen C.int, signature *C.char) C.int {
+	payload, ok := goBytes(data, dataLen)
+	if key == nil || !ok || signature == nil {
+		return C.SIGNER_ERR_INVALID_ARGUMENT
+	}
+	k, err := signing.ParseHMACKey(C.GoString(key))
+	if err != nil {
+		return errorCode(err)
 	}
+	return errorCode(signing.VerifyHMAC(k, payload, C.GoString(signature)))
+}
 
-	goData := C.GoString(data)
-	goSignature := C.GoString(signature)
+// SignEd25519 writes the hex Ed25519 signature of data to *signature.
+//
+//export SignEd25519
+func SignEd25519(privateKey *C.char, data unsafe.Pointer, dataLen C.int, signature **C.char) C.int {
+	payload, ok := goBytes(data, dataLen)
+	if privateKey == nil || !ok || signature == nil {
+		return C.SIGNER_ERR_INVALID_ARGUMENT
+	}
+	k, err := signing.ParseEd25519PrivateKey(C.GoString(privateKey))
+	if err != nil {
+		return errorCode(err)
+	}
+	*signature = C.CString(signing.SignEd25519(k, payload))
+	return C.SIGNER_OK
+}
 
-	hash := sha256.Sum256([]byte(goData))
-	expectedSignature := hex.EncodeToString(hash[:])
+// VerifyEd25519 checks a hex Ed25519 signature. It returns SIGNER_OK when the
+// signature is valid.
+//
+//export VerifyEd25519
+func VerifyEd25519(publicKey *C.char, data unsafe.Pointer, dataLen C.int, signature *C.char) C.int {
+	payload, ok := goBytes(data, dataLen)
+	if publicKey == nil || !ok || signature == nil {
+		return C.SIGNER_ERR_INVALID_ARGUMENT
+	}
+	k, err := signing.ParseEd25519PublicKey(C.GoString(publicKey))
+	if err != nil {
+		return errorCode(err)
+	}
+	return errorCode(signing.VerifyEd25519(k, payload, C.GoString(signature)))
+}
 
-	if expectedSignature == goSignature {
-		return 1
+// GenerateEd25519Key writes a new key pair as PEM blocks.
+//
+//export GenerateEd25519Key
+func GenerateEd25519Key(privateKey, publicKey **C.char) C.int {
+	if privateKey == nil || publicKey == nil {
+		return C.SIGNER_ERR_INVALID_ARGUMENT
+	}
+	priv, pub, err := signing.GenerateEd25519()
+	if err != nil {
+		return C.SIGNER_ERR_INTERNAL
 	}
-	return 0
+	*privateKey = C.CString(priv)
+	*publicKey = C.CString(pub)
+	return C.SIGNER_OK
 }
 
 // FreeString frees a C string allocated by the library.
@@ -68,6 +156,31 @@ func FreeString(str *C.char) {
 	C.free(unsafe.Pointer(str))
 }
 
+// goBytes copies a C buffer. A NULL pointer is allowed for an empty payload.
+func goBytes(data unsafe.Pointer, n C.int) ([]byte, bool) {
+	if n < 0 || (data == nil && n != 0) {
+		return nil, false
+	}
+	if n == 0 {
+		return []byte{}, true
+	}
+	return C.GoBytes(data, n), true
+}
+
+func errorCode(err error) C.int {
+	switch {
+	case err == nil:
+		return C.SIGNER_OK
+	case errors.Is(err, signing.ErrInvalidKey):
+		return C.SIGNER_ERR_INVALID_KEY
+	case errors.Is(err, signing.ErrInvalidSignature):
+		return C.SIGNER_ERR_INVALID_SIGNATURE
+	case errors.Is(err, signing.ErrVerificationFailed):
+		return C.SIGNER_ERR_VERIFICATION_FAILED
+	}
+	return C.SIGNER_ERR_INTERNAL
+}
+
 func main() {
 	// Required for building shared library
 }